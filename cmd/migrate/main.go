@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/config"
+	"github.com/bnursik/aitu-ad-final-back/internal/db"
+	mongorepo "github.com/bnursik/aitu-ad-final-back/internal/repository/mongo"
+)
+
+// migrations maps a migration name to its runner. Each runner must be safe to re-run.
+var migrations = map[string]func(ctx context.Context, m *mongorepo.Migrations) (int64, error){
+	"order-price-snapshots": func(ctx context.Context, m *mongorepo.Migrations) (int64, error) {
+		return m.BackfillOrderPriceSnapshots(ctx)
+	},
+	"money-decimal128": func(ctx context.Context, m *mongorepo.Migrations) (int64, error) {
+		return m.MoneyToDecimal(ctx)
+	},
+	"stock-opening-balances": func(ctx context.Context, m *mongorepo.Migrations) (int64, error) {
+		return m.StockOpeningBalances(ctx)
+	},
+	"product-list-fields": func(ctx context.Context, m *mongorepo.Migrations) (int64, error) {
+		return m.ProductListFields(ctx)
+	},
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall migration timeout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [-timeout d] <name>\n\navailable migrations:\n")
+		for name := range migrations {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", name)
+		}
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	run, ok := migrations[name]
+	if !ok {
+		log.Fatalf("unknown migration %q", name)
+	}
+
+	_ = godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config load: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := db.Connect(ctx, cfg.MongoURI)
+	if err != nil {
+		log.Fatalf("db connect: %v", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	n, err := run(ctx, mongorepo.NewMigrations(client.Database(cfg.DBName), cfg.BaseCurrency))
+	if err != nil {
+		log.Fatalf("migration %s: %v (updated %d before failure)", name, err, n)
+	}
+	log.Printf("migration %s: updated %d documents", name, n)
+}
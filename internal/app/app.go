@@ -6,13 +6,27 @@ import (
 
 	"github.com/bnursik/aitu-ad-final-back/internal/config"
 	"github.com/bnursik/aitu-ad-final-back/internal/db"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
 	"github.com/bnursik/aitu-ad-final-back/internal/http/handlers"
 	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
+	"github.com/bnursik/aitu-ad-final-back/internal/notifier/filenotifier"
+	"github.com/bnursik/aitu-ad-final-back/internal/notifier/lognotifier"
+	"github.com/bnursik/aitu-ad-final-back/internal/payments/fakeprovider"
 	mongorepo "github.com/bnursik/aitu-ad-final-back/internal/repository/mongo"
+	cartsvc "github.com/bnursik/aitu-ad-final-back/internal/services/cart"
 	categoriessvc "github.com/bnursik/aitu-ad-final-back/internal/services/categories"
+	currenciessvc "github.com/bnursik/aitu-ad-final-back/internal/services/currencies"
+	deliverysvc "github.com/bnursik/aitu-ad-final-back/internal/services/delivery"
+	invoicessvc "github.com/bnursik/aitu-ad-final-back/internal/services/invoices"
 	orderssvc "github.com/bnursik/aitu-ad-final-back/internal/services/orders"
+	paymentssvc "github.com/bnursik/aitu-ad-final-back/internal/services/payments"
 	productssvc "github.com/bnursik/aitu-ad-final-back/internal/services/products"
+	promotionssvc "github.com/bnursik/aitu-ad-final-back/internal/services/promotions"
+	reservationssvc "github.com/bnursik/aitu-ad-final-back/internal/services/reservations"
+	returnssvc "github.com/bnursik/aitu-ad-final-back/internal/services/returns"
 	statisticssvc "github.com/bnursik/aitu-ad-final-back/internal/services/statistics"
+	stocksvc "github.com/bnursik/aitu-ad-final-back/internal/services/stock"
+	taxessvc "github.com/bnursik/aitu-ad-final-back/internal/services/taxes"
 	userssvc "github.com/bnursik/aitu-ad-final-back/internal/services/users"
 	wishlistsvc "github.com/bnursik/aitu-ad-final-back/internal/services/wishlist"
 )
@@ -32,6 +46,9 @@ func Build(cfg *config.Config) (*Container, error) {
 
 	jwtIssuer := middleware.NewJWT(cfg.JWTSecret, 24*time.Hour)
 
+	idempotencyRepo := mongorepo.NewIdempotencyRepo(dbase)
+	_ = idempotencyRepo.EnsureIndexes(context.Background())
+
 	authSvc := userssvc.New(usersRepo, jwtIssuer)
 
 	authHandler := handlers.NewAuthHandler(authSvc)
@@ -40,15 +57,82 @@ func Build(cfg *config.Config) (*Container, error) {
 	categoriesSvc := categoriessvc.New(categoriesRepo, productsCounter)
 	categoriesHandler := handlers.NewCategoriesHandler(categoriesSvc)
 
-	productsRepo := mongorepo.NewProductsRepo(dbase)
-	productsSvc := productssvc.New(productsRepo)
-	productsHandler := handlers.NewProductsHandler(productsSvc)
+	currenciesRepo := mongorepo.NewCurrenciesRepo(dbase)
+	currenciesSvc := currenciessvc.New(currenciesRepo, cfg.BaseCurrency)
+	currenciesHandler := handlers.NewCurrenciesHandler(currenciesSvc)
+
+	unitOfWork := mongorepo.NewUnitOfWork(client)
+
+	stockRepo := mongorepo.NewStockRepo(dbase)
+	_ = stockRepo.EnsureIndexes(context.Background())
+	stockSvc := stocksvc.New(stockRepo)
+	stockHandler := handlers.NewStockHandler(stockSvc)
+
+	productsRepo := mongorepo.NewProductsRepo(dbase, cfg.BaseCurrency)
+	_ = productsRepo.EnsureIndexes(context.Background())
+	productsSvc := productssvc.New(productsRepo, stockRepo, unitOfWork, cfg.PriceFacetBreaks)
+	productsHandler := handlers.NewProductsHandler(productsSvc, currenciesSvc)
+
+	reservationsRepo := mongorepo.NewReservationsRepo(dbase)
+	_ = reservationsRepo.EnsureIndexes(context.Background())
+	reservationsSvc := reservationssvc.New(reservationsRepo, productsRepo, unitOfWork, cfg.ReservationTTL)
+
+	promotionsRepo := mongorepo.NewPromotionsRepo(dbase, cfg.BaseCurrency)
+	_ = promotionsRepo.EnsureIndexes(context.Background())
+	promotionsSvc := promotionssvc.New(promotionsRepo)
+	promotionsHandler := handlers.NewPromotionsHandler(promotionsSvc, cfg.BaseCurrency)
+
+	deliveryRepo := mongorepo.NewDeliveryRepo(dbase, cfg.BaseCurrency)
+	deliverySvc := deliverysvc.New(deliveryRepo)
+	deliveryHandler := handlers.NewDeliveryHandler(deliverySvc, cfg.BaseCurrency)
 
-	ordersRepo := mongorepo.NewOrdersRepo(dbase)
-	ordersSvc := orderssvc.New(ordersRepo, productsRepo)
+	taxesRepo := mongorepo.NewTaxesRepo(dbase)
+	taxesSvc := taxessvc.New(taxesRepo)
+	taxesHandler := handlers.NewTaxesHandler(taxesSvc)
+
+	ordersRepo := mongorepo.NewOrdersRepo(dbase, cfg.BaseCurrency)
+	_ = ordersRepo.EnsureIndexes(context.Background())
+	var stockAlerts products.Notifier = lognotifier.New(nil)
+	if cfg.StockAlerts == "file" {
+		stockAlerts = filenotifier.New(cfg.StockAlertsFile)
+	}
+	ordersSvc := orderssvc.New(orderssvc.Deps{
+		Orders:     ordersRepo,
+		Products:   productsRepo,
+		Stock:      stockRepo,
+		Promotions: promotionsRepo,
+		Users:      usersRepo,
+		Delivery:   deliveryRepo,
+		Taxes:      taxesRepo,
+		Rates:      currenciesSvc,
+		Holds:      reservationsSvc,
+		Notifier:   stockAlerts,
+		Tx:         unitOfWork,
+	}, cfg.OrderCancelWindow)
 	ordersHandler := handlers.NewOrdersHandler(ordersSvc)
 
-	statisticsRepo := mongorepo.NewStatisticsRepo(dbase)
+	paymentsRepo := mongorepo.NewPaymentsRepo(dbase, cfg.BaseCurrency)
+	_ = paymentsRepo.EnsureIndexes(context.Background())
+	paymentProvider := fakeprovider.New(cfg.PaymentsWebhookSecret)
+	paymentsSvc := paymentssvc.New(paymentsRepo, ordersSvc, paymentProvider, unitOfWork)
+	paymentsHandler := handlers.NewPaymentsHandler(paymentsSvc)
+
+	invoicesRepo := mongorepo.NewInvoicesRepo(dbase)
+	_ = invoicesRepo.EnsureIndexes(context.Background())
+	invoicesSvc := invoicessvc.New(invoicesRepo, ordersSvc, unitOfWork)
+	invoicesHandler := handlers.NewInvoicesHandler(invoicesSvc)
+
+	returnsRepo := mongorepo.NewReturnsRepo(dbase, cfg.BaseCurrency)
+	_ = returnsRepo.EnsureIndexes(context.Background())
+	returnsSvc := returnssvc.New(returnsRepo, ordersSvc, paymentsSvc, productsRepo, stockRepo, unitOfWork)
+	returnsHandler := handlers.NewReturnsHandler(returnsSvc)
+
+	cartRepo := mongorepo.NewCartRepo(dbase)
+	_ = cartRepo.EnsureIndexes(context.Background())
+	cartSvc := cartsvc.New(cartRepo, productsRepo, ordersSvc, reservationsSvc, unitOfWork, cfg.BaseCurrency)
+	cartHandler := handlers.NewCartHandler(cartSvc)
+
+	statisticsRepo := mongorepo.NewStatisticsRepo(dbase, cfg.BaseCurrency)
 	statisticsSvc := statisticssvc.New(statisticsRepo)
 	statisticsHandler := handlers.NewStatisticsHandler(statisticsSvc)
 
@@ -57,16 +141,39 @@ func Build(cfg *config.Config) (*Container, error) {
 	wishlistSvc := wishlistsvc.New(wishlistRepo, productsRepo)
 	wishlistHandler := handlers.NewWishlistHandler(wishlistSvc)
 
+	// expired holds are released in the background until shutdown
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	sweepDone := make(chan struct{})
+	go func() {
+		defer close(sweepDone)
+		reservationsSvc.Run(sweepCtx, cfg.ReservationSweepInterval)
+	}()
+
 	return &Container{
 		Auth: authHandler,
 		Shutdown: func(ctx context.Context) error {
+			stopSweep()
+			select {
+			case <-sweepDone:
+			case <-ctx.Done():
+			}
 			return client.Disconnect(ctx)
 		},
 		JWT:        jwtIssuer,
+		Idempotent: middleware.Idempotency(idempotencyRepo, cfg.IdempotencyTTL),
 		Now:        func() time.Time { return time.Now().UTC() },
 		Categories: categoriesHandler,
+		Currencies: currenciesHandler,
 		Products:   productsHandler,
+		Stock:      stockHandler,
 		Orders:     ordersHandler,
+		Cart:       cartHandler,
+		Payments:   paymentsHandler,
+		Invoices:   invoicesHandler,
+		Returns:    returnsHandler,
+		Promotions: promotionsHandler,
+		Delivery:   deliveryHandler,
+		Taxes:      taxesHandler,
 		Statistics: statisticsHandler,
 		Wishlist:   wishlistHandler,
 	}, nil
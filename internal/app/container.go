@@ -6,6 +6,7 @@ import (
 
 	"github.com/bnursik/aitu-ad-final-back/internal/http/handlers"
 	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
+	"github.com/gin-gonic/gin"
 )
 
 type Container struct {
@@ -16,8 +17,19 @@ type Container struct {
 	Now        func() time.Time
 	Categories *handlers.CategoriesHandler
 	JWT        *middleware.JWT
+	// Idempotent honors Idempotency-Key on authenticated POSTs; see middleware.Idempotency.
+	Idempotent gin.HandlerFunc
+	Currencies *handlers.CurrenciesHandler
 	Products   *handlers.ProductsHandler
+	Stock      *handlers.StockHandler
 	Orders     *handlers.OrdersHandler
+	Cart       *handlers.CartHandler
+	Payments   *handlers.PaymentsHandler
+	Invoices   *handlers.InvoicesHandler
+	Returns    *handlers.ReturnsHandler
+	Promotions *handlers.PromotionsHandler
+	Delivery   *handlers.DeliveryHandler
+	Taxes      *handlers.TaxesHandler
 	Statistics *handlers.StatisticsHandler
 	Wishlist   *handlers.WishlistHandler
 }
@@ -3,6 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 )
 
 type Config struct {
@@ -10,6 +15,27 @@ type Config struct {
 	DBName    string
 	JWTSecret string
 	Port      string
+
+	// OrderCancelWindow is how long after placing an order a customer may cancel it. 0 means no limit.
+	OrderCancelWindow time.Duration
+	// IdempotencyTTL is how long a stored Idempotency-Key response can be replayed.
+	IdempotencyTTL time.Duration
+	// PaymentsWebhookSecret signs webhooks from the payment provider. Empty rejects all webhooks.
+	PaymentsWebhookSecret string
+	// BaseCurrency is what prices are entered in and statistics are kept in.
+	// Changing it does not convert amounts already stored.
+	BaseCurrency money.Currency
+	// ReservationTTL is how long a cart line holds its stock after it was last changed.
+	ReservationTTL time.Duration
+	// ReservationSweepInterval is how often expired holds are released.
+	ReservationSweepInterval time.Duration
+	// StockAlerts picks where low-stock alerts go: "log" or "file".
+	StockAlerts string
+	// StockAlertsFile is the JSON Lines file alerts are appended to when StockAlerts is "file".
+	StockAlertsFile string
+	// PriceFacetBreaks are the base-currency prices the catalog's price facet
+	// is bucketed at, ascending.
+	PriceFacetBreaks []money.Money
 }
 
 func Load() (*Config, error) {
@@ -18,6 +44,8 @@ func Load() (*Config, error) {
 		DBName:    os.Getenv("DB_NAME"),
 		JWTSecret: os.Getenv("JWT_SECRET"),
 		Port:      os.Getenv("PORT"),
+
+		PaymentsWebhookSecret: os.Getenv("PAYMENTS_WEBHOOK_SECRET"),
 	}
 
 	if cfg.MongoURI == "" {
@@ -34,5 +62,76 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
 
+	cfg.OrderCancelWindow = 24 * time.Hour
+	if v := os.Getenv("ORDER_CANCEL_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("ORDER_CANCEL_WINDOW must be a non-negative duration like 24h")
+		}
+		cfg.OrderCancelWindow = d
+	}
+
+	cfg.IdempotencyTTL = 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("IDEMPOTENCY_TTL must be a positive duration like 24h")
+		}
+		cfg.IdempotencyTTL = d
+	}
+
+	cfg.BaseCurrency = money.Default
+	if v := os.Getenv("BASE_CURRENCY"); v != "" {
+		c, err := money.ParseCurrency(v)
+		if err != nil {
+			return nil, fmt.Errorf("BASE_CURRENCY must be an ISO 4217 code like KZT")
+		}
+		cfg.BaseCurrency = c
+	}
+
+	cfg.ReservationTTL = 15 * time.Minute
+	if v := os.Getenv("RESERVATION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("RESERVATION_TTL must be a positive duration like 15m")
+		}
+		cfg.ReservationTTL = d
+	}
+
+	cfg.ReservationSweepInterval = time.Minute
+	if v := os.Getenv("RESERVATION_SWEEP_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("RESERVATION_SWEEP_INTERVAL must be a positive duration like 1m")
+		}
+		cfg.ReservationSweepInterval = d
+	}
+
+	cfg.StockAlerts = "log"
+	if v := os.Getenv("STOCK_ALERTS"); v != "" {
+		if v != "log" && v != "file" {
+			return nil, fmt.Errorf("STOCK_ALERTS must be log or file")
+		}
+		cfg.StockAlerts = v
+	}
+	cfg.StockAlertsFile = os.Getenv("STOCK_ALERTS_FILE")
+	if cfg.StockAlertsFile == "" {
+		cfg.StockAlertsFile = "stock-alerts.jsonl"
+	}
+
+	breaks := os.Getenv("PRICE_FACET_BREAKS")
+	if breaks == "" {
+		breaks = "5000,10000,25000,50000,100000"
+	}
+	for _, v := range strings.Split(breaks, ",") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		m := money.FromMajor(n, cfg.BaseCurrency)
+		last := len(cfg.PriceFacetBreaks) - 1
+		if err != nil || !m.IsPositive() || last >= 0 && m.Cmp(cfg.PriceFacetBreaks[last]) <= 0 {
+			return nil, fmt.Errorf("PRICE_FACET_BREAKS must be ascending positive prices like 5000,10000,25000")
+		}
+		cfg.PriceFacetBreaks = append(cfg.PriceFacetBreaks, m)
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,14 @@
+package cart
+
+import "errors"
+
+var (
+	ErrInvalidID         = errors.New("invalid id")
+	ErrInvalidProduct    = errors.New("invalid product")
+	ErrInvalidQty        = errors.New("invalid quantity")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrItemNotFound      = errors.New("item not in cart")
+	ErrEmptyCart         = errors.New("cart is empty")
+	// ErrCurrencyMismatch means a product's price isn't in the base currency.
+	ErrCurrencyMismatch = errors.New("price is not in the base currency")
+)
@@ -0,0 +1,44 @@
+package cart
+
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+)
+
+type Item struct {
+	ProductID string
+	Quantity  int64
+	AddedAt   time.Time
+
+	// Filled from the live product on read; not stored.
+	ProductName string
+	UnitPrice   money.Money
+	LineTotal   money.Money
+	InStock     bool
+	// nil once the line's hold expired and was released
+	ReservedUntil *time.Time
+}
+
+type Cart struct {
+	UserID string
+	Items  []Item
+
+	TotalPrice money.Money
+
+	UpdatedAt time.Time
+}
+
+type AddItemInput struct {
+	ProductID string
+	Quantity  int64
+}
+
+// CheckoutInput carries the order options chosen at checkout; see orders.CreateInput for defaults.
+type CheckoutInput struct {
+	PromoCode        string
+	ShippingAddress  *orders.ShippingAddress
+	DeliveryMethodID string
+	Currency         money.Currency
+}
@@ -0,0 +1,12 @@
+package cart
+
+import "context"
+
+type Repo interface {
+	// Get returns the user's cart; a user without one gets an empty cart.
+	Get(ctx context.Context, userID string) (Cart, error)
+	// SetItem sets the quantity of productID, adding the line if it is missing.
+	SetItem(ctx context.Context, userID string, item Item) error
+	RemoveItem(ctx context.Context, userID string, productID string) error
+	Clear(ctx context.Context, userID string) error
+}
@@ -0,0 +1,16 @@
+package cart
+
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+)
+
+type Service interface {
+	Get(ctx context.Context, userID string) (Cart, error)
+	AddItem(ctx context.Context, userID string, in AddItemInput) (Cart, error)
+	UpdateItem(ctx context.Context, userID string, productID string, qty int64) (Cart, error)
+	RemoveItem(ctx context.Context, userID string, productID string) (Cart, error)
+	Clear(ctx context.Context, userID string) error
+	Checkout(ctx context.Context, userID string, in CheckoutInput) (orders.Order, error)
+}
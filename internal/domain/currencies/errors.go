@@ -0,0 +1,11 @@
+package currencies
+
+import "errors"
+
+var (
+	ErrInvalidCurrency = errors.New("invalid currency")
+	ErrInvalidRate     = errors.New("invalid rate")
+	ErrBaseCurrency    = errors.New("base currency has no exchange rate")
+	ErrUnsupported     = errors.New("no exchange rate for currency")
+	ErrNotFound        = errors.New("not found")
+)
@@ -0,0 +1,45 @@
+package currencies
+
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+// Rate is the price of one unit of Currency in the store's base currency,
+// e.g. 480 for USD when the base is KZT.
+type Rate struct {
+	Currency  money.Currency
+	Rate      float64
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// Quote converts base-currency amounts for display in Currency.
+type Quote struct {
+	Currency money.Currency
+	// Rate is as in Rate.Rate; 1 when Currency is the base currency.
+	Rate float64
+}
+
+// FromBase converts m, which must be in the base currency, to q.Currency.
+func (q Quote) FromBase(m money.Money) money.Money {
+	if m.Currency == q.Currency {
+		return m
+	}
+	return m.Convert(q.Currency, 1/q.Rate)
+}
+
+// ToBase converts m, which must be in q.Currency, to the base currency.
+func (q Quote) ToBase(m money.Money, base money.Currency) money.Money {
+	if m.Currency == base {
+		return m
+	}
+	return m.Convert(base, q.Rate)
+}
+
+type SetInput struct {
+	Currency  money.Currency
+	Rate      float64
+	UpdatedBy string
+}
@@ -0,0 +1,43 @@
+package currencies_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+func TestQuoteFromBase(t *testing.T) {
+	usd := money.Currency("USD")
+	tests := []struct {
+		name  string
+		quote currencies.Quote
+		in    money.Money
+		want  money.Money
+	}{
+		{"base", currencies.Quote{Currency: money.Default, Rate: 1}, money.New(48000, money.Default), money.New(48000, money.Default)},
+		{"converted", currencies.Quote{Currency: usd, Rate: 480}, money.New(48000, money.Default), money.New(100, usd)},
+		{"rounded", currencies.Quote{Currency: usd, Rate: 480}, money.New(1000, money.Default), money.New(2, usd)},
+		{"negative", currencies.Quote{Currency: usd, Rate: 480}, money.New(-48000, money.Default), money.New(-100, usd)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quote.FromBase(tt.in); got != tt.want {
+				t.Errorf("FromBase(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteToBase(t *testing.T) {
+	usd := money.Currency("USD")
+	q := currencies.Quote{Currency: usd, Rate: 480}
+
+	if got, want := q.ToBase(money.New(250, usd), money.Default), money.New(120000, money.Default); got != want {
+		t.Errorf("ToBase = %v, want %v", got, want)
+	}
+	// already in the base currency
+	if got, want := q.ToBase(money.New(999, money.Default), money.Default), money.New(999, money.Default); got != want {
+		t.Errorf("ToBase of a base amount = %v, want %v", got, want)
+	}
+}
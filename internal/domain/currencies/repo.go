@@ -0,0 +1,18 @@
+package currencies
+
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+// Repo stores rates against a base currency. Rates recorded against another
+// base are treated as missing.
+type Repo interface {
+	// List returns rates ordered by currency code.
+	List(ctx context.Context, base money.Currency) ([]Rate, error)
+	Get(ctx context.Context, base, cur money.Currency) (Rate, error)
+	// Upsert creates or replaces the rate for r.Currency.
+	Upsert(ctx context.Context, base money.Currency, r Rate) (Rate, error)
+	Delete(ctx context.Context, base, cur money.Currency) error
+}
@@ -0,0 +1,18 @@
+package currencies
+
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type Service interface {
+	// Base is the currency prices are entered in and statistics are kept in.
+	Base() money.Currency
+	List(ctx context.Context) ([]Rate, error)
+	Set(ctx context.Context, in SetInput) (Rate, error)
+	Delete(ctx context.Context, cur money.Currency) error
+	// Quote returns how to show base amounts in cur. An empty cur or the base
+	// currency itself quotes at 1; other currencies need a rate.
+	Quote(ctx context.Context, cur money.Currency) (Quote, error)
+}
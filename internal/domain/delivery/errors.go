@@ -0,0 +1,11 @@
+package delivery
+
+import "errors"
+
+var (
+	ErrInvalidID   = errors.New("invalid id")
+	ErrNotFound    = errors.New("not found")
+	ErrInvalidName = errors.New("invalid name")
+	ErrInvalidFee  = errors.New("invalid fee")
+	ErrInvalidDays = errors.New("invalid estimated days")
+)
@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type Method struct {
+	ID            string
+	Name          string
+	Description   string
+	Fee           money.Money
+	EstimatedDays int64
+	Active        bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type ListFilter struct {
+	ActiveOnly bool
+}
+
+type CreateInput struct {
+	Name          string
+	Description   string
+	Fee           money.Money
+	EstimatedDays int64
+	Active        bool
+}
+
+type UpdateInput struct {
+	Name          *string
+	Description   *string
+	Fee           *money.Money
+	EstimatedDays *int64
+	Active        *bool
+}
@@ -0,0 +1,12 @@
+package delivery
+
+import "context"
+
+type Repo interface {
+	// List returns methods ordered by fee, cheapest first.
+	List(ctx context.Context, f ListFilter) ([]Method, error)
+	GetByID(ctx context.Context, id string) (Method, error)
+	Create(ctx context.Context, m Method) (Method, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Method, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,11 @@
+package delivery
+
+import "context"
+
+type Service interface {
+	List(ctx context.Context, f ListFilter) ([]Method, error)
+	Get(ctx context.Context, id string) (Method, error)
+	Create(ctx context.Context, in CreateInput) (Method, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Method, error)
+	Delete(ctx context.Context, id string) error
+}
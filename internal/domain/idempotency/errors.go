@@ -0,0 +1,8 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrKeyExists = errors.New("idempotency key already used")
+)
@@ -0,0 +1,22 @@
+package idempotency
+
+import "time"
+
+// Record is a stored response for an Idempotency-Key, scoped to one user.
+// A record is created before the request runs (Completed=false) so that a
+// concurrent retry can tell the first attempt is still in flight.
+type Record struct {
+	UserID string
+	Key    string
+	// RequestHash fingerprints method, path, query, display currency and body
+	// so a reused key with a different request can be rejected.
+	RequestHash string
+
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
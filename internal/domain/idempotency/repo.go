@@ -0,0 +1,15 @@
+package idempotency
+
+import "context"
+
+type Repo interface {
+	// Reserve stores a pending record. It returns ErrKeyExists if an unexpired
+	// record for the same user and key is already present.
+	Reserve(ctx context.Context, r Record) error
+	// Get returns ErrNotFound for missing or expired records.
+	Get(ctx context.Context, userID, key string) (Record, error)
+	// Complete attaches the response to a reserved record.
+	Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error
+	// Release drops a reserved record so the request can be retried.
+	Release(ctx context.Context, userID, key string) error
+}
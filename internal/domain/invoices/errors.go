@@ -0,0 +1,9 @@
+package invoices
+
+import "errors"
+
+var (
+	ErrNotFound       = errors.New("invoice not found")
+	ErrExists         = errors.New("invoice already issued")
+	ErrNotInvoiceable = errors.New("order cannot be invoiced")
+)
@@ -0,0 +1,31 @@
+package invoices
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+)
+
+// Invoice is the permanent record that an order was invoiced. Numbers are
+// sequential across the store and never reused or changed once assigned.
+type Invoice struct {
+	ID       string
+	OrderID  string
+	UserID   string
+	Number   int64
+	IssuedAt time.Time
+}
+
+// Code is the human-facing invoice number, e.g. INV-000042.
+func (i Invoice) Code() string {
+	return fmt.Sprintf("INV-%06d", i.Number)
+}
+
+// Document is what gets rendered: the invoice record plus the order it bills.
+// Orders snapshot their prices at checkout, so re-rendering later yields the
+// same figures.
+type Document struct {
+	Invoice Invoice
+	Order   orders.Order
+}
@@ -0,0 +1,15 @@
+package invoices_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+)
+
+func TestCode(t *testing.T) {
+	for n, want := range map[int64]string{1: "INV-000001", 42: "INV-000042", 1234567: "INV-1234567"} {
+		if got := (invoices.Invoice{Number: n}).Code(); got != want {
+			t.Errorf("Code(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package invoices
+
+import "context"
+
+type Repo interface {
+	GetByOrderID(ctx context.Context, orderID string) (Invoice, error)
+	// NextNumber reserves the next invoice number. Call it in the same
+	// transaction as Create so an aborted issue doesn't leave a gap.
+	NextNumber(ctx context.Context) (int64, error)
+	// Create fails with ErrExists if the order already has an invoice.
+	Create(ctx context.Context, inv Invoice) (Invoice, error)
+}
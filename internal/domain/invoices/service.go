@@ -0,0 +1,9 @@
+package invoices
+
+import "context"
+
+type Service interface {
+	// Issue returns the order's invoice, numbering it on first request.
+	// Customers can only invoice their own orders.
+	Issue(ctx context.Context, orderID string, userID string, isAdmin bool) (Document, error)
+}
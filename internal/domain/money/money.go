@@ -0,0 +1,196 @@
+// Package money represents amounts as integer minor units (e.g. tiyn for KZT)
+// so sums and discounts don't pick up floating-point error.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var ErrInvalidCurrency = errors.New("invalid currency")
+
+// Currency is an ISO 4217 code.
+type Currency string
+
+// ParseCurrency normalizes a code such as "usd" to "USD". It checks only the
+// shape of the code, not that the store trades in it.
+func ParseCurrency(s string) (Currency, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return Currency(s), nil
+}
+
+// Default is the store's base currency unless BASE_CURRENCY says otherwise.
+const Default Currency = "KZT"
+
+// minorPerMajor is how many minor units make one major unit. Every currency
+// the store deals in has two decimal places.
+const minorPerMajor = 100
+
+// Money is an amount in minor units of Currency. The zero value is zero with
+// no currency, and adopts the currency of whatever it is added to.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(minor int64, cur Currency) Money {
+	return Money{Amount: minor, Currency: cur}
+}
+
+func Zero(cur Currency) Money {
+	return Money{Currency: cur}
+}
+
+// FromMajor converts a decimal amount such as 49.99, rounding half away from
+// zero to the nearest minor unit. It is for API input and legacy data only;
+// arithmetic should stay in Money.
+func FromMajor(v float64, cur Currency) Money {
+	return Money{Amount: int64(math.Round(v * minorPerMajor)), Currency: cur}
+}
+
+// Major returns the amount in major units, e.g. 4999 minor units as 49.99.
+// It is for JSON output, which keeps prices as plain numbers.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / minorPerMajor
+}
+
+// IsIn reports whether m can be combined with amounts in cur without a
+// conversion: it is in cur or, like the zero value, has no currency.
+func (m Money) IsIn(cur Currency) bool {
+	return m.Currency == "" || strings.EqualFold(string(m.Currency), string(cur))
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+func (m Money) Add(o Money) Money {
+	cur := m.common(o)
+	return Money{Amount: m.Amount + o.Amount, Currency: cur}
+}
+
+func (m Money) Sub(o Money) Money {
+	cur := m.common(o)
+	return Money{Amount: m.Amount - o.Amount, Currency: cur}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul multiplies by a whole quantity, e.g. unit price times items.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// MulRatio returns m * num / den, rounded half away from zero. It is used to
+// split an amount proportionally, e.g. a discount across order lines.
+func (m Money) MulRatio(num, den int64) Money {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	return Money{Amount: divRound(m.Amount*num, den), Currency: m.Currency}
+}
+
+// Percent returns p percent of m, rounded half away from zero.
+func (m Money) Percent(p float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * p / 100)), Currency: m.Currency}
+}
+
+// Convert returns m in another currency at rate, the number of units of to
+// that one unit of m's currency buys, rounded half away from zero to the
+// nearest minor unit.
+func (m Money) Convert(to Currency, rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: to}
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) int {
+	m.common(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	}
+	return 0
+}
+
+func Min(a, b Money) Money {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// Sum adds up amounts in cur.
+func Sum(cur Currency, ms ...Money) Money {
+	total := Zero(cur)
+	for _, m := range ms {
+		total = total.Add(m)
+	}
+	return total
+}
+
+// Decimal formats the amount alone, e.g. "49.99".
+func (m Money) Decimal() string {
+	sign := ""
+	a := m.Amount
+	if a < 0 {
+		sign, a = "-", -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/minorPerMajor, a%minorPerMajor)
+}
+
+// String formats m as e.g. "49.99 KZT".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// common returns the currency of an operation on m and o. Mixing currencies
+// is a programming error: conversion has to be explicit.
+func (m Money) common(o Money) Currency {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || strings.EqualFold(string(m.Currency), string(o.Currency)):
+		return m.Currency
+	}
+	panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.Currency, o.Currency))
+}
+
+// divRound divides rounding half away from zero.
+func divRound(a, b int64) int64 {
+	if b < 0 {
+		a, b = -a, -b
+	}
+	q, r := a/b, a%b
+	if 2*abs(r) >= b {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
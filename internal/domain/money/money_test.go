@@ -0,0 +1,131 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func TestFromMajor(t *testing.T) {
+	tests := []struct {
+		major float64
+		want  int64
+	}{
+		{49.99, 4999},
+		{0.1 + 0.2, 30},
+		{-2.345, -235},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := money.FromMajor(tt.major, money.Default); got != kzt(tt.want) {
+			t.Errorf("FromMajor(%v) = %v, want %v", tt.major, got, kzt(tt.want))
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := kzt(1050), kzt(299)
+	if got := a.Add(b); got != kzt(1349) {
+		t.Errorf("Add = %v", got)
+	}
+	if got := b.Sub(a); got != kzt(-751) {
+		t.Errorf("Sub = %v", got)
+	}
+	if got := b.Mul(3); got != kzt(897) {
+		t.Errorf("Mul = %v", got)
+	}
+	if got := a.Neg(); got != kzt(-1050) {
+		t.Errorf("Neg = %v", got)
+	}
+	// the zero value adopts the other currency
+	if got := (money.Money{}).Add(a); got != a {
+		t.Errorf("zero Add = %v, want %v", got, a)
+	}
+	if got := money.Sum(money.Default, a, b, b); got != kzt(1648) {
+		t.Errorf("Sum = %v", got)
+	}
+	if money.Min(a, b) != b || a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Error("comparison is wrong")
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        money.Money
+		num, den int64
+		want     money.Money
+	}{
+		{"exact", kzt(6000), 9000, 10000, kzt(5400)},
+		{"rounds half up", kzt(5), 1, 2, kzt(3)},
+		{"rounds down below half", kzt(10), 1, 3, kzt(3)},
+		{"negative rounds half away from zero", kzt(-5), 1, 2, kzt(-3)},
+		{"negative denominator", kzt(10), 1, -4, kzt(-3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MulRatio(tt.num, tt.den); got != tt.want {
+				t.Errorf("MulRatio = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	if got := kzt(1999).Percent(10); got != kzt(200) {
+		t.Errorf("10%% of 19.99 = %v, want 2.00", got)
+	}
+	if got := kzt(1000).Percent(12.5); got != kzt(125) {
+		t.Errorf("12.5%% of 10.00 = %v, want 1.25", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		m    money.Money
+		want string
+	}{
+		{kzt(4999), "49.99 KZT"},
+		{kzt(5), "0.05 KZT"},
+		{kzt(-1250), "-12.50 KZT"},
+		{money.New(100, ""), "1.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String = %q, want %q", got, tt.want)
+		}
+	}
+	if got := kzt(4999).Major(); got != 49.99 {
+		t.Errorf("Major = %v", got)
+	}
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding KZT to USD did not panic")
+		}
+	}()
+	kzt(100).Add(money.New(100, "USD"))
+}
+
+func TestIsIn(t *testing.T) {
+	tests := []struct {
+		m    money.Money
+		cur  money.Currency
+		want bool
+	}{
+		{kzt(100), money.Default, true},
+		{money.New(100, "kzt"), money.Default, true},
+		// untagged amounts combine with anything
+		{money.Money{Amount: 100}, "USD", true},
+		{kzt(100), "USD", false},
+	}
+	for _, tt := range tests {
+		if got := tt.m.IsIn(tt.cur); got != tt.want {
+			t.Errorf("%v.IsIn(%s) = %v, want %v", tt.m, tt.cur, got, tt.want)
+		}
+	}
+}
@@ -11,4 +11,19 @@ var (
 	ErrInvalidProduct    = errors.New("invalid product")
 	ErrInvalidQty        = errors.New("invalid quantity")
 	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrCancelWindow      = errors.New("cancellation window has passed")
+	ErrInvalidAddress    = errors.New("invalid shipping address")
+	ErrInvalidDelivery   = errors.New("invalid delivery method")
+	ErrInvalidFilter     = errors.New("invalid filter")
+	ErrAlreadyPaid       = errors.New("order is already paid")
+	ErrPaidAfterCancel   = errors.New("payment received for a cancelled order")
+	ErrInvalidPayment    = errors.New("invalid payment status")
+	ErrInvalidShipment   = errors.New("carrier and tracking number are required")
+	ErrOverShipment      = errors.New("quantity exceeds what is left to ship")
+	ErrShipmentNotFound  = errors.New("shipment not found")
+	ErrNotShippable      = errors.New("order cannot be shipped in its current status")
+	// ErrCurrencyMismatch means stored prices or fees aren't in the base
+	// currency, e.g. BASE_CURRENCY was changed without converting them.
+	ErrCurrencyMismatch = errors.New("amount is not in the base currency")
 )
@@ -1,41 +1,326 @@
 package orders
 
-import "time"
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
 
 type Status string
 
 const (
-	StatusPending   Status = "pending"
-	StatusShipped   Status = "shipped"
-	StatusDelivered Status = "delivered"
-	StatusCancelled Status = "cancelled"
+	StatusPending Status = "pending"
+	StatusPaid    Status = "paid"
+	// StatusPartiallyShipped and the shipped/delivered statuses that follow it
+	// are derived from the order's shipments; see Order.Unshipped.
+	StatusPartiallyShipped Status = "partially_shipped"
+	StatusShipped          Status = "shipped"
+	StatusDelivered        Status = "delivered"
+	StatusCancelled        Status = "cancelled"
 )
 
+// transitions lists the statuses each status may move to. Delivered and
+// cancelled are terminal. Pending orders may still ship unpaid (e.g. cash on
+// delivery); paid is set by the payments subsystem and the fulfilment
+// statuses by shipments, which leaves admins only cancellation.
+var transitions = map[Status][]Status{
+	StatusPending:          {StatusPaid, StatusPartiallyShipped, StatusShipped, StatusCancelled},
+	StatusPaid:             {StatusPartiallyShipped, StatusShipped, StatusCancelled},
+	StatusPartiallyShipped: {StatusShipped},
+	StatusShipped:          {StatusDelivered},
+	StatusDelivered:        {},
+	StatusCancelled:        {},
+}
+
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentStatus tracks money for an order separately from fulfilment.
+type PaymentStatus string
+
+const (
+	PaymentUnpaid   PaymentStatus = "unpaid"
+	PaymentPaid     PaymentStatus = "paid"
+	PaymentFailed   PaymentStatus = "failed"
+	PaymentRefunded PaymentStatus = "refunded"
+)
+
+func (p PaymentStatus) Valid() bool {
+	switch p {
+	case PaymentUnpaid, PaymentPaid, PaymentFailed, PaymentRefunded:
+		return true
+	}
+	return false
+}
+
+// StatusChange is one entry of an order's status history. From is empty for
+// the entry recorded when the order is created.
+type StatusChange struct {
+	From      Status
+	To        Status
+	ChangedBy string
+	Note      string
+	ChangedAt time.Time
+}
+
 type Item struct {
+	ProductID   string
+	ProductName string
+	Quantity    int64
+
+	// UnitPrice and LineTotal are snapshotted at checkout and never recomputed.
+	UnitPrice money.Money
+	LineTotal money.Money
+}
+
+type ShippingAddress struct {
+	FullName   string
+	Phone      string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// DeliveryMethod is the delivery option chosen at checkout, copied onto the
+// order so later edits to the method don't change it.
+type DeliveryMethod struct {
+	MethodID string
+	Name     string
+	Fee      money.Money
+}
+
+// Discount is a promo code applied to an order at checkout.
+type Discount struct {
+	PromotionID string
+	Code        string
+	Amount      money.Money
+}
+
+// TaxLine is the tax one rule charged on an order, snapshotted at checkout.
+// Inclusive tax is already part of the line totals; the rest was added to
+// TotalPrice.
+type TaxLine struct {
+	RuleID    string
+	Name      string
+	Rate      float64
+	Inclusive bool
+	// Base is the discounted total of the lines the rule applied to.
+	Base   money.Money
+	Amount money.Money
+}
+
+// Shipment is one parcel sent for an order. An order may ship in several
+// parcels, each holding some of its items.
+type Shipment struct {
+	ID             string
+	Carrier        string
+	TrackingNumber string
+	Items          []ShipmentItem
+	ShippedAt      time.Time
+	DeliveredAt    *time.Time
+	CreatedBy      string
+}
+
+type ShipmentItem struct {
 	ProductID string
 	Quantity  int64
+}
+
+// Refund is money returned to the customer after an order was placed, e.g.
+// for returned items. It reduces net revenue but not TotalPrice.
+type Refund struct {
+	// empty when the whole payment was refunded rather than a return
+	ReturnID  string
+	Amount    money.Money
+	CreatedAt time.Time
+}
 
-	UnitPrice float64
-	LineTotal float64
+// Display records the currency the customer shopped in when it differs from
+// the base currency, which every other amount on the order is in. The
+// customer is charged Display.TotalPrice.
+type Display struct {
+	Currency money.Currency
+	// Rate is the price of one unit of Currency in the base currency at checkout.
+	Rate       float64
+	Subtotal   money.Money
+	TotalPrice money.Money
 }
 
 type Order struct {
-	ID     string
-	UserID string
-	Items  []Item
-	Status Status
+	ID            string
+	UserID        string
+	Items         []Item
+	Status        Status
+	PaymentStatus PaymentStatus
+
+	// nil on orders placed before shipping details were captured
+	ShippingAddress *ShippingAddress
+	Delivery        *DeliveryMethod
 
-	TotalPrice float64
+	// Subtotal is the sum of line totals; TotalPrice is what the customer pays
+	// after discounts, the delivery fee and tax not included in prices.
+	Subtotal   money.Money
+	Discounts  []Discount
+	Taxes      []TaxLine
+	TotalPrice money.Money
+	Refunds    []Refund
+	// nil when the order was placed in the base currency
+	Display *Display
+
+	Shipments     []Shipment
+	StatusHistory []StatusChange
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// AmountDue is what the customer is charged: the display total if they
+// shopped in another currency, TotalPrice otherwise.
+func (o Order) AmountDue() money.Money {
+	if o.Display != nil {
+		return o.Display.TotalPrice
+	}
+	return o.TotalPrice
+}
+
+// TaxTotal sums all tax on the order, inclusive or not.
+func (o Order) TaxTotal() money.Money {
+	total := money.Zero(o.TotalPrice.Currency)
+	for _, t := range o.Taxes {
+		total = total.Add(t.Amount)
+	}
+	return total
+}
+
+// AddedTax sums the tax charged on top of prices, i.e. not inclusive.
+func (o Order) AddedTax() money.Money {
+	total := money.Zero(o.TotalPrice.Currency)
+	for _, t := range o.Taxes {
+		if !t.Inclusive {
+			total = total.Add(t.Amount)
+		}
+	}
+	return total
+}
+
+// Refunded sums the refunds given on the order so far.
+func (o Order) Refunded() money.Money {
+	total := money.Zero(o.TotalPrice.Currency)
+	for _, r := range o.Refunds {
+		total = total.Add(r.Amount)
+	}
+	return total
+}
+
+// Unshipped returns, per product, the quantity not yet in any shipment.
+// Products that are fully shipped are left out.
+func (o Order) Unshipped() map[string]int64 {
+	left := make(map[string]int64, len(o.Items))
+	for _, it := range o.Items {
+		left[it.ProductID] += it.Quantity
+	}
+	for _, sh := range o.Shipments {
+		for _, it := range sh.Items {
+			left[it.ProductID] -= it.Quantity
+		}
+	}
+	for id, q := range left {
+		if q <= 0 {
+			delete(left, id)
+		}
+	}
+	return left
+}
+
+// SortField is a field orders can be listed by. Ties are broken by id so
+// paging stays stable.
+type SortField string
+
+const (
+	SortByCreatedAt  SortField = "createdAt"
+	SortByTotalPrice SortField = "totalPrice"
+)
+
+func (f SortField) Valid() bool {
+	return f == SortByCreatedAt || f == SortByTotalPrice
+}
+
+// ListFilter narrows and orders an order listing. Nil/empty fields are not
+// applied; the zero value lists everything newest first.
 type ListFilter struct {
+	Status    *Status
+	UserID    *string
+	Email     string // resolved to a user ID by the service
+	ProductID *string
+
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	MinTotal    *money.Money
+	MaxTotal    *money.Money
+
+	SortBy  SortField // defaults to SortByCreatedAt
+	SortAsc bool
+
 	Offset int64
 	Limit  int64
 }
 
+// ExportLine is one order line flattened for accounting exports, with the
+// order-level fields repeated on every line.
+type ExportLine struct {
+	OrderID       string
+	UserID        string
+	Email         string
+	Status        Status
+	PaymentStatus PaymentStatus
+	ProductID     string
+	ProductName   string
+	Quantity      int64
+	UnitPrice     money.Money
+	LineTotal     money.Money
+	OrderTotal    money.Money
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type CreateInput struct {
-	Items []Item
+	Items     []Item
+	PromoCode string
+
+	// ShippingAddress defaults to the user's profile when nil.
+	ShippingAddress *ShippingAddress
+	// DeliveryMethodID defaults to the cheapest active method when empty.
+	DeliveryMethodID string
+	// Currency is the display currency; empty means the base currency.
+	Currency money.Currency
+}
+
+type UpdateStatusInput struct {
+	Status Status
+	Note   string
+}
+
+// ShipmentInput records a parcel. Empty Items ships everything not yet shipped.
+type ShipmentInput struct {
+	Carrier        string
+	TrackingNumber string
+	Items          []ShipmentItem
+}
+
+type CancelInput struct {
+	Reason string
 }
@@ -1,11 +1,30 @@
 package orders
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Repo interface {
+	// List and Count apply f on top of the optional userID scope; f.Email is
+	// ignored and must be resolved to f.UserID by the caller.
 	List(ctx context.Context, userID *string, f ListFilter) ([]Order, error)
+	// Each streams the orders matching f from a cursor, in list order, calling
+	// fn once per order. f.Offset and f.Limit apply; a zero Limit means all.
+	// Iteration stops at the first error from fn.
+	Each(ctx context.Context, userID *string, f ListFilter, fn func(Order) error) error
 	GetByID(ctx context.Context, id string, userID *string) (Order, error)
-	Count(ctx context.Context, userID *string) (int64, error)
+	Count(ctx context.Context, userID *string, f ListFilter) (int64, error)
 	Create(ctx context.Context, o Order) (Order, error)
-	UpdateStatus(ctx context.Context, id string, status Status) (Order, error)
+	// UpdateStatus applies ch only if the order is still in ch.From; otherwise it returns ErrInvalidTransition.
+	UpdateStatus(ctx context.Context, id string, ch StatusChange) (Order, error)
+	SetPaymentStatus(ctx context.Context, id string, ps PaymentStatus, at time.Time) (Order, error)
+	AddRefund(ctx context.Context, id string, r Refund) (Order, error)
+	// AddShipment appends sh and, if ch is set, applies the status change in
+	// the same write. The write only happens while the order is still in
+	// status `from`; otherwise it returns ErrInvalidTransition.
+	AddShipment(ctx context.Context, id string, from Status, sh Shipment, ch *StatusChange) (Order, error)
+	// SetShipmentDelivered stamps deliveredAt on one shipment, with the same
+	// status guard and optional change as AddShipment.
+	SetShipmentDelivered(ctx context.Context, id string, shipmentID string, from Status, at time.Time, ch *StatusChange) (Order, error)
 }
@@ -5,6 +5,22 @@ import "context"
 type Service interface {
 	List(ctx context.Context, userID string, isAdmin bool, f ListFilter) ([]Order, int64, error)
 	Get(ctx context.Context, id string, userID string, isAdmin bool) (Order, error)
+	// Export streams every order line matching f to fn, for admins only.
+	// Offset and Limit are ignored.
+	Export(ctx context.Context, f ListFilter, fn func(ExportLine) error) error
 	Create(ctx context.Context, userID string, in CreateInput) (Order, error)
-	UpdateStatus(ctx context.Context, id string, status Status) (Order, error)
+	UpdateStatus(ctx context.Context, id string, actorID string, in UpdateStatusInput) (Order, error)
+	Cancel(ctx context.Context, id string, userID string, in CancelInput) (Order, error)
+	// RecordPayment stores the order's payment status; a successful payment
+	// also moves a pending order to paid. A successful payment for a
+	// cancelled order is refused with ErrPaidAfterCancel, so the caller can
+	// return the money.
+	RecordPayment(ctx context.Context, id string, ps PaymentStatus) (Order, error)
+	RecordRefund(ctx context.Context, id string, r Refund) (Order, error)
+	// AddShipment records a parcel and moves the order to partially_shipped
+	// or shipped depending on what is left.
+	AddShipment(ctx context.Context, id string, actorID string, in ShipmentInput) (Order, error)
+	// DeliverShipment marks a parcel delivered; the order becomes delivered
+	// once it is fully shipped and every parcel has arrived.
+	DeliverShipment(ctx context.Context, id string, shipmentID string, actorID string) (Order, error)
 }
@@ -0,0 +1,13 @@
+package payments
+
+import "errors"
+
+var (
+	ErrInvalidID        = errors.New("invalid id")
+	ErrNotFound         = errors.New("payment not found")
+	ErrNotPayable       = errors.New("order cannot be paid")
+	ErrNotRefundable    = errors.New("order has no payment to refund")
+	ErrRefundExceeded   = errors.New("refund exceeds what is left of the payment")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidEvent     = errors.New("invalid webhook event")
+)
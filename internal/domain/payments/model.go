@@ -0,0 +1,48 @@
+package payments
+
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusPaid     Status = "paid"
+	StatusFailed   Status = "failed"
+	StatusRefunded Status = "refunded"
+)
+
+// Payment is one attempt to pay for an order through a provider. An order can
+// have several if earlier attempts failed.
+type Payment struct {
+	ID          string
+	OrderID     string
+	UserID      string
+	Provider    string
+	ProviderRef string
+	// ClientSecret lets the client confirm the payment with the provider directly.
+	ClientSecret string
+	Amount       money.Money
+	Status       Status
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// EventType is a provider-neutral webhook event.
+type EventType string
+
+const (
+	// EventAuthorized means funds are held and must be captured.
+	EventAuthorized EventType = "payment.authorized"
+	EventSucceeded  EventType = "payment.succeeded"
+	EventFailed     EventType = "payment.failed"
+)
+
+// Event is a verified webhook notification about one intent.
+type Event struct {
+	Type        EventType
+	ProviderRef string
+}
@@ -0,0 +1,27 @@
+package payments
+
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+// Intent is what a provider returns when a payment is started.
+type Intent struct {
+	Ref          string
+	ClientSecret string
+}
+
+// Provider is a payment gateway. Implementations translate their own API and
+// webhook format into these calls so the rest of the app stays gateway-agnostic.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, orderID string, amount money.Money) (Intent, error)
+	Capture(ctx context.Context, ref string, amount money.Money) error
+	Refund(ctx context.Context, ref string, amount money.Money) error
+	// SignatureHeader is the HTTP header carrying the webhook signature.
+	SignatureHeader() string
+	// VerifyWebhook checks the signature over the raw payload and parses it.
+	// It returns ErrInvalidSignature or ErrInvalidEvent on bad input.
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+}
@@ -0,0 +1,14 @@
+package payments
+
+import (
+	"context"
+	"time"
+)
+
+type Repo interface {
+	Create(ctx context.Context, p Payment) (Payment, error)
+	GetByProviderRef(ctx context.Context, provider, ref string) (Payment, error)
+	// GetLatestByOrderID returns the most recent payment for an order.
+	GetLatestByOrderID(ctx context.Context, orderID string) (Payment, error)
+	UpdateStatus(ctx context.Context, id string, st Status, at time.Time) (Payment, error)
+}
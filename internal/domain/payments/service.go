@@ -0,0 +1,20 @@
+package payments
+
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type Service interface {
+	// Pay starts (or resumes) payment of the user's own pending order.
+	Pay(ctx context.Context, orderID string, userID string) (Payment, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	// Refund returns what is left of the order's payment.
+	Refund(ctx context.Context, orderID string) (Payment, error)
+	// RefundReturn returns amount, in the base currency, of the order's
+	// payment for a received return. Refunds of an order never add up to
+	// more than TotalPrice; ErrRefundExceeded is returned instead.
+	RefundReturn(ctx context.Context, orderID string, returnID string, amount money.Money) (Payment, error)
+	SignatureHeader() string
+}
@@ -9,7 +9,11 @@ var (
 	ErrInvalidCategory    = errors.New("invalid category")
 	ErrInvalidPrice       = errors.New("invalid price")
 	ErrInvalidStock       = errors.New("invalid stock")
+	ErrInvalidThreshold   = errors.New("invalid reorder threshold")
+	ErrInvalidQuery       = errors.New("invalid search query")
+	ErrInvalidFilter      = errors.New("invalid filter")
 	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrStockBelowReserved = errors.New("stock is below what carts hold")
 	ErrInvalidRating      = errors.New("invalid rating")
 	ErrInvalidComment     = errors.New("invalid comment")
 	ErrInvalidReviewID    = errors.New("invalid review id")
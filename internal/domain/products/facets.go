@@ -0,0 +1,92 @@
+package products
+
+import "github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+
+// Facet is one breakdown of Facets.
+type Facet string
+
+const (
+	FacetCategory     Facet = "category"
+	FacetPrice        Facet = "price"
+	FacetRating       Facet = "rating"
+	FacetAvailability Facet = "availability"
+)
+
+// RatingSteps are the "N stars & up" rating buckets, highest first.
+var RatingSteps = []float64{4, 3, 2, 1}
+
+// Facets counts the products matching a listing the way a filter sidebar
+// shows them. Each breakdown applies every filter but its own, so with one
+// category picked the others still show what picking them would list; Total
+// applies them all.
+type Facets struct {
+	Total      int64
+	Categories []CategoryCount // most products first
+	Prices     []PriceBucket   // cheapest first
+	Ratings    []RatingBucket  // in RatingSteps order
+	InStock    int64
+	OutOfStock int64
+}
+
+type CategoryCount struct {
+	CategoryID string
+	Count      int64
+}
+
+// PriceBucket counts products priced from Min up to but not including Max,
+// in the base currency. Max is nil on the last bucket.
+type PriceBucket struct {
+	Min   money.Money
+	Max   *money.Money
+	Count int64
+}
+
+// RatingBucket counts reviewed products rated MinRating or higher, as
+// ListFilter.MinRating would list them, so buckets overlap.
+type RatingBucket struct {
+	MinRating float64
+	Count     int64
+}
+
+// PriceBuckets returns empty buckets split at breaks, which must be
+// ascending: one below the first break, one between each pair and one open
+// bucket above the last.
+func PriceBuckets(breaks []money.Money) []PriceBucket {
+	var lo money.Money
+	if len(breaks) > 0 {
+		lo = money.Zero(breaks[0].Currency)
+	}
+	out := make([]PriceBucket, 0, len(breaks)+1)
+	for _, b := range breaks {
+		out = append(out, PriceBucket{Min: lo, Max: &b})
+		lo = b
+	}
+	return append(out, PriceBucket{Min: lo})
+}
+
+// RatingBuckets returns empty buckets for RatingSteps.
+func RatingBuckets() []RatingBucket {
+	out := make([]RatingBucket, 0, len(RatingSteps))
+	for _, r := range RatingSteps {
+		out = append(out, RatingBucket{MinRating: r})
+	}
+	return out
+}
+
+// Except returns the filter a facet's breakdown is counted with: f without
+// the filter that facet breaks down, and without paging or sorting.
+func (f ListFilter) Except(facet Facet) ListFilter {
+	switch facet {
+	case FacetCategory:
+		f.CategoryIDs = nil
+	case FacetPrice:
+		f.MinPrice, f.MaxPrice = nil, nil
+	case FacetRating:
+		f.MinRating = nil
+	case FacetAvailability:
+		f.InStockOnly = false
+	}
+	f.SortBy, f.SortAsc = "", false
+	f.Offset, f.Limit = 0, 0
+	return f
+}
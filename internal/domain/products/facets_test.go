@@ -0,0 +1,72 @@
+package products_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+)
+
+func TestPriceBuckets(t *testing.T) {
+	kzt := func(minor int64) money.Money { return money.New(minor, money.Default) }
+
+	got := products.PriceBuckets([]money.Money{kzt(5000), kzt(20000)})
+	if len(got) != 3 {
+		t.Fatalf("buckets = %+v, want 3", got)
+	}
+	// a max of -1 stands for the open bucket
+	for i, want := range [][2]int64{{0, 5000}, {5000, 20000}, {20000, -1}} {
+		b := got[i]
+		max := int64(-1)
+		if b.Max != nil {
+			max = b.Max.Amount
+		}
+		if b.Min != kzt(want[0]) || max != want[1] {
+			t.Errorf("bucket %d = %v..%v, want %d..%d", i, b.Min, b.Max, want[0], want[1])
+		}
+	}
+
+	// without breaks everything is in one open bucket
+	if got := products.PriceBuckets(nil); len(got) != 1 || got[0].Max != nil {
+		t.Errorf("buckets = %+v, want one open bucket", got)
+	}
+}
+
+func TestExcept(t *testing.T) {
+	price := money.New(1000, money.Default)
+	rating := 4.0
+	f := products.ListFilter{
+		CategoryIDs: []string{"c1"},
+		Query:       "mouse",
+		MinPrice:    &price,
+		MaxPrice:    &price,
+		InStockOnly: true,
+		MinRating:   &rating,
+		SortBy:      products.SortByPrice,
+		SortAsc:     true,
+		Offset:      20,
+		Limit:       10,
+	}
+	// what every breakdown keeps: the filters, without paging or sorting
+	base := f
+	base.SortBy, base.SortAsc, base.Offset, base.Limit = "", false, 0, 0
+
+	tests := []struct {
+		facet products.Facet
+		edit  func(f *products.ListFilter)
+	}{
+		{"", func(f *products.ListFilter) {}},
+		{products.FacetCategory, func(f *products.ListFilter) { f.CategoryIDs = nil }},
+		{products.FacetPrice, func(f *products.ListFilter) { f.MinPrice, f.MaxPrice = nil, nil }},
+		{products.FacetRating, func(f *products.ListFilter) { f.MinRating = nil }},
+		{products.FacetAvailability, func(f *products.ListFilter) { f.InStockOnly = false }},
+	}
+	for _, tt := range tests {
+		want := base
+		tt.edit(&want)
+		if got := f.Except(tt.facet); !reflect.DeepEqual(got, want) {
+			t.Errorf("Except(%q) = %+v, want %+v", tt.facet, got, want)
+		}
+	}
+}
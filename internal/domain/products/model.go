@@ -1,19 +1,49 @@
 package products
 
-import "time"
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
 
 type Product struct {
 	ID          string
 	CategoryID  string
 	Name        string
 	Description string
-	Price       float64
-	Stock       int64
+	Price       money.Money
+	// Stock is on hand; Reserved of it is held for carts and cannot be ordered
+	// by anyone else until the holds are released or expire.
+	Stock    int64
+	Reserved int64
+	// ReorderThreshold is the stock at or below which the product needs
+	// restocking; 0 turns low-stock alerts off.
+	ReorderThreshold int64
+	// RatingAvg and RatingCount summarise Reviews (RatingAvg is 0 without
+	// any); Sold counts units ordered and not cancelled. The repo keeps them
+	// up to date so listings can filter and sort on them.
+	RatingAvg   float64
+	RatingCount int64
+	Sold        int64
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Reviews     []Review
 }
 
+// LowStock reports whether on-hand stock is at or below the reorder threshold.
+func (p Product) LowStock() bool {
+	return p.ReorderThreshold > 0 && p.Stock <= p.ReorderThreshold
+}
+
+// Available is the stock that can still be reserved or ordered. It is never
+// negative, even when stock was lowered below what is reserved.
+func (p Product) Available() int64 {
+	if p.Stock <= p.Reserved {
+		return 0
+	}
+	return p.Stock - p.Reserved
+}
+
 type Review struct {
 	ID        string
 	UserID    string
@@ -22,26 +52,77 @@ type Review struct {
 	CreatedAt time.Time
 }
 
+// SortField is a field products can be listed by. Ties are broken by ID so
+// paging stays stable.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "createdAt"
+	SortByPrice     SortField = "price"
+	SortByRating    SortField = "rating"
+	SortBySold      SortField = "sold"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByCreatedAt, SortByPrice, SortByRating, SortBySold:
+		return true
+	}
+	return false
+}
+
+// ListFilter narrows and orders a product listing. Nil/empty fields are not
+// applied; the zero value lists everything newest first.
 type ListFilter struct {
-	CategoryID *string
-	Offset     int64
-	Limit      int64
+	// CategoryIDs matches products in any of the categories.
+	CategoryIDs []string
+	// Query is a full-text search over name and description (see Search).
+	Query string
+
+	// MinPrice and MaxPrice are inclusive bounds in the base currency.
+	MinPrice *money.Money
+	MaxPrice *money.Money
+	// InStockOnly keeps products with Available stock.
+	InStockOnly bool
+	// MinRating keeps products whose RatingAvg is at least this; products
+	// without reviews never match.
+	MinRating *float64
+	// LowStockOnly keeps products that are LowStock.
+	LowStockOnly bool
+
+	// SortBy defaults to relevance when Query is set, to the lowest stock
+	// first for LowStockOnly, and to SortByCreatedAt otherwise.
+	SortBy  SortField
+	SortAsc bool
+
+	Offset int64
+	Limit  int64
 }
 
 type CreateInput struct {
 	CategoryID  string
 	Name        string
 	Description string
-	Price       float64
+	Price       money.Money
 	Stock       int64
+	// ReorderThreshold must not be negative; 0 turns low-stock alerts off.
+	ReorderThreshold int64
+	// CreatedBy is the admin recorded on the initial stock movement.
+	CreatedBy string
 }
 
 type UpdateInput struct {
 	CategoryID  *string
 	Name        *string
 	Description *string
-	Price       *float64
+	Price       *money.Money
 	Stock       *int64
+	// ReorderThreshold must not be negative; 0 turns low-stock alerts off.
+	ReorderThreshold *int64
+
+	// UpdatedBy and StockNote are recorded on the stock movement when Stock changes.
+	UpdatedBy string
+	StockNote string
 }
 
 type AddReviewInput struct {
@@ -0,0 +1,44 @@
+package products_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+)
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		stock, reserved, want int64
+	}{
+		{10, 0, 10},
+		{10, 4, 6},
+		{4, 4, 0},
+		// stock lowered below the holds
+		{2, 5, 0},
+	}
+	for _, tt := range tests {
+		p := products.Product{Stock: tt.stock, Reserved: tt.reserved}
+		if got := p.Available(); got != tt.want {
+			t.Errorf("Available with stock %d, reserved %d = %d, want %d", tt.stock, tt.reserved, got, tt.want)
+		}
+	}
+}
+
+func TestLowStock(t *testing.T) {
+	tests := []struct {
+		stock, threshold int64
+		want             bool
+	}{
+		{5, 3, false},
+		{3, 3, true},
+		{0, 3, true},
+		// no threshold, no alerts
+		{0, 0, false},
+	}
+	for _, tt := range tests {
+		p := products.Product{Stock: tt.stock, ReorderThreshold: tt.threshold}
+		if got := p.LowStock(); got != tt.want {
+			t.Errorf("LowStock with stock %d, threshold %d = %v, want %v", tt.stock, tt.threshold, got, tt.want)
+		}
+	}
+}
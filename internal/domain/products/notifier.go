@@ -0,0 +1,24 @@
+package products
+
+import (
+	"context"
+	"time"
+)
+
+// StockAlert is raised when an order takes a product's stock to or below its
+// reorder threshold. It is raised once per crossing, not for every order
+// placed while the product stays low.
+type StockAlert struct {
+	ProductID   string
+	ProductName string
+	Stock       int64
+	Threshold   int64
+	OrderID     string
+	At          time.Time
+}
+
+// Notifier delivers low-stock alerts, e.g. to a log, a file or a chat
+// channel. Implementations must be safe for concurrent use.
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, a StockAlert) error
+}
@@ -1,15 +1,39 @@
 package products
 
-import "context"
+import (
+	"context"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
 
 type Repo interface {
 	List(ctx context.Context, f ListFilter) ([]Product, error)
 	Count(ctx context.Context, f ListFilter) (int64, error)
+	// Facets counts the products matching f; see Facets. Prices are bucketed
+	// at priceBreaks as in PriceBuckets.
+	Facets(ctx context.Context, f ListFilter, priceBreaks []money.Money) (Facets, error)
 	GetByID(ctx context.Context, id string) (Product, error)
+	// GetByIDs loads many products in one query, keyed by ID. Unknown IDs are
+	// left out of the map; a malformed ID returns ErrInvalidID.
+	GetByIDs(ctx context.Context, ids []string) (map[string]Product, error)
 	Create(ctx context.Context, p Product) (Product, error)
+	// Update applies in. Setting Stock below what carts currently hold is
+	// refused with ErrStockBelowReserved, checked in the same write as the
+	// change so a concurrent Reserve can't slip in between.
 	Update(ctx context.Context, id string, in UpdateInput) (Product, error)
 	Delete(ctx context.Context, id string) error
-	DecrementStock(ctx context.Context, productID string, qty int64) error
+	// DecrementStock takes qty off the available stock, failing with
+	// ErrInsufficientStock rather than eating into other carts' holds. It
+	// returns the product as updated.
+	DecrementStock(ctx context.Context, productID string, qty int64) (Product, error)
+	IncrementStock(ctx context.Context, productID string, qty int64) error
+	// Reserve holds qty of the available stock; see Product.Reserved.
+	Reserve(ctx context.Context, productID string, qty int64) error
+	// Unreserve releases qty held by Reserve.
+	Unreserve(ctx context.Context, productID string, qty int64) error
+	// AddSold adds qty to the units sold; a negative qty takes back a
+	// cancelled sale or restocked return.
+	AddSold(ctx context.Context, productID string, qty int64) error
 
 	AddReview(ctx context.Context, productID string, r Review) (Review, error)
 	DeleteReview(ctx context.Context, productID string, reviewID string) error
@@ -0,0 +1,170 @@
+package products
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Field weights of the product text index: a term in the name counts five
+// times as much as one in the description.
+const (
+	NameWeight        = 10
+	DescriptionWeight = 2
+)
+
+// MaxQueryLen caps ListFilter.Query, in characters.
+const MaxQueryLen = 200
+
+// Search is a parsed full-text query, following MongoDB $text rules for the
+// English language so every Repo matches and ranks products alike:
+//
+//   - words are case-insensitive, stemmed, and stop words are ignored;
+//   - a product matches if it contains any word, and every "quoted phrase";
+//   - a -word excludes products containing it.
+type Search struct {
+	Terms    []string
+	Phrases  []string
+	Excluded []string
+}
+
+// ParseSearch parses q. Blank q parses to a Search that is Empty.
+func ParseSearch(q string) Search {
+	var s Search
+
+	// quoted phrases first; an unclosed quote runs to the end
+	for {
+		i := strings.IndexByte(q, '"')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(q[i+1:], '"')
+		var phrase string
+		if j < 0 {
+			phrase, q = q[i+1:], q[:i]
+		} else {
+			phrase, q = q[i+1:i+1+j], q[:i]+" "+q[i+2+j:]
+		}
+		if p := strings.ToLower(strings.TrimSpace(phrase)); p != "" {
+			s.Phrases = append(s.Phrases, p)
+			// phrase words still count towards the score
+			s.Terms = append(s.Terms, stems(p)...)
+		}
+	}
+
+	for _, f := range strings.Fields(q) {
+		if strings.HasPrefix(f, "-") {
+			s.Excluded = append(s.Excluded, stems(f[1:])...)
+			continue
+		}
+		s.Terms = append(s.Terms, stems(f)...)
+	}
+	return s
+}
+
+// Empty reports whether there is nothing to search for; such a query matches
+// no products rather than all of them.
+func (s Search) Empty() bool {
+	return len(s.Terms) == 0 && len(s.Phrases) == 0
+}
+
+// Score ranks p against s, higher first; 0 means p doesn't match.
+func (s Search) Score(p Product) float64 {
+	if s.Empty() {
+		return 0
+	}
+
+	name, desc := stems(p.Name), stems(p.Description)
+	for _, ex := range s.Excluded {
+		if contains(name, ex) || contains(desc, ex) {
+			return 0
+		}
+	}
+	text := strings.ToLower(p.Name + "\n" + p.Description)
+	for _, ph := range s.Phrases {
+		if !strings.Contains(text, ph) {
+			return 0
+		}
+	}
+
+	score := fieldScore(name, s.Terms, NameWeight) + fieldScore(desc, s.Terms, DescriptionWeight)
+	if score == 0 && len(s.Phrases) > 0 {
+		// a phrase made only of stop words still matched
+		score = 1
+	}
+	return score
+}
+
+// fieldScore follows the text index scoring: each distinct query term found
+// in the field adds weight * (0.5 + 0.5 * share of the field's words it makes up).
+func fieldScore(tokens, terms []string, weight float64) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		counts[t]++
+	}
+
+	score := 0.0
+	seen := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		if seen[t] || counts[t] == 0 {
+			continue
+		}
+		seen[t] = true
+		score += weight * (0.5 + 0.5*float64(counts[t])/float64(len(tokens)))
+	}
+	return score
+}
+
+func contains(tokens []string, t string) bool {
+	for _, x := range tokens {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// stems splits text into lower-case stemmed words, dropping stop words.
+func stems(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		out = append(out, stem(w))
+	}
+	return out
+}
+
+// stem strips the common English inflections. It is a small subset of the
+// Snowball stemmer MongoDB uses, enough for catalog words such as
+// "keyboards" or "batteries".
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	case len(w) > 5 && strings.HasSuffix(w, "ing"):
+		return w[:len(w)-3]
+	case len(w) > 4 && strings.HasSuffix(w, "ed"):
+		return w[:len(w)-2]
+	}
+	return w
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "so": true, "than": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "were": true, "will": true, "with": true,
+}
@@ -0,0 +1,102 @@
+package products_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		want products.Search
+	}{
+		{"blank", "   ", products.Search{}},
+		{"words are lowered and stemmed", "Wireless Keyboards", products.Search{
+			Terms: []string{"wireless", "keyboard"},
+		}},
+		{"stop words are dropped", "the case for a phone", products.Search{
+			Terms: []string{"case", "phone"},
+		}},
+		{"only stop words", "the and of", products.Search{}},
+		{"inflections", "batteries charging tested glass", products.Search{
+			Terms: []string{"battery", "charg", "test", "glass"},
+		}},
+		{"phrase", `usb "Fast Charger" cable`, products.Search{
+			Terms:   []string{"fast", "charger", "usb", "cable"},
+			Phrases: []string{"fast charger"},
+		}},
+		{"unclosed phrase runs to the end", `mouse "gaming pad`, products.Search{
+			Terms:   []string{"gam", "pad", "mouse"},
+			Phrases: []string{"gaming pad"},
+		}},
+		{"empty phrase is ignored", `"" mouse`, products.Search{
+			Terms: []string{"mouse"},
+		}},
+		{"exclusion", "headphones -wired", products.Search{
+			Terms:    []string{"headphone"},
+			Excluded: []string{"wir"},
+		}},
+		{"exclusion alone", "-refurbished", products.Search{
+			Excluded: []string{"refurbish"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := products.ParseSearch(tt.q)
+			if !reflect.DeepEqual(normalize(got), normalize(tt.want)) {
+				t.Errorf("ParseSearch(%q) = %+v, want %+v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+// normalize makes nil and empty slices compare equal.
+func normalize(s products.Search) products.Search {
+	for _, f := range []*[]string{&s.Terms, &s.Phrases, &s.Excluded} {
+		if len(*f) == 0 {
+			*f = nil
+		}
+	}
+	return s
+}
+
+func TestSearchScore(t *testing.T) {
+	keyboard := products.Product{
+		Name:        "Wireless Keyboard",
+		Description: "Quiet keys with a rechargeable battery",
+	}
+	tests := []struct {
+		name string
+		q    string
+		p    products.Product
+		want float64
+	}{
+		{"blank query matches nothing", "", keyboard, 0},
+		{"no term found", "monitor", keyboard, 0},
+		// name: 10 * (0.5 + 0.5*1/2)
+		{"name term", "keyboards", keyboard, 7.5},
+		// description words are quiet, key, rechargeable, battery: 2 * (0.5 + 0.5*1/4)
+		{"description term", "batteries", keyboard, 1.25},
+		{"name and description terms add up", "keyboard battery", keyboard, 7.5 + 1.25},
+		{"a repeated term counts once", "keyboard keyboards", keyboard, 7.5},
+		{"stop words don't score", "the with keyboard", keyboard, 7.5},
+		{"matching phrase", `"wireless keyboard"`, keyboard, 7.5 + 7.5},
+		{"phrase is case-insensitive", `"RECHARGEABLE battery"`, keyboard, 1.25 + 1.25},
+		{"missing phrase fails the match", `keyboard "bluetooth keyboard"`, keyboard, 0},
+		{"phrase of stop words still matches", `"with a"`, keyboard, 1},
+		{"excluded name word", "keyboard -wireless", keyboard, 0},
+		{"excluded description word is stemmed", "keyboard -batteries", keyboard, 0},
+		{"exclusion not found", "keyboard -wired", keyboard, 7.5},
+		{"exclusion alone matches nothing", "-wired", keyboard, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := products.ParseSearch(tt.q).Score(tt.p); got != tt.want {
+				t.Errorf("Score(%q) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import "context"
 
 type Service interface {
 	List(ctx context.Context, f ListFilter) ([]Product, int64, error)
+	// Facets counts what List would return for f, broken down for filtering.
+	Facets(ctx context.Context, f ListFilter) (Facets, error)
 	Get(ctx context.Context, id string) (Product, error)
 	Create(ctx context.Context, in CreateInput) (Product, error)
 	Update(ctx context.Context, id string, in UpdateInput) (Product, error)
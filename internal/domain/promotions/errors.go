@@ -0,0 +1,19 @@
+package promotions
+
+import "errors"
+
+var (
+	ErrInvalidID     = errors.New("invalid id")
+	ErrNotFound      = errors.New("not found")
+	ErrInvalidCode   = errors.New("invalid code")
+	ErrCodeTaken     = errors.New("code already exists")
+	ErrInvalidType   = errors.New("invalid discount type")
+	ErrInvalidValue  = errors.New("invalid discount value")
+	ErrInvalidLimit  = errors.New("invalid usage limit")
+	ErrInvalidWindow = errors.New("invalid validity window")
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrNotActive     = errors.New("promo code is not active")
+	ErrMinOrderTotal = errors.New("order total is below the promo minimum")
+	ErrNotApplicable = errors.New("promo code does not apply to these items")
+	ErrUsageLimit    = errors.New("promo code usage limit reached")
+)
@@ -0,0 +1,159 @@
+package promotions
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+type Promotion struct {
+	ID          string
+	Code        string
+	Description string
+	Type        DiscountType
+	// Value is a percent (0-100] for percentage codes and an amount in major
+	// units of the store currency for fixed ones.
+	Value         float64
+	MinOrderTotal money.Money
+
+	// Empty scope means the whole order; otherwise only matching lines are discounted.
+	CategoryIDs []string
+	ProductIDs  []string
+
+	// 0 means unlimited.
+	MaxUses        int64
+	MaxUsesPerUser int64
+	UsedCount      int64
+
+	StartsAt *time.Time
+	EndsAt   *time.Time
+	Active   bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Redemption struct {
+	PromotionID string
+	UserID      string
+	OrderID     string
+	CreatedAt   time.Time
+}
+
+// Line is an order line as seen by a promotion.
+type Line struct {
+	ProductID  string
+	CategoryID string
+	LineTotal  money.Money
+}
+
+type ListFilter struct {
+	Offset int64
+	Limit  int64
+}
+
+type CreateInput struct {
+	Code           string
+	Description    string
+	Type           DiscountType
+	Value          float64
+	MinOrderTotal  money.Money
+	CategoryIDs    []string
+	ProductIDs     []string
+	MaxUses        int64
+	MaxUsesPerUser int64
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Active         bool
+}
+
+type UpdateInput struct {
+	Description    *string
+	Type           *DiscountType
+	Value          *float64
+	MinOrderTotal  *money.Money
+	CategoryIDs    *[]string
+	ProductIDs     *[]string
+	MaxUses        *int64
+	MaxUsesPerUser *int64
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Active         *bool
+}
+
+// NormalizeCode is how codes are stored and looked up: trimmed and upper-cased.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (t DiscountType) Valid() bool {
+	return t == DiscountPercentage || t == DiscountFixed
+}
+
+func (p Promotion) ActiveAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// InScope reports whether p applies to l.
+func (p Promotion) InScope(l Line) bool {
+	if len(p.CategoryIDs) == 0 && len(p.ProductIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == l.ProductID {
+			return true
+		}
+	}
+	for _, id := range p.CategoryIDs {
+		if id == l.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns the amount p takes off an order made of lines, rounded to
+// the minor unit. It never exceeds the total of the lines in scope.
+func (p Promotion) Discount(lines []Line) (money.Money, error) {
+	var subtotal, eligible money.Money
+	for _, l := range lines {
+		subtotal = subtotal.Add(l.LineTotal)
+		if p.InScope(l) {
+			eligible = eligible.Add(l.LineTotal)
+		}
+	}
+	zero := money.Zero(subtotal.Currency)
+
+	if subtotal.Cmp(p.MinOrderTotal) < 0 {
+		return zero, ErrMinOrderTotal
+	}
+	if !eligible.IsPositive() {
+		return zero, ErrNotApplicable
+	}
+
+	switch p.Type {
+	case DiscountPercentage:
+		return eligible.Percent(p.Value), nil
+	case DiscountFixed:
+		return money.Min(money.FromMajor(p.Value, eligible.Currency), eligible), nil
+	default:
+		return zero, ErrInvalidType
+	}
+}
@@ -0,0 +1,93 @@
+package promotions_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+)
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func TestDiscount(t *testing.T) {
+	lines := []promotions.Line{
+		{ProductID: "mouse", CategoryID: "accessories", LineTotal: kzt(3000)},
+		{ProductID: "keyboard", CategoryID: "keyboards", LineTotal: kzt(7000)},
+	}
+	tests := []struct {
+		name    string
+		p       promotions.Promotion
+		want    money.Money
+		wantErr error
+	}{
+		{"percentage of the order", promotions.Promotion{Type: promotions.DiscountPercentage, Value: 10}, kzt(1000), nil},
+		{"percentage rounds to the minor unit", promotions.Promotion{Type: promotions.DiscountPercentage, Value: 33.335}, kzt(3334), nil},
+		{"fixed", promotions.Promotion{Type: promotions.DiscountFixed, Value: 15}, kzt(1500), nil},
+		{"fixed capped at the order", promotions.Promotion{Type: promotions.DiscountFixed, Value: 500}, kzt(10000), nil},
+		{"percentage of a category", promotions.Promotion{
+			Type: promotions.DiscountPercentage, Value: 50, CategoryIDs: []string{"keyboards"},
+		}, kzt(3500), nil},
+		{"fixed capped at the lines in scope", promotions.Promotion{
+			Type: promotions.DiscountFixed, Value: 50, ProductIDs: []string{"mouse"},
+		}, kzt(3000), nil},
+		{"product or category in scope", promotions.Promotion{
+			Type: promotions.DiscountPercentage, Value: 10, ProductIDs: []string{"mouse"}, CategoryIDs: []string{"keyboards"},
+		}, kzt(1000), nil},
+		{"nothing in scope", promotions.Promotion{
+			Type: promotions.DiscountFixed, Value: 5, CategoryIDs: []string{"monitors"},
+		}, kzt(0), promotions.ErrNotApplicable},
+		{"minimum met by the whole order", promotions.Promotion{
+			Type: promotions.DiscountFixed, Value: 5, MinOrderTotal: kzt(10000), ProductIDs: []string{"mouse"},
+		}, kzt(500), nil},
+		{"below the minimum", promotions.Promotion{
+			Type: promotions.DiscountFixed, Value: 5, MinOrderTotal: kzt(10001),
+		}, kzt(0), promotions.ErrMinOrderTotal},
+		{"unknown type", promotions.Promotion{Type: "bogo", Value: 5}, kzt(0), promotions.ErrInvalidType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.p.Discount(lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Discount error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Discount = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveAt(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	p := promotions.Promotion{Active: true, StartsAt: &start, EndsAt: &end}
+
+	tests := []struct {
+		name string
+		p    promotions.Promotion
+		at   time.Time
+		want bool
+	}{
+		{"inside the window", p, start.Add(time.Hour), true},
+		{"at the start", p, start, true},
+		{"before the start", p, start.Add(-time.Second), false},
+		{"at the end", p, end, false},
+		{"switched off", promotions.Promotion{}, start, false},
+		{"no window", promotions.Promotion{Active: true}, start, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.ActiveAt(tt.at); got != tt.want {
+				t.Errorf("ActiveAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	if got := promotions.NormalizeCode("  summer10 "); got != "SUMMER10" {
+		t.Errorf("NormalizeCode = %q, want %q", got, "SUMMER10")
+	}
+}
@@ -0,0 +1,21 @@
+package promotions
+
+import "context"
+
+type Repo interface {
+	List(ctx context.Context, f ListFilter) ([]Promotion, error)
+	Count(ctx context.Context) (int64, error)
+	GetByID(ctx context.Context, id string) (Promotion, error)
+	GetByCode(ctx context.Context, code string) (Promotion, error)
+	Create(ctx context.Context, p Promotion) (Promotion, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Promotion, error)
+	Delete(ctx context.Context, id string) error
+
+	CountRedemptions(ctx context.Context, promotionID string, userID string) (int64, error)
+	// Redeem records r and bumps the usage counter, or returns ErrUsageLimit
+	// if the global limit is already reached.
+	Redeem(ctx context.Context, r Redemption) error
+	// Release undoes the redemption made for orderID, giving the use back to
+	// the global and per-user limits. It does nothing if there is none.
+	Release(ctx context.Context, promotionID string, orderID string) error
+}
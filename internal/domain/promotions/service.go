@@ -0,0 +1,11 @@
+package promotions
+
+import "context"
+
+type Service interface {
+	List(ctx context.Context, f ListFilter) ([]Promotion, int64, error)
+	Get(ctx context.Context, id string) (Promotion, error)
+	Create(ctx context.Context, in CreateInput) (Promotion, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Promotion, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,11 @@
+package reservations
+
+import "errors"
+
+var (
+	ErrInvalidID         = errors.New("invalid id")
+	ErrInvalidProduct    = errors.New("invalid product")
+	ErrInvalidQty        = errors.New("invalid quantity")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrNotFound          = errors.New("not found")
+)
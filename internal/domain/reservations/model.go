@@ -0,0 +1,21 @@
+package reservations
+
+import "time"
+
+// Reservation holds Quantity of a product for one user's cart until
+// ExpiresAt. A user has at most one reservation per product; the held
+// quantity is also counted in the product's Reserved stock.
+type Reservation struct {
+	UserID    string
+	ProductID string
+	Quantity  int64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Expired reports whether the hold has lapsed at now. Expired holds still
+// count against stock until the sweeper releases them.
+func (r Reservation) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package reservations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+)
+
+func TestExpired(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := reservations.Reservation{ExpiresAt: at}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at.Add(-time.Second), false},
+		{at, true},
+		{at.Add(time.Second), true},
+	}
+	for _, tt := range tests {
+		if got := r.Expired(tt.now); got != tt.want {
+			t.Errorf("Expired(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
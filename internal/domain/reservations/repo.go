@@ -0,0 +1,21 @@
+package reservations
+
+import (
+	"context"
+	"time"
+)
+
+type Repo interface {
+	Get(ctx context.Context, userID, productID string) (Reservation, error)
+	ListByUser(ctx context.Context, userID string) ([]Reservation, error)
+	// ListExpired returns up to limit reservations that expired at or before
+	// now, oldest first.
+	ListExpired(ctx context.Context, now time.Time, limit int64) ([]Reservation, error)
+	// Upsert creates or replaces the reservation for r.UserID and r.ProductID.
+	Upsert(ctx context.Context, r Reservation) error
+	// Delete removes and returns the reservation, or ErrNotFound.
+	Delete(ctx context.Context, userID, productID string) (Reservation, error)
+	// DeleteExpired is Delete, but only while the reservation is still
+	// expired at now, so a hold renewed in the meantime is kept.
+	DeleteExpired(ctx context.Context, userID, productID string, now time.Time) (Reservation, error)
+}
@@ -0,0 +1,15 @@
+package reservations
+
+import "context"
+
+type Service interface {
+	// Hold sets the user's hold on productID to qty and restarts its expiry.
+	// Only the difference to an existing hold is taken from available stock.
+	Hold(ctx context.Context, userID, productID string, qty int64) (Reservation, error)
+	// Release drops the user's hold on productID; it is a no-op without one.
+	Release(ctx context.Context, userID, productID string) error
+	ReleaseAll(ctx context.Context, userID string) error
+	ListByUser(ctx context.Context, userID string) ([]Reservation, error)
+	// SweepExpired releases holds past their expiry and returns how many it released.
+	SweepExpired(ctx context.Context) (int, error)
+}
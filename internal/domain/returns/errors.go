@@ -0,0 +1,16 @@
+package returns
+
+import "errors"
+
+var (
+	ErrInvalidID         = errors.New("invalid id")
+	ErrNotFound          = errors.New("return not found")
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalidItems      = errors.New("invalid items")
+	ErrInvalidProduct    = errors.New("product is not on this order")
+	ErrInvalidQty        = errors.New("invalid quantity")
+	ErrQtyExceeded       = errors.New("quantity exceeds what can still be returned")
+	ErrInvalidReason     = errors.New("reason is required")
+	ErrNotReturnable     = errors.New("only delivered orders can be returned")
+	ErrInvalidTransition = errors.New("invalid return status transition")
+)
@@ -0,0 +1,95 @@
+package returns
+
+import (
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+type Status string
+
+const (
+	StatusRequested Status = "requested"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	// StatusReceived means the goods are back and the refund was recorded.
+	StatusReceived Status = "received"
+)
+
+var transitions = map[Status][]Status{
+	StatusRequested: {StatusApproved, StatusRejected},
+	StatusApproved:  {StatusReceived},
+	StatusRejected:  {},
+	StatusReceived:  {},
+}
+
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Item is one returned order line. Price and refund are snapshotted from the
+// order when the return is opened.
+type Item struct {
+	ProductID   string
+	ProductName string
+	Quantity    int64
+	UnitPrice   money.Money
+	// RefundAmount is the line's share of what the customer paid, i.e. net of
+	// the order's discounts. Delivery fees are not refunded.
+	RefundAmount money.Money
+}
+
+type StatusChange struct {
+	From      Status
+	To        Status
+	ChangedBy string
+	Note      string
+	ChangedAt time.Time
+}
+
+type Return struct {
+	ID           string
+	OrderID      string
+	UserID       string
+	Items        []Item
+	Reason       string
+	Status       Status
+	RefundAmount money.Money
+	// Restocked is set when received items were put back into stock.
+	Restocked     bool
+	StatusHistory []StatusChange
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type ListFilter struct {
+	Status  *Status
+	OrderID *string
+	Offset  int64
+	Limit   int64
+}
+
+// CreateInput lists the lines to return; only ProductID and Quantity are read.
+type CreateInput struct {
+	Items  []Item
+	Reason string
+}
+
+type ReviewInput struct {
+	Note string
+}
+
+type ReceiveInput struct {
+	Restock bool
+	Note    string
+}
@@ -0,0 +1,30 @@
+package returns_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/returns"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	all := []returns.Status{returns.StatusRequested, returns.StatusApproved, returns.StatusRejected, returns.StatusReceived}
+	allowed := map[returns.Status][]returns.Status{
+		returns.StatusRequested: {returns.StatusApproved, returns.StatusRejected},
+		returns.StatusApproved:  {returns.StatusReceived},
+	}
+
+	for _, from := range all {
+		for _, to := range all {
+			want := false
+			for _, ok := range allowed[from] {
+				want = want || ok == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: CanTransitionTo = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	if returns.Status("lost").Valid() {
+		t.Error("unknown status is Valid")
+	}
+}
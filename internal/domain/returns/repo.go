@@ -0,0 +1,17 @@
+package returns
+
+import "context"
+
+type Repo interface {
+	Create(ctx context.Context, r Return) (Return, error)
+	// GetByID scopes the lookup to userID when it is non-nil.
+	GetByID(ctx context.Context, id string, userID *string) (Return, error)
+	List(ctx context.Context, userID *string, f ListFilter) ([]Return, error)
+	Count(ctx context.Context, userID *string, f ListFilter) (int64, error)
+	// UpdateStatus applies ch only if the return is still in ch.From; otherwise
+	// it returns ErrInvalidTransition.
+	UpdateStatus(ctx context.Context, id string, ch StatusChange, restocked bool) (Return, error)
+	// ReturnedQuantities sums quantities per product over the order's returns
+	// that were not rejected.
+	ReturnedQuantities(ctx context.Context, orderID string) (map[string]int64, error)
+}
@@ -0,0 +1,14 @@
+package returns
+
+import "context"
+
+type Service interface {
+	Create(ctx context.Context, orderID string, userID string, in CreateInput) (Return, error)
+	List(ctx context.Context, userID string, isAdmin bool, f ListFilter) ([]Return, int64, error)
+	Get(ctx context.Context, id string, userID string, isAdmin bool) (Return, error)
+	Approve(ctx context.Context, id string, actorID string, in ReviewInput) (Return, error)
+	Reject(ctx context.Context, id string, actorID string, in ReviewInput) (Return, error)
+	// Receive marks returned goods as arrived, optionally restocks them and
+	// refunds them through the order's payment, up to what is left of it.
+	Receive(ctx context.Context, id string, actorID string, in ReceiveInput) (Return, error)
+}
@@ -1,15 +1,49 @@
 package statistics
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
 
 type SalesStatistics struct {
-	TotalOrders     int64   `json:"total_orders"`
-	TotalRevenue    float64 `json:"total_revenue"`
-	AverageOrder    float64 `json:"average_order"`
-	PendingOrders   int64   `json:"pending_orders"`
-	ShippedOrders   int64   `json:"shipped_orders"`
-	DeliveredOrders int64   `json:"delivered_orders"`
-	CancelledOrders int64   `json:"cancelled_orders"`
+	TotalOrders            int64       `json:"total_orders"`
+	TotalRevenue           money.Money `json:"total_revenue" swaggertype:"number"` // net of refunds
+	TotalDiscounts         money.Money `json:"total_discounts" swaggertype:"number"`
+	TotalRefunds           money.Money `json:"total_refunds" swaggertype:"number"`
+	TotalTax               money.Money `json:"total_tax" swaggertype:"number"` // included in revenue
+	AverageOrder           money.Money `json:"average_order" swaggertype:"number"`
+	PendingOrders          int64       `json:"pending_orders"`
+	PaidOrders             int64       `json:"paid_orders"`
+	PartiallyShippedOrders int64       `json:"partially_shipped_orders"`
+	ShippedOrders          int64       `json:"shipped_orders"`
+	DeliveredOrders        int64       `json:"delivered_orders"`
+	CancelledOrders        int64       `json:"cancelled_orders"`
+}
+
+// MarshalJSON keeps amounts as plain numbers in major units, as they were
+// before they became Money, and adds the currency they are in. The outer
+// fields shadow the Money ones of the same name.
+func (s SalesStatistics) MarshalJSON() ([]byte, error) {
+	type plain SalesStatistics
+	return json.Marshal(struct {
+		plain
+		TotalRevenue   float64        `json:"total_revenue"`
+		TotalDiscounts float64        `json:"total_discounts"`
+		TotalRefunds   float64        `json:"total_refunds"`
+		TotalTax       float64        `json:"total_tax"`
+		AverageOrder   float64        `json:"average_order"`
+		Currency       money.Currency `json:"currency"`
+	}{
+		plain:          plain(s),
+		TotalRevenue:   s.TotalRevenue.Major(),
+		TotalDiscounts: s.TotalDiscounts.Major(),
+		TotalRefunds:   s.TotalRefunds.Major(),
+		TotalTax:       s.TotalTax.Major(),
+		AverageOrder:   s.AverageOrder.Major(),
+		Currency:       s.TotalRevenue.Currency,
+	})
 }
 
 type ProductStatistics struct {
@@ -0,0 +1,8 @@
+package stock
+
+import "errors"
+
+var (
+	ErrInvalidID = errors.New("invalid id")
+	ErrNotFound  = errors.New("not found")
+)
@@ -0,0 +1,61 @@
+package stock
+
+import "time"
+
+// Reason is why a product's stock changed.
+type Reason string
+
+const (
+	// ReasonAdjustment is stock set by an admin, including a new product's initial stock.
+	ReasonAdjustment Reason = "adjustment"
+	// ReasonOrder is stock taken by a placed order.
+	ReasonOrder Reason = "order"
+	// ReasonCancellation is an order's stock put back when it was cancelled.
+	ReasonCancellation Reason = "cancellation"
+	// ReasonReturn is returned items put back into stock.
+	ReasonReturn Reason = "return"
+	// ReasonImport is stock brought in from outside the app, e.g. the opening
+	// balances cmd/migrate writes for stock that predates the ledger.
+	ReasonImport Reason = "import"
+)
+
+// Movement is one entry of the append-only stock ledger. Summing a product's
+// deltas gives its current on-hand stock; reservations are not movements.
+type Movement struct {
+	ID        string
+	ProductID string
+	// Delta is positive when stock was added and negative when it was taken.
+	Delta  int64
+	Reason Reason
+	// ActorID is the user who caused the change, or empty for system changes.
+	ActorID string
+	// Reference is the order or return behind the change, if any.
+	Reference string
+	Note      string
+	CreatedAt time.Time
+}
+
+type ListFilter struct {
+	ProductID string
+	Offset    int64
+	Limit     int64
+}
+
+// Reconciliation compares a product's stock with the sum of its ledger.
+type Reconciliation struct {
+	ProductID   string
+	ProductName string
+	Stock       int64
+	Ledger      int64
+	Movements   int64
+}
+
+// Drift is how much stock changed without a movement; positive means the
+// product has more stock than its ledger explains.
+func (r Reconciliation) Drift() int64 {
+	return r.Stock - r.Ledger
+}
+
+func (r Reconciliation) Balanced() bool {
+	return r.Drift() == 0
+}
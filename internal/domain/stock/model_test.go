@@ -0,0 +1,31 @@
+package stock_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+)
+
+func TestDrift(t *testing.T) {
+	tests := []struct {
+		name          string
+		stock, ledger int64
+		drift         int64
+	}{
+		{"balanced", 7, 7, 0},
+		{"more stock than movements", 10, 7, 3},
+		{"less stock than movements", 4, 7, -3},
+		{"no movements yet", 5, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := stock.Reconciliation{Stock: tt.stock, Ledger: tt.ledger}
+			if got := r.Drift(); got != tt.drift {
+				t.Errorf("Drift = %d, want %d", got, tt.drift)
+			}
+			if got := r.Balanced(); got != (tt.drift == 0) {
+				t.Errorf("Balanced = %v with drift %d", got, tt.drift)
+			}
+		})
+	}
+}
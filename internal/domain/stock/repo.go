@@ -0,0 +1,15 @@
+package stock
+
+import "context"
+
+type Repo interface {
+	// Append adds movements to the ledger. Movements are never changed or removed.
+	Append(ctx context.Context, ms ...Movement) error
+	// List returns a product's movements newest first.
+	List(ctx context.Context, f ListFilter) ([]Movement, error)
+	Count(ctx context.Context, f ListFilter) (int64, error)
+	// Reconcile sums the ledger of every product, or of productID when it is
+	// not empty, and compares it with current stock, both read at the same
+	// point in time. An unknown productID returns ErrNotFound.
+	Reconcile(ctx context.Context, productID string) ([]Reconciliation, error)
+}
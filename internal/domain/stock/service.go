@@ -0,0 +1,13 @@
+package stock
+
+import "context"
+
+type Service interface {
+	// History returns a page of a product's movements and how many it has.
+	History(ctx context.Context, f ListFilter) ([]Movement, int64, error)
+	// Check reconciles one product against its ledger.
+	Check(ctx context.Context, productID string) (Reconciliation, error)
+	// Reconcile checks every product. Unless all is set only products whose
+	// stock disagrees with their ledger are returned.
+	Reconcile(ctx context.Context, all bool) ([]Reconciliation, error)
+}
@@ -0,0 +1,11 @@
+package taxes
+
+import "errors"
+
+var (
+	ErrInvalidID       = errors.New("invalid id")
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidName     = errors.New("invalid name")
+	ErrInvalidRate     = errors.New("invalid rate")
+	ErrInvalidCategory = errors.New("invalid category")
+)
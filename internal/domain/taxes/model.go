@@ -0,0 +1,137 @@
+package taxes
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+// Rule charges Rate percent on order lines. Empty CategoryID, Country and
+// Region match anything; see Calculate for how overlapping rules combine.
+type Rule struct {
+	ID   string
+	Name string // printed on invoices, e.g. "VAT"
+	Rate float64
+	// Inclusive rules are already part of the price: the tax is carved out of
+	// the line total instead of being added on top.
+	Inclusive  bool
+	CategoryID string
+	Country    string
+	Region     string
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Destination is where an order ships, matched against Rule.Country and
+// Rule.Region case-insensitively.
+type Destination struct {
+	Country string
+	Region  string
+}
+
+// Line is an order line's taxable amount, after its share of discounts.
+type Line struct {
+	CategoryID string
+	Amount     money.Money
+}
+
+// Charge is the tax one rule charges on the lines it was picked for.
+type Charge struct {
+	Rule   Rule
+	Base   money.Money
+	Amount money.Money
+}
+
+func (r Rule) matches(l Line, d Destination) bool {
+	if r.CategoryID != "" && r.CategoryID != l.CategoryID {
+		return false
+	}
+	if r.Country != "" && !strings.EqualFold(r.Country, strings.TrimSpace(d.Country)) {
+		return false
+	}
+	if r.Region != "" && !strings.EqualFold(r.Region, strings.TrimSpace(d.Region)) {
+		return false
+	}
+	return true
+}
+
+// specificity ranks matching rules: a category rule beats a regional one,
+// which beats a country-wide one, which beats a catch-all.
+func (r Rule) specificity() int {
+	n := 0
+	if r.CategoryID != "" {
+		n += 4
+	}
+	if r.Region != "" {
+		n += 2
+	}
+	if r.Country != "" {
+		n++
+	}
+	return n
+}
+
+// Calculate taxes lines shipped to d. Each line is taxed by the single most
+// specific active rule matching it, the earlier rule winning a tie, so a
+// category rule with a 0 rate exempts that category from a general rate.
+// Tax is rounded once per rule over all of its lines. Charges come back in
+// the order of rules.
+func Calculate(rules []Rule, d Destination, lines []Line) []Charge {
+	bases := make([]money.Money, len(rules))
+	used := make([]bool, len(rules))
+	for _, l := range lines {
+		best := -1
+		for i, r := range rules {
+			if !r.Active || !r.matches(l, d) {
+				continue
+			}
+			if best < 0 || r.specificity() > rules[best].specificity() {
+				best = i
+			}
+		}
+		if best >= 0 {
+			bases[best] = bases[best].Add(l.Amount)
+			used[best] = true
+		}
+	}
+
+	var out []Charge
+	for i, r := range rules {
+		if !used[i] {
+			continue
+		}
+		rate := r.Rate
+		if r.Inclusive {
+			// the share of a gross amount that is tax: rate / (100 + rate)
+			rate = 100 * r.Rate / (100 + r.Rate)
+		}
+		out = append(out, Charge{Rule: r, Base: bases[i], Amount: bases[i].Percent(rate)})
+	}
+	return out
+}
+
+type ListFilter struct {
+	ActiveOnly bool
+}
+
+type CreateInput struct {
+	Name       string
+	Rate       float64
+	Inclusive  bool
+	CategoryID string
+	Country    string
+	Region     string
+	Active     bool
+}
+
+type UpdateInput struct {
+	Name       *string
+	Rate       *float64
+	Inclusive  *bool
+	CategoryID *string
+	Country    *string
+	Region     *string
+	Active     *bool
+}
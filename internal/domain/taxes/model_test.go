@@ -0,0 +1,113 @@
+package taxes_test
+
+import (
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+)
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func TestCalculate(t *testing.T) {
+	vat := taxes.Rule{ID: "vat", Rate: 12, Country: "KZ", Active: true}
+	almaty := taxes.Rule{ID: "almaty", Rate: 15, Country: "KZ", Region: "Almaty", Active: true}
+	books := taxes.Rule{ID: "books", Rate: 0, CategoryID: "books", Active: true}
+	catchAll := taxes.Rule{ID: "any", Rate: 5, Active: true}
+	kz := taxes.Destination{Country: " kz "}
+
+	tests := []struct {
+		name  string
+		rules []taxes.Rule
+		dest  taxes.Destination
+		lines []taxes.Line
+		want  map[string][2]int64 // rule ID -> base, amount
+	}{
+		{
+			name:  "added on top",
+			rules: []taxes.Rule{vat},
+			dest:  kz,
+			lines: []taxes.Line{{Amount: kzt(10000)}},
+			want:  map[string][2]int64{"vat": {10000, 1200}},
+		},
+		{
+			name:  "inclusive carved out",
+			rules: []taxes.Rule{{ID: "vat", Rate: 12, Inclusive: true, Active: true}},
+			lines: []taxes.Line{{Amount: kzt(11200)}},
+			want:  map[string][2]int64{"vat": {11200, 1200}},
+		},
+		{
+			name:  "rounded once per rule",
+			rules: []taxes.Rule{vat},
+			dest:  kz,
+			// 0.12*3 = 0.36 per line would round to 0 each; summed it is 1
+			lines: []taxes.Line{{Amount: kzt(3)}, {Amount: kzt(3)}, {Amount: kzt(3)}},
+			want:  map[string][2]int64{"vat": {9, 1}},
+		},
+		{
+			name:  "category exemption beats country rate",
+			rules: []taxes.Rule{vat, books},
+			dest:  kz,
+			lines: []taxes.Line{{CategoryID: "books", Amount: kzt(2000)}, {CategoryID: "toys", Amount: kzt(1000)}},
+			want:  map[string][2]int64{"vat": {1000, 120}, "books": {2000, 0}},
+		},
+		{
+			name:  "region beats country",
+			rules: []taxes.Rule{vat, almaty},
+			dest:  taxes.Destination{Country: "KZ", Region: "almaty"},
+			lines: []taxes.Line{{Amount: kzt(1000)}},
+			want:  map[string][2]int64{"almaty": {1000, 150}},
+		},
+		{
+			name:  "other country falls back to catch-all",
+			rules: []taxes.Rule{vat, catchAll},
+			dest:  taxes.Destination{Country: "UZ"},
+			lines: []taxes.Line{{Amount: kzt(1000)}},
+			want:  map[string][2]int64{"any": {1000, 50}},
+		},
+		{
+			name:  "earlier rule wins a tie",
+			rules: []taxes.Rule{catchAll, {ID: "later", Rate: 7, Active: true}},
+			lines: []taxes.Line{{Amount: kzt(1000)}},
+			want:  map[string][2]int64{"any": {1000, 50}},
+		},
+		{
+			name:  "inactive rules ignored",
+			rules: []taxes.Rule{{ID: "vat", Rate: 12, Active: false}},
+			lines: []taxes.Line{{Amount: kzt(1000)}},
+			want:  map[string][2]int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taxes.Calculate(tt.rules, tt.dest, tt.lines)
+			if len(got) != len(tt.want) {
+				t.Fatalf("charges = %+v, want %v", got, tt.want)
+			}
+			for _, c := range got {
+				w, ok := tt.want[c.Rule.ID]
+				if !ok {
+					t.Errorf("unexpected charge for %s", c.Rule.ID)
+					continue
+				}
+				if c.Base != kzt(w[0]) || c.Amount != kzt(w[1]) {
+					t.Errorf("%s: base %v amount %v, want %v and %v", c.Rule.ID, c.Base, c.Amount, kzt(w[0]), kzt(w[1]))
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateKeepsRuleOrder(t *testing.T) {
+	rules := []taxes.Rule{
+		{ID: "books", Rate: 0, CategoryID: "books", Active: true},
+		{ID: "any", Rate: 5, Active: true},
+	}
+	got := taxes.Calculate(rules, taxes.Destination{}, []taxes.Line{
+		{CategoryID: "toys", Amount: kzt(100)},
+		{CategoryID: "books", Amount: kzt(100)},
+	})
+	if len(got) != 2 || got[0].Rule.ID != "books" || got[1].Rule.ID != "any" {
+		t.Errorf("charges = %+v, want books then any", got)
+	}
+}
@@ -0,0 +1,12 @@
+package taxes
+
+import "context"
+
+type Repo interface {
+	// List returns rules oldest first, the order Calculate breaks ties in.
+	List(ctx context.Context, f ListFilter) ([]Rule, error)
+	GetByID(ctx context.Context, id string) (Rule, error)
+	Create(ctx context.Context, r Rule) (Rule, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Rule, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,11 @@
+package taxes
+
+import "context"
+
+type Service interface {
+	List(ctx context.Context, f ListFilter) ([]Rule, error)
+	Get(ctx context.Context, id string) (Rule, error)
+	Create(ctx context.Context, in CreateInput) (Rule, error)
+	Update(ctx context.Context, id string, in UpdateInput) (Rule, error)
+	Delete(ctx context.Context, id string) error
+}
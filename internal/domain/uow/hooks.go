@@ -0,0 +1,34 @@
+package uow
+
+import "context"
+
+type hooksKey struct{}
+
+type hooks struct {
+	fns []func(ctx context.Context)
+}
+
+// AfterCommit registers fn to run once the outermost unit of work in ctx has
+// committed; nothing runs if it rolls back. Outside a unit of work fn runs
+// straight away.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	h, ok := ctx.Value(hooksKey{}).(*hooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	h.fns = append(h.fns, fn)
+}
+
+// WithHooks is for UnitOfWork implementations. It returns ctx carrying an empty
+// set of AfterCommit hooks, and a func that runs them in registration order.
+// The outermost WithinTx calls it for each attempt and runs only the hooks of
+// the attempt that committed; joined units of work must not call it.
+func WithHooks(ctx context.Context) (context.Context, func(ctx context.Context)) {
+	h := &hooks{}
+	return context.WithValue(ctx, hooksKey{}, h), func(ctx context.Context) {
+		for _, fn := range h.fns {
+			fn(ctx)
+		}
+	}
+}
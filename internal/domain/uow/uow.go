@@ -0,0 +1,9 @@
+package uow
+
+import "context"
+
+// UnitOfWork runs fn atomically. Repository calls made with the ctx passed to fn
+// take part in the same unit of work; if fn returns an error nothing is persisted.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/cart"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/gin-gonic/gin"
+)
+
+type CartHandler struct {
+	svc cart.Service
+}
+
+func NewCartHandler(svc cart.Service) *CartHandler {
+	return &CartHandler{svc: svc}
+}
+
+type AddCartItemRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int64  `json:"quantity" binding:"required"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int64 `json:"quantity" binding:"required"`
+}
+
+type CheckoutCartRequest struct {
+	PromoCode string `json:"promoCode"`
+	// Defaults to the profile name/phone/address when omitted.
+	ShippingAddress *ShippingAddressRequest `json:"shippingAddress"`
+	// Defaults to the cheapest active delivery method when omitted.
+	DeliveryMethodID string `json:"deliveryMethodId"`
+}
+
+// GetCart godoc
+// @Summary Get current user's cart with live prices (auth required)
+// @Tags Cart
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Router /cart [get]
+func (h *CartHandler) Get(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ct, err := h.svc.Get(c.Request.Context(), uid)
+	if err != nil {
+		writeCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartToJSON(ct))
+}
+
+// AddCartItem godoc
+// @Summary Add product to cart (auth required)
+// @Description Adding a product already in the cart increases its quantity.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param body body AddCartItemRequest true "Item"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /cart/items [post]
+func (h *CartHandler) AddItem(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ct, err := h.svc.AddItem(c.Request.Context(), uid, cart.AddItemInput{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+	})
+	if err != nil {
+		writeCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartToJSON(ct))
+}
+
+// UpdateCartItem godoc
+// @Summary Set quantity of a cart item (auth required)
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param productId path string true "Product ID"
+// @Param body body UpdateCartItemRequest true "Quantity"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /cart/items/{productId} [put]
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ct, err := h.svc.UpdateItem(c.Request.Context(), uid, c.Param("productId"), req.Quantity)
+	if err != nil {
+		writeCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartToJSON(ct))
+}
+
+// RemoveCartItem godoc
+// @Summary Remove product from cart (auth required)
+// @Tags Cart
+// @Produce json
+// @Param productId path string true "Product ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /cart/items/{productId} [delete]
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ct, err := h.svc.RemoveItem(c.Request.Context(), uid, c.Param("productId"))
+	if err != nil {
+		writeCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cartToJSON(ct))
+}
+
+// ClearCart godoc
+// @Summary Remove all items from cart (auth required)
+// @Tags Cart
+// @Produce json
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Router /cart [delete]
+func (h *CartHandler) Clear(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.svc.Clear(c.Request.Context(), uid); err != nil {
+		writeCartError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CheckoutCart godoc
+// @Summary Create an order from the cart and empty it (auth required)
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param body body CheckoutCartRequest false "Checkout options"
+// @Param currency query string false "Display currency, e.g. USD; defaults to the base currency"
+// @Param X-Currency header string false "Display currency when the currency query param is absent"
+// @Param Idempotency-Key header string false "Retry-safe key; a retry with the same key, query, X-Currency and body replays the first response"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /cart/checkout [post]
+func (h *CartHandler) Checkout(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CheckoutCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	cur, err := requestCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return
+	}
+
+	created, err := h.svc.Checkout(c.Request.Context(), uid, cart.CheckoutInput{
+		PromoCode:        req.PromoCode,
+		ShippingAddress:  req.ShippingAddress.toDomain(),
+		DeliveryMethodID: req.DeliveryMethodID,
+		Currency:         cur,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, cart.ErrEmptyCart):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cart is empty"})
+		case errors.Is(err, orders.ErrInvalidProduct):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cart contains an unavailable product"})
+		case errors.Is(err, cart.ErrCurrencyMismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": "a price is not in the store currency"})
+		default:
+			writeCreateOrderError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, orderToJSON(created, false))
+}
+
+func writeCartError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, cart.ErrInvalidID):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	case errors.Is(err, cart.ErrInvalidProduct):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productId"})
+	case errors.Is(err, cart.ErrInvalidQty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+	case errors.Is(err, cart.ErrInsufficientStock):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient stock"})
+	case errors.Is(err, cart.ErrItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not in cart"})
+	case errors.Is(err, cart.ErrCurrencyMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": "a price is not in the store currency"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func cartToJSON(ct cart.Cart) gin.H {
+	items := make([]gin.H, 0, len(ct.Items))
+	for _, it := range ct.Items {
+		items = append(items, gin.H{
+			"productId":   it.ProductID,
+			"productName": it.ProductName,
+			"quantity":    it.Quantity,
+			"unitPrice":   it.UnitPrice.Major(),
+			"lineTotal":   it.LineTotal.Major(),
+			"inStock":     it.InStock,
+			// when the held stock is released unless the line changes again
+			"reservedUntil": it.ReservedUntil,
+			"addedAt":       it.AddedAt,
+		})
+	}
+
+	return gin.H{
+		"items":      items,
+		"totalPrice": ct.TotalPrice.Major(),
+		"currency":   ct.TotalPrice.Currency,
+		"updatedAt":  ct.UpdatedAt,
+	}
+}
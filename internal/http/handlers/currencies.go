@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/gin-gonic/gin"
+)
+
+type CurrenciesHandler struct {
+	svc currencies.Service
+}
+
+func NewCurrenciesHandler(svc currencies.Service) *CurrenciesHandler {
+	return &CurrenciesHandler{svc: svc}
+}
+
+type SetExchangeRateRequest struct {
+	// Price of one unit of the currency in the base currency, e.g. 480 for USD against KZT.
+	Rate float64 `json:"rate" binding:"required" example:"480"`
+}
+
+// ListCurrencies godoc
+// @Summary List the base currency and exchange rates
+// @Description Any listed currency can be passed as ?currency= or X-Currency to product and order endpoints.
+// @Tags Currencies
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /currencies [get]
+func (h *CurrenciesHandler) List(c *gin.Context) {
+	rates, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(rates))
+	for _, r := range rates {
+		out = append(out, gin.H{
+			"currency":  r.Currency,
+			"rate":      r.Rate,
+			"updatedAt": r.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base":  h.svc.Base(),
+		"rates": out,
+	})
+}
+
+// SetExchangeRate godoc
+// @Summary Create or replace an exchange rate (admin only)
+// @Description Orders keep the rate they were placed at.
+// @Tags Admin Currencies
+// @Accept json
+// @Produce json
+// @Param code path string true "ISO 4217 currency code"
+// @Param body body SetExchangeRateRequest true "Rate"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /admin/currencies/{code} [put]
+func (h *CurrenciesHandler) Set(c *gin.Context) {
+	var req SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	uid, _ := userIDFromCtx(c)
+
+	r, err := h.svc.Set(c.Request.Context(), currencies.SetInput{
+		Currency:  money.Currency(c.Param("code")),
+		Rate:      req.Rate,
+		UpdatedBy: uid,
+	})
+	if err != nil {
+		writeCurrencyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"currency":  r.Currency,
+		"base":      h.svc.Base(),
+		"rate":      r.Rate,
+		"updatedBy": r.UpdatedBy,
+		"updatedAt": r.UpdatedAt,
+	})
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete an exchange rate (admin only)
+// @Description The currency can no longer be requested for display or checkout.
+// @Tags Admin Currencies
+// @Produce json
+// @Param code path string true "ISO 4217 currency code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/currencies/{code} [delete]
+func (h *CurrenciesHandler) Delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), money.Currency(c.Param("code"))); err != nil {
+		writeCurrencyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/delivery"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryHandler struct {
+	svc  delivery.Service
+	base money.Currency
+}
+
+// NewDeliveryHandler builds the handler; fees in request bodies are in base.
+func NewDeliveryHandler(svc delivery.Service, base money.Currency) *DeliveryHandler {
+	return &DeliveryHandler{svc: svc, base: base}
+}
+
+type CreateDeliveryMethodRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Description   string  `json:"description"`
+	Fee           float64 `json:"fee"`
+	EstimatedDays int64   `json:"estimatedDays"`
+	Active        bool    `json:"active"`
+}
+
+type UpdateDeliveryMethodRequest struct {
+	Name          *string  `json:"name"`
+	Description   *string  `json:"description"`
+	Fee           *float64 `json:"fee"`
+	EstimatedDays *int64   `json:"estimatedDays"`
+	Active        *bool    `json:"active"`
+}
+
+// ListDeliveryMethods godoc
+// @Summary List active delivery methods, cheapest first
+// @Tags Delivery
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Router /delivery-methods [get]
+func (h *DeliveryHandler) List(c *gin.Context) {
+	h.list(c, delivery.ListFilter{ActiveOnly: true})
+}
+
+// AdminListDeliveryMethods godoc
+// @Summary List all delivery methods including inactive (admin only)
+// @Tags Admin Delivery
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/delivery-methods [get]
+func (h *DeliveryHandler) AdminList(c *gin.Context) {
+	h.list(c, delivery.ListFilter{})
+}
+
+func (h *DeliveryHandler) list(c *gin.Context, f delivery.ListFilter) {
+	items, err := h.svc.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, deliveryMethodToJSON(it))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateDeliveryMethod godoc
+// @Summary Create delivery method (admin only)
+// @Tags Admin Delivery
+// @Accept json
+// @Produce json
+// @Param body body CreateDeliveryMethodRequest true "Delivery method"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /admin/delivery-methods [post]
+func (h *DeliveryHandler) Create(c *gin.Context) {
+	var req CreateDeliveryMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	it, err := h.svc.Create(c.Request.Context(), delivery.CreateInput{
+		Name:          req.Name,
+		Description:   req.Description,
+		Fee:           money.FromMajor(req.Fee, h.base),
+		EstimatedDays: req.EstimatedDays,
+		Active:        req.Active,
+	})
+	if err != nil {
+		writeDeliveryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, deliveryMethodToJSON(it))
+}
+
+// UpdateDeliveryMethod godoc
+// @Summary Update delivery method (admin only)
+// @Tags Admin Delivery
+// @Accept json
+// @Produce json
+// @Param id path string true "Delivery method ID"
+// @Param body body UpdateDeliveryMethodRequest true "Patch"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/delivery-methods/{id} [put]
+func (h *DeliveryHandler) Update(c *gin.Context) {
+	var req UpdateDeliveryMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	it, err := h.svc.Update(c.Request.Context(), c.Param("id"), delivery.UpdateInput{
+		Name:          req.Name,
+		Description:   req.Description,
+		Fee:           moneyPtr(req.Fee, h.base),
+		EstimatedDays: req.EstimatedDays,
+		Active:        req.Active,
+	})
+	if err != nil {
+		writeDeliveryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveryMethodToJSON(it))
+}
+
+// DeleteDeliveryMethod godoc
+// @Summary Delete delivery method (admin only)
+// @Description Existing orders keep their own copy of the method name and fee.
+// @Tags Admin Delivery
+// @Produce json
+// @Param id path string true "Delivery method ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/delivery-methods/{id} [delete]
+func (h *DeliveryHandler) Delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		writeDeliveryError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func writeDeliveryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, delivery.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, delivery.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, delivery.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+	case errors.Is(err, delivery.ErrInvalidFee):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee"})
+	case errors.Is(err, delivery.ErrInvalidDays):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid estimatedDays"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func deliveryMethodToJSON(m delivery.Method) gin.H {
+	return gin.H{
+		"id":            m.ID,
+		"name":          m.Name,
+		"description":   m.Description,
+		"fee":           m.Fee.Major(),
+		"currency":      m.Fee.Currency,
+		"estimatedDays": m.EstimatedDays,
+		"active":        m.Active,
+		"createdAt":     m.CreatedAt,
+		"updatedAt":     m.UpdatedAt,
+	}
+}
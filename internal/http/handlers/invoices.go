@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/invoices/render"
+	"github.com/gin-gonic/gin"
+)
+
+type InvoicesHandler struct {
+	svc invoices.Service
+}
+
+func NewInvoicesHandler(svc invoices.Service) *InvoicesHandler {
+	return &InvoicesHandler{svc: svc}
+}
+
+// GetInvoice godoc
+// @Summary Get an order's invoice (user: own, admin: any)
+// @Description The invoice number is assigned on first request and never changes. Cancelled orders that were never invoiced cannot be invoiced.
+// @Tags Orders
+// @Produce html
+// @Produce application/pdf
+// @Param id path string true "Order ID"
+// @Param format query string false "html (default) or pdf"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/invoice [get]
+func (h *InvoicesHandler) Get(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html or pdf"})
+		return
+	}
+
+	doc, err := h.svc.Issue(c.Request.Context(), c.Param("id"), uid, isAdminFromCtx(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, orders.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		case errors.Is(err, orders.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		case errors.Is(err, orders.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		case errors.Is(err, invoices.ErrNotInvoiceable):
+			c.JSON(http.StatusConflict, gin.H{"error": "cancelled orders cannot be invoiced"})
+		default:
+			log.Println("invoice error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	// render fully before writing so a failure can still become a 500
+	var buf bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = render.PDF(&buf, doc)
+	} else {
+		err = render.HTML(&buf, doc)
+	}
+	if err != nil {
+		log.Println("invoice render error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+doc.Invoice.Code()+"."+format+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
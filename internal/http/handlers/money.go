@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// currencyHeader picks the display currency when there is no ?currency=.
+const currencyHeader = middleware.HeaderCurrency
+
+// moneyPtr converts an optional amount from a request body, given in major
+// units of cur.
+func moneyPtr(v *float64, cur money.Currency) *money.Money {
+	if v == nil {
+		return nil
+	}
+	m := money.FromMajor(*v, cur)
+	return &m
+}
+
+// requestCurrency returns the display currency asked for with ?currency= or
+// the X-Currency header, the query param winning. Empty means the base
+// currency.
+func requestCurrency(c *gin.Context) (money.Currency, error) {
+	v := c.Query("currency")
+	if v == "" {
+		v = c.GetHeader(currencyHeader)
+	}
+	if v == "" {
+		return "", nil
+	}
+	return money.ParseCurrency(v)
+}
+
+// displayQuote resolves the request's display currency. On failure it writes
+// the error response and returns false.
+func displayQuote(c *gin.Context, rates currencies.Service) (currencies.Quote, bool) {
+	c.Header("Vary", currencyHeader)
+
+	cur, err := requestCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return currencies.Quote{}, false
+	}
+	q, err := rates.Quote(c.Request.Context(), cur)
+	if err != nil {
+		writeCurrencyError(c, err)
+		return currencies.Quote{}, false
+	}
+	return q, true
+}
+
+func writeCurrencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, currencies.ErrInvalidCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+	case errors.Is(err, currencies.ErrUnsupported):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported currency"})
+	case errors.Is(err, currencies.ErrInvalidRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rate"})
+	case errors.Is(err, currencies.ErrBaseCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the base currency has a fixed rate of 1"})
+	case errors.Is(err, currencies.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
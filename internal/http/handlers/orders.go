@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
 	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
 	"github.com/gin-gonic/gin"
 )
@@ -19,15 +26,46 @@ func NewOrdersHandler(svc orders.Service) *OrdersHandler {
 	return &OrdersHandler{svc: svc}
 }
 
+type ShippingAddressRequest struct {
+	FullName   string `json:"fullName"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
 type CreateOrderRequest struct {
 	Items []struct {
 		ProductID string `json:"productId" binding:"required"`
 		Quantity  int64  `json:"quantity" binding:"required"`
 	} `json:"items" binding:"required"`
+	PromoCode string `json:"promoCode"`
+	// Defaults to the profile name/phone/address when omitted.
+	ShippingAddress *ShippingAddressRequest `json:"shippingAddress"`
+	// Defaults to the cheapest active delivery method when omitted.
+	DeliveryMethodID string `json:"deliveryMethodId"`
 }
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+type CreateShipmentRequest struct {
+	Carrier        string `json:"carrier" binding:"required"`
+	TrackingNumber string `json:"trackingNumber" binding:"required"`
+	// Ships everything not yet shipped when omitted.
+	Items []struct {
+		ProductID string `json:"productId" binding:"required"`
+		Quantity  int64  `json:"quantity" binding:"required"`
+	} `json:"items"`
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
 }
 
 type FindOrderByIDRequest struct {
@@ -51,10 +89,20 @@ func userIDFromCtx(c *gin.Context) (string, bool) {
 
 // ListOrders godoc
 // @Summary List orders (user: own, admin: all)
+// @Description Filters combine with AND. userId and email are admin-only and ignored for customers.
 // @Tags Orders
 // @Produce json
 // @Param offset query int true "Offset for pagination"
 // @Param limit query int true "Limit for pagination"
+// @Param status query string false "Order status" Enums(pending, paid, partially_shipped, shipped, delivered, cancelled)
+// @Param userId query string false "Customer user ID (admin only)"
+// @Param email query string false "Customer email (admin only)"
+// @Param productId query string false "Orders containing this product"
+// @Param from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param minTotal query number false "Minimum total price"
+// @Param maxTotal query number false "Maximum total price"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(createdAt, -createdAt, totalPrice, -totalPrice) default(-createdAt)
 // @Success 200 {array} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -86,15 +134,27 @@ func (h *OrdersHandler) List(c *gin.Context) {
 		return
 	}
 
+	f, msg := parseOrderListFilter(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	f.Offset = offset
+	f.Limit = limit
+
 	admin := isAdminFromCtx(c)
 
-	items, total, err := h.svc.List(c.Request.Context(), uid, admin, orders.ListFilter{
-		Offset: offset,
-		Limit:  limit,
-	})
+	items, total, err := h.svc.List(c.Request.Context(), uid, admin, f)
 	if err != nil {
-		log.Println("List orders error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		switch {
+		case errors.Is(err, orders.ErrInvalidProduct):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productId"})
+		case errors.Is(err, orders.ErrInvalidFilter):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid filter"})
+		default:
+			log.Println("List orders error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
 		return
 	}
 
@@ -111,6 +171,75 @@ func (h *OrdersHandler) List(c *gin.Context) {
 	})
 }
 
+// ExportOrders godoc
+// @Summary Export orders for accounting (admin only)
+// @Description Streams one row per order line, oldest first unless sort is given. Takes the same filters as the order list, without paging. csv has a header row; jsonl has one JSON object per line.
+// @Tags Admin Orders
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Output format" Enums(csv, jsonl) default(csv)
+// @Param status query string false "Order status" Enums(pending, paid, partially_shipped, shipped, delivered, cancelled)
+// @Param userId query string false "Customer user ID"
+// @Param email query string false "Customer email"
+// @Param productId query string false "Orders containing this product"
+// @Param from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param minTotal query number false "Minimum total price"
+// @Param maxTotal query number false "Maximum total price"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(createdAt, -createdAt, totalPrice, -totalPrice) default(createdAt)
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/orders/export [get]
+func (h *OrdersHandler) Export(c *gin.Context) {
+	if !isAdminFromCtx(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
+	}
+
+	f, msg := parseOrderListFilter(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	// accounting reads exports chronologically
+	if c.Query("sort") == "" {
+		f.SortAsc = true
+	}
+
+	w := newExportWriter(c, format)
+	err := h.svc.Export(c.Request.Context(), f, w.write)
+	if err == nil {
+		err = w.close()
+	}
+	if err == nil {
+		return
+	}
+
+	// once rows are out the status is sent; all we can do is cut the stream
+	if w.started {
+		log.Println("export orders error:", err)
+		c.Abort()
+		return
+	}
+	switch {
+	case errors.Is(err, orders.ErrInvalidProduct):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productId"})
+	case errors.Is(err, orders.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid filter"})
+	default:
+		log.Println("export orders error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
 // GetOrder godoc
 // @Summary Get order by ID (user: own, admin: any)
 // @Tags Orders
@@ -148,13 +277,18 @@ func (h *OrdersHandler) Get(c *gin.Context) {
 
 // CreateOrder godoc
 // @Summary Create order (auth required)
+// @Description Amounts are computed in the base currency. With a display currency the order also records the converted subtotal and total, and the customer is charged the converted total.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param body body CreateOrderRequest true "Order"
+// @Param currency query string false "Display currency, e.g. USD; defaults to the base currency"
+// @Param X-Currency header string false "Display currency when the currency query param is absent"
+// @Param Idempotency-Key header string false "Retry-safe key; a retry with the same key, query, X-Currency and body replays the first response"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /orders [post]
 func (h *OrdersHandler) Create(c *gin.Context) {
 	uid, ok := userIDFromCtx(c)
@@ -168,8 +302,19 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
+	cur, err := requestCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return
+	}
 
-	in := orders.CreateInput{Items: make([]orders.Item, 0, len(req.Items))}
+	in := orders.CreateInput{
+		Items:            make([]orders.Item, 0, len(req.Items)),
+		PromoCode:        req.PromoCode,
+		ShippingAddress:  req.ShippingAddress.toDomain(),
+		DeliveryMethodID: req.DeliveryMethodID,
+		Currency:         cur,
+	}
 	for _, it := range req.Items {
 		in.Items = append(in.Items, orders.Item{
 			ProductID: it.ProductID,
@@ -178,27 +323,70 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 	}
 
 	created, err := h.svc.Create(c.Request.Context(), uid, in)
+	if err != nil {
+		writeCreateOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, orderToJSON(created, false))
+}
+
+// CancelOrder godoc
+// @Summary Cancel own order (auth required)
+// @Description Only pending orders can be cancelled, within the configured window after creation. Items are returned to stock.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body CancelOrderRequest false "Optional reason"
+// @Param Idempotency-Key header string false "Retry-safe key; a retry with the same key, query, X-Currency and body replays the first response"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/cancel [post]
+func (h *OrdersHandler) Cancel(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CancelOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	id := c.Param("id")
+	updated, err := h.svc.Cancel(c.Request.Context(), id, uid, orders.CancelInput{Reason: req.Reason})
 	if err != nil {
 		switch {
-		case errors.Is(err, orders.ErrInvalidItems):
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid items"})
-		case errors.Is(err, orders.ErrInvalidProduct):
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productId"})
-		case errors.Is(err, orders.ErrInvalidQty):
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
-		case errors.Is(err, orders.ErrInsufficientStock):
-			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient stock"})
+		case errors.Is(err, orders.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		case errors.Is(err, orders.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		case errors.Is(err, orders.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": "only pending orders can be cancelled"})
+		case errors.Is(err, orders.ErrCancelWindow):
+			c.JSON(http.StatusConflict, gin.H{"error": "cancellation window has passed"})
+		case errors.Is(err, orders.ErrAlreadyPaid):
+			c.JSON(http.StatusConflict, gin.H{"error": "paid orders can only be cancelled by support"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, orderToJSON(created, false))
+	c.JSON(http.StatusOK, orderToJSON(updated, false))
 }
 
 // UpdateOrderStatus godoc
 // @Summary Update order status (admin only)
+// @Description Allowed transitions: pending→cancelled, paid→cancelled. Orders become paid only through the payments webhook; partially_shipped, shipped and delivered follow from shipments (POST /admin/orders/{id}/shipments and .../shipments/{shipmentId}/deliver). Cancelling returns the items to stock. A paid order is refused with 409 until its payment is refunded (POST /admin/orders/{id}/refund).
 // @Tags Admin Orders
 // @Accept json
 // @Produce json
@@ -209,6 +397,7 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /admin/orders/{id}/status [put]
 func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 	// admin guard лучше делать в routes (AdminOnly), но на всякий:
@@ -217,6 +406,7 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
+	uid, _ := userIDFromCtx(c)
 	id := c.Param("id")
 
 	var req UpdateOrderStatusRequest
@@ -225,13 +415,20 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.svc.UpdateStatus(c.Request.Context(), id, orders.Status(req.Status))
+	updated, err := h.svc.UpdateStatus(c.Request.Context(), id, uid, orders.UpdateStatusInput{
+		Status: orders.Status(req.Status),
+		Note:   req.Note,
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, orders.ErrInvalidID):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		case errors.Is(err, orders.ErrInvalidStatus):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		case errors.Is(err, orders.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": "invalid status transition"})
+		case errors.Is(err, orders.ErrAlreadyPaid):
+			c.JSON(http.StatusConflict, gin.H{"error": "refund the payment before cancelling"})
 		case errors.Is(err, orders.ErrNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		default:
@@ -243,6 +440,111 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, orderToJSON(updated, true))
 }
 
+// AddShipment godoc
+// @Summary Ship order items (admin only)
+// @Description Records a shipment with carrier and tracking number. Items default to everything not yet shipped. The order becomes partially_shipped while items remain and shipped once everything has gone out.
+// @Tags Admin Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body CreateShipmentRequest true "Shipment"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/orders/{id}/shipments [post]
+func (h *OrdersHandler) AddShipment(c *gin.Context) {
+	if !isAdminFromCtx(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	uid, _ := userIDFromCtx(c)
+	id := c.Param("id")
+
+	var req CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	in := orders.ShipmentInput{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Items:          make([]orders.ShipmentItem, 0, len(req.Items)),
+	}
+	for _, it := range req.Items {
+		in.Items = append(in.Items, orders.ShipmentItem{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
+
+	updated, err := h.svc.AddShipment(c.Request.Context(), id, uid, in)
+	if err != nil {
+		writeShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, orderToJSON(updated, true))
+}
+
+// DeliverShipment godoc
+// @Summary Mark a shipment delivered (admin only)
+// @Description Once every shipment of a shipped order is delivered, the order becomes delivered. Marking a delivered shipment again is a no-op.
+// @Tags Admin Orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param shipmentId path string true "Shipment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/orders/{id}/shipments/{shipmentId}/deliver [post]
+func (h *OrdersHandler) DeliverShipment(c *gin.Context) {
+	if !isAdminFromCtx(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	uid, _ := userIDFromCtx(c)
+
+	updated, err := h.svc.DeliverShipment(c.Request.Context(), c.Param("id"), c.Param("shipmentId"), uid)
+	if err != nil {
+		writeShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orderToJSON(updated, true))
+}
+
+func writeShipmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, orders.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, orders.ErrInvalidShipment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "carrier and trackingNumber are required"})
+	case errors.Is(err, orders.ErrInvalidProduct):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product is not part of the order"})
+	case errors.Is(err, orders.ErrInvalidQty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be > 0"})
+	case errors.Is(err, orders.ErrOverShipment):
+		c.JSON(http.StatusConflict, gin.H{"error": "quantity exceeds what is left to ship"})
+	case errors.Is(err, orders.ErrNotShippable):
+		c.JSON(http.StatusConflict, gin.H{"error": "order cannot be shipped in its current status"})
+	case errors.Is(err, orders.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": "order changed concurrently, retry"})
+	case errors.Is(err, orders.ErrShipmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "shipment not found"})
+	case errors.Is(err, orders.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Printf("shipment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
 // FindOrderByID godoc
 // @Summary Find order by ID (admin only)
 // @Tags Admin Orders
@@ -287,23 +589,400 @@ func orderToJSON(o orders.Order, admin bool) gin.H {
 	items := make([]gin.H, 0, len(o.Items))
 	for _, it := range o.Items {
 		items = append(items, gin.H{
-			"productId": it.ProductID,
-			"quantity":  it.Quantity,
-			"unitPrice": it.UnitPrice,
-			"lineTotal": it.LineTotal,
+			"productId":   it.ProductID,
+			"productName": it.ProductName,
+			"quantity":    it.Quantity,
+			"unitPrice":   it.UnitPrice.Major(),
+			"lineTotal":   it.LineTotal.Major(),
 		})
 	}
 
+	discounts := make([]gin.H, 0, len(o.Discounts))
+	for _, dc := range o.Discounts {
+		discounts = append(discounts, gin.H{
+			"code":   dc.Code,
+			"amount": dc.Amount.Major(),
+		})
+	}
+
+	var shipping gin.H
+	if a := o.ShippingAddress; a != nil {
+		shipping = gin.H{
+			"fullName":   a.FullName,
+			"phone":      a.Phone,
+			"line1":      a.Line1,
+			"line2":      a.Line2,
+			"city":       a.City,
+			"region":     a.Region,
+			"postalCode": a.PostalCode,
+			"country":    a.Country,
+		}
+	}
+
+	var dlv gin.H
+	if m := o.Delivery; m != nil {
+		dlv = gin.H{
+			"methodId": m.MethodID,
+			"name":     m.Name,
+			"fee":      m.Fee.Major(),
+		}
+	}
+
+	taxLines := make([]gin.H, 0, len(o.Taxes))
+	for _, t := range o.Taxes {
+		taxLines = append(taxLines, gin.H{
+			"name":      t.Name,
+			"rate":      t.Rate,
+			"inclusive": t.Inclusive,
+			"base":      t.Base.Major(),
+			"amount":    t.Amount.Major(),
+		})
+	}
+
+	var display gin.H
+	if dp := o.Display; dp != nil {
+		display = gin.H{
+			"currency":   dp.Currency,
+			"rate":       dp.Rate,
+			"subtotal":   dp.Subtotal.Major(),
+			"totalPrice": dp.TotalPrice.Major(),
+		}
+	}
+
+	refunds := make([]gin.H, 0, len(o.Refunds))
+	for _, rf := range o.Refunds {
+		refunds = append(refunds, gin.H{
+			"returnId":  rf.ReturnID,
+			"amount":    rf.Amount.Major(),
+			"createdAt": rf.CreatedAt,
+		})
+	}
+
+	shipments := make([]gin.H, 0, len(o.Shipments))
+	for _, sh := range o.Shipments {
+		shItems := make([]gin.H, 0, len(sh.Items))
+		for _, it := range sh.Items {
+			shItems = append(shItems, gin.H{
+				"productId": it.ProductID,
+				"quantity":  it.Quantity,
+			})
+		}
+		entry := gin.H{
+			"id":             sh.ID,
+			"carrier":        sh.Carrier,
+			"trackingNumber": sh.TrackingNumber,
+			"items":          shItems,
+			"shippedAt":      sh.ShippedAt,
+			"deliveredAt":    sh.DeliveredAt,
+		}
+		if admin {
+			entry["createdBy"] = sh.CreatedBy
+		}
+		shipments = append(shipments, entry)
+	}
+
+	history := make([]gin.H, 0, len(o.StatusHistory))
+	for _, ch := range o.StatusHistory {
+		entry := gin.H{
+			"from":      ch.From,
+			"to":        ch.To,
+			"note":      ch.Note,
+			"changedAt": ch.ChangedAt,
+		}
+		if admin {
+			entry["changedBy"] = ch.ChangedBy
+		}
+		history = append(history, entry)
+	}
+
 	out := gin.H{
-		"id":         o.ID,
-		"items":      items,
-		"status":     o.Status,
-		"totalPrice": o.TotalPrice,
-		"createdAt":  o.CreatedAt,
-		"updatedAt":  o.UpdatedAt,
+		"id":              o.ID,
+		"items":           items,
+		"status":          o.Status,
+		"paymentStatus":   o.PaymentStatus,
+		"shippingAddress": shipping,
+		"delivery":        dlv,
+		"subtotal":        o.Subtotal.Major(),
+		"discounts":       discounts,
+		"taxes":           taxLines,
+		"taxTotal":        o.TaxTotal().Major(),
+		"totalPrice":      o.TotalPrice.Major(),
+		"currency":        o.TotalPrice.Currency,
+		"display":         display,
+		"shipments":       shipments,
+		"refunds":         refunds,
+		"statusHistory":   history,
+		"createdAt":       o.CreatedAt,
+		"updatedAt":       o.UpdatedAt,
 	}
 	if admin {
 		out["userId"] = o.UserID
 	}
 	return out
 }
+
+var exportColumns = []string{
+	"orderId", "createdAt", "updatedAt", "status", "paymentStatus", "userId", "email",
+	"productId", "productName", "quantity", "unitPrice", "lineTotal", "orderTotal", "currency",
+}
+
+// exportLineJSON keeps JSON Lines keys in the same order as the CSV columns.
+type exportLineJSON struct {
+	OrderID       string               `json:"orderId"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	Status        orders.Status        `json:"status"`
+	PaymentStatus orders.PaymentStatus `json:"paymentStatus"`
+	UserID        string               `json:"userId"`
+	Email         string               `json:"email"`
+	ProductID     string               `json:"productId"`
+	ProductName   string               `json:"productName"`
+	Quantity      int64                `json:"quantity"`
+	UnitPrice     float64              `json:"unitPrice"`
+	LineTotal     float64              `json:"lineTotal"`
+	OrderTotal    float64              `json:"orderTotal"`
+	Currency      money.Currency       `json:"currency"`
+}
+
+// exportFlushEvery is how many rows are buffered before pushing them to the client.
+const exportFlushEvery = 200
+
+// exportWriter streams export lines as CSV or JSON Lines. Headers go out with
+// the first row, so errors before any row can still be reported as JSON.
+type exportWriter struct {
+	c       *gin.Context
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	rows    int
+	started bool
+}
+
+func newExportWriter(c *gin.Context, format string) *exportWriter {
+	return &exportWriter{c: c, format: format}
+}
+
+func (w *exportWriter) start() error {
+	w.started = true
+
+	name := "orders-" + time.Now().UTC().Format("20060102-150405") + "." + w.format
+	w.c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	if w.format == "jsonl" {
+		w.c.Header("Content-Type", "application/x-ndjson")
+		w.c.Status(http.StatusOK)
+		w.json = json.NewEncoder(w.c.Writer)
+		return nil
+	}
+	w.c.Header("Content-Type", "text/csv; charset=utf-8")
+	w.c.Status(http.StatusOK)
+	w.csv = csv.NewWriter(w.c.Writer)
+	return w.csv.Write(exportColumns)
+}
+
+func (w *exportWriter) write(l orders.ExportLine) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if w.json != nil {
+		err = w.json.Encode(exportLineJSON{
+			OrderID:       l.OrderID,
+			CreatedAt:     l.CreatedAt,
+			UpdatedAt:     l.UpdatedAt,
+			Status:        l.Status,
+			PaymentStatus: l.PaymentStatus,
+			UserID:        l.UserID,
+			Email:         l.Email,
+			ProductID:     l.ProductID,
+			ProductName:   l.ProductName,
+			Quantity:      l.Quantity,
+			UnitPrice:     l.UnitPrice.Major(),
+			LineTotal:     l.LineTotal.Major(),
+			OrderTotal:    l.OrderTotal.Major(),
+			Currency:      l.OrderTotal.Currency,
+		})
+	} else {
+		err = w.csv.Write([]string{
+			l.OrderID,
+			l.CreatedAt.UTC().Format(time.RFC3339),
+			l.UpdatedAt.UTC().Format(time.RFC3339),
+			string(l.Status),
+			string(l.PaymentStatus),
+			l.UserID,
+			csvSafe(l.Email),
+			l.ProductID,
+			csvSafe(l.ProductName),
+			strconv.FormatInt(l.Quantity, 10),
+			l.UnitPrice.Decimal(),
+			l.LineTotal.Decimal(),
+			l.OrderTotal.Decimal(),
+			string(l.OrderTotal.Currency),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%exportFlushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// close finishes the export; an export with no rows is still a valid file.
+func (w *exportWriter) close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// csvSafe stops spreadsheet apps from evaluating user-entered text as a formula.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// parseOrderListFilter reads the optional list filters from the query string.
+// It returns a client-facing message for the first invalid parameter.
+func parseOrderListFilter(c *gin.Context) (orders.ListFilter, string) {
+	var f orders.ListFilter
+
+	if v := c.Query("status"); v != "" {
+		st := orders.Status(v)
+		if !st.Valid() {
+			return f, "invalid status"
+		}
+		f.Status = &st
+	}
+	if v := c.Query("userId"); v != "" {
+		f.UserID = &v
+	}
+	f.Email = c.Query("email")
+	if v := c.Query("productId"); v != "" {
+		f.ProductID = &v
+	}
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, "invalid from, use YYYY-MM-DD"
+		}
+		f.CreatedFrom = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, "invalid to, use YYYY-MM-DD"
+		}
+		// include the whole "to" day
+		t = t.AddDate(0, 0, 1)
+		f.CreatedTo = &t
+	}
+
+	// bounds apply to the stored base-currency totals
+	if v := c.Query("minTotal"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return f, "invalid minTotal"
+		}
+		f.MinTotal = moneyPtr(&n, "")
+	}
+	if v := c.Query("maxTotal"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return f, "invalid maxTotal"
+		}
+		f.MaxTotal = moneyPtr(&n, "")
+	}
+
+	if v := c.Query("sort"); v != "" {
+		f.SortAsc = !strings.HasPrefix(v, "-")
+		f.SortBy = orders.SortField(strings.TrimPrefix(v, "-"))
+		if !f.SortBy.Valid() {
+			return f, "invalid sort"
+		}
+	}
+
+	return f, ""
+}
+
+func (r *ShippingAddressRequest) toDomain() *orders.ShippingAddress {
+	if r == nil {
+		return nil
+	}
+	return &orders.ShippingAddress{
+		FullName:   r.FullName,
+		Phone:      r.Phone,
+		Line1:      r.Line1,
+		Line2:      r.Line2,
+		City:       r.City,
+		Region:     r.Region,
+		PostalCode: r.PostalCode,
+		Country:    r.Country,
+	}
+}
+
+// writeCreateOrderError maps errors from orders.Service.Create, shared by
+// POST /orders and cart checkout.
+func writeCreateOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, orders.ErrInvalidItems):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid items"})
+	case errors.Is(err, orders.ErrInvalidProduct):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productId"})
+	case errors.Is(err, orders.ErrInvalidQty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+	case errors.Is(err, orders.ErrInsufficientStock):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient stock"})
+	case errors.Is(err, orders.ErrInvalidAddress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "shipping address is required (fullName and line1), or set an address in your profile"})
+	case errors.Is(err, orders.ErrInvalidDelivery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deliveryMethodId"})
+	case isPromoError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, currencies.ErrInvalidCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+	case errors.Is(err, currencies.ErrUnsupported):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported currency"})
+	case errors.Is(err, orders.ErrCurrencyMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": "a price or fee is not in the store currency"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// isPromoError reports whether err is a promo code rejection whose message is safe to show.
+func isPromoError(err error) bool {
+	for _, target := range []error{
+		promotions.ErrInvalidCode,
+		promotions.ErrNotActive,
+		promotions.ErrMinOrderTotal,
+		promotions.ErrNotApplicable,
+		promotions.ErrUsageLimit,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
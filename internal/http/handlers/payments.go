@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentsHandler struct {
+	svc payments.Service
+}
+
+func NewPaymentsHandler(svc payments.Service) *PaymentsHandler {
+	return &PaymentsHandler{svc: svc}
+}
+
+// PayOrder godoc
+// @Summary Start payment of own pending order (auth required)
+// @Description Returns a payment intent with a clientSecret to confirm with the provider. Calling again while the intent is pending returns the same intent.
+// @Tags Payments
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/pay [post]
+func (h *PaymentsHandler) Pay(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	p, err := h.svc.Pay(c.Request.Context(), c.Param("id"), uid)
+	if err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	out := paymentToJSON(p)
+	out["clientSecret"] = p.ClientSecret
+	c.JSON(http.StatusCreated, out)
+}
+
+// PaymentWebhook godoc
+// @Summary Payment provider webhook
+// @Description Called by the payment provider. The body must be signed; see the provider's signature header.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/webhook [post]
+func (h *PaymentsHandler) Webhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	err = h.svc.HandleWebhook(c.Request.Context(), payload, c.GetHeader(h.svc.SignatureHeader()))
+	if err != nil {
+		switch {
+		case errors.Is(err, payments.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		case errors.Is(err, payments.ErrInvalidEvent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event"})
+		case errors.Is(err, payments.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		default:
+			log.Println("payment webhook error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// RefundOrder godoc
+// @Summary Refund what is left of an order's payment (admin only)
+// @Description Refunds what return refunds have left of the payment. Refunding records the refund on the order but does not change its status; cancel the order afterwards to restock it.
+// @Tags Admin Payments
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/orders/{id}/refund [post]
+func (h *PaymentsHandler) Refund(c *gin.Context) {
+	p, err := h.svc.Refund(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentToJSON(p))
+}
+
+func writePaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, orders.ErrInvalidID), errors.Is(err, payments.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, orders.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, orders.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case errors.Is(err, payments.ErrNotPayable):
+		c.JSON(http.StatusConflict, gin.H{"error": "order cannot be paid"})
+	case errors.Is(err, payments.ErrNotRefundable):
+		c.JSON(http.StatusConflict, gin.H{"error": "order has no payment to refund"})
+	case errors.Is(err, payments.ErrRefundExceeded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Println("payment error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func paymentToJSON(p payments.Payment) gin.H {
+	return gin.H{
+		"id":          p.ID,
+		"orderId":     p.OrderID,
+		"provider":    p.Provider,
+		"providerRef": p.ProviderRef,
+		"amount":      p.Amount.Major(),
+		"currency":    p.Amount.Currency,
+		"status":      p.Status,
+		"createdAt":   p.CreatedAt,
+		"updatedAt":   p.UpdatedAt,
+	}
+}
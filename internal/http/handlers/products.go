@@ -4,18 +4,24 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
 	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 type ProductsHandler struct {
-	svc products.Service
+	svc   products.Service
+	rates currencies.Service
 }
 
-func NewProductsHandler(svc products.Service) *ProductsHandler {
-	return &ProductsHandler{svc: svc}
+// NewProductsHandler builds the handler. Prices are entered in the base
+// currency and shown in the currency the request asks for.
+func NewProductsHandler(svc products.Service, rates currencies.Service) *ProductsHandler {
+	return &ProductsHandler{svc: svc, rates: rates}
 }
 
 type CreateProductRequest struct {
@@ -24,6 +30,8 @@ type CreateProductRequest struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price" binding:"required"`
 	Stock       int64   `json:"stock" binding:"required"`
+	// Stock at or below which the product is reported low; 0 turns alerts off.
+	ReorderThreshold int64 `json:"reorderThreshold" example:"5"`
 }
 
 type UpdateProductRequest struct {
@@ -32,6 +40,10 @@ type UpdateProductRequest struct {
 	Description *string  `json:"description"`
 	Price       *float64 `json:"price"`
 	Stock       *int64   `json:"stock"`
+	// Stock at or below which the product is reported low; 0 turns alerts off.
+	ReorderThreshold *int64 `json:"reorderThreshold"`
+	// Why stock was changed, kept on the stock movement.
+	StockNote string `json:"stockNote" example:"stocktake"`
 }
 
 type AddReviewRequest struct {
@@ -41,11 +53,21 @@ type AddReviewRequest struct {
 
 // ListProducts godoc
 // @Summary List products
+// @Description With q, products matching the search are listed most relevant first unless sort is given. Words match in any order and form ("keyboards" finds "keyboard"); "quoted phrases" must appear; -word excludes.
+// @Description Without q or sort, newest first. Rating sorts by average review rating, sold by units ordered.
 // @Tags Products
 // @Produce json
-// @Param categoryId query string false "Category ID (ObjectId hex)"
+// @Param q query string false "Full-text search over name and description"
+// @Param categoryId query []string false "Category IDs (ObjectId hex); repeat or comma-separate to match any" collectionFormat(multi)
+// @Param minPrice query number false "Minimum price, inclusive, in the display currency"
+// @Param maxPrice query number false "Maximum price, inclusive, in the display currency"
+// @Param inStock query bool false "Only products with available stock"
+// @Param minRating query number false "Minimum average rating, 0 to 5; products without reviews are left out"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(createdAt, -createdAt, price, -price, rating, -rating, sold, -sold)
 // @Param offset query int true "Offset for pagination"
 // @Param limit query int true "Limit for pagination"
+// @Param currency query string false "Display currency, e.g. USD; defaults to the base currency"
+// @Param X-Currency header string false "Display currency when the currency query param is absent"
 // @Success 200 {array} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Router /products [get]
@@ -70,32 +92,49 @@ func (h *ProductsHandler) List(c *gin.Context) {
 		return
 	}
 
-	var f products.ListFilter
+	quote, ok := displayQuote(c, h.rates)
+	if !ok {
+		return
+	}
+
+	f, msg := parseProductListFilter(c, quote, h.rates.Base())
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 	f.Offset = offset
 	f.Limit = limit
-	if v := c.Query("categoryId"); v != "" {
-		f.CategoryID = &v
-	}
 
 	items, total, err := h.svc.List(c.Request.Context(), f)
 	if err != nil {
-		if errors.Is(err, products.ErrInvalidCategory) {
+		switch {
+		case errors.Is(err, products.ErrInvalidCategory):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid categoryId"})
-			return
+		case errors.Is(err, products.ErrInvalidQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		case errors.Is(err, products.ErrInvalidFilter):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minPrice must not exceed maxPrice"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
 	out := make([]gin.H, 0, len(items))
 	for _, it := range items {
+		price := quote.FromBase(it.Price)
 		out = append(out, gin.H{
 			"id":          it.ID,
 			"categoryId":  it.CategoryID,
 			"name":        it.Name,
 			"description": it.Description,
-			"price":       it.Price,
+			"price":       price.Major(),
+			"currency":    price.Currency,
 			"stock":       it.Stock,
+			"reserved":    it.Reserved,
+			"available":   it.Available(),
+			"rating":      it.RatingAvg,
+			"ratingCount": it.RatingCount,
 			"createdAt":   it.CreatedAt,
 			"updatedAt":   it.UpdatedAt,
 		})
@@ -109,11 +148,211 @@ func (h *ProductsHandler) List(c *gin.Context) {
 	})
 }
 
+// ProductFacets godoc
+// @Summary Counts for the catalog's filter sidebar
+// @Description Takes the same filters as GET /products. Each breakdown applies every filter but its own, so with one category picked the others still show what picking them would list; total applies them all.
+// @Description Price buckets run from min up to but not including max, in the display currency; the last has no max. Rating buckets count reviewed products rated minRating or higher, so they overlap.
+// @Tags Products
+// @Produce json
+// @Param q query string false "Full-text search over name and description"
+// @Param categoryId query []string false "Category IDs (ObjectId hex); repeat or comma-separate to match any" collectionFormat(multi)
+// @Param minPrice query number false "Minimum price, inclusive, in the display currency"
+// @Param maxPrice query number false "Maximum price, inclusive, in the display currency"
+// @Param inStock query bool false "Only products with available stock"
+// @Param minRating query number false "Minimum average rating, 0 to 5; products without reviews are left out"
+// @Param currency query string false "Display currency, e.g. USD; defaults to the base currency"
+// @Param X-Currency header string false "Display currency when the currency query param is absent"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /products/facets [get]
+func (h *ProductsHandler) Facets(c *gin.Context) {
+	quote, ok := displayQuote(c, h.rates)
+	if !ok {
+		return
+	}
+
+	f, msg := parseProductListFilter(c, quote, h.rates.Base())
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	fc, err := h.svc.Facets(c.Request.Context(), f)
+	if err != nil {
+		switch {
+		case errors.Is(err, products.ErrInvalidCategory):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid categoryId"})
+		case errors.Is(err, products.ErrInvalidQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		case errors.Is(err, products.ErrInvalidFilter):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minPrice must not exceed maxPrice"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	categories := make([]gin.H, 0, len(fc.Categories))
+	for _, cc := range fc.Categories {
+		categories = append(categories, gin.H{"categoryId": cc.CategoryID, "count": cc.Count})
+	}
+	prices := make([]gin.H, 0, len(fc.Prices))
+	for _, b := range fc.Prices {
+		var max *float64
+		if b.Max != nil {
+			v := quote.FromBase(*b.Max).Major()
+			max = &v
+		}
+		prices = append(prices, gin.H{
+			"min":   quote.FromBase(b.Min).Major(),
+			"max":   max,
+			"count": b.Count,
+		})
+	}
+	ratings := make([]gin.H, 0, len(fc.Ratings))
+	for _, r := range fc.Ratings {
+		ratings = append(ratings, gin.H{"minRating": r.MinRating, "count": r.Count})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      fc.Total,
+		"categories": categories,
+		"prices":     prices,
+		"currency":   quote.Currency,
+		"ratings":    ratings,
+		"availability": gin.H{
+			"inStock":    fc.InStock,
+			"outOfStock": fc.OutOfStock,
+		},
+	})
+}
+
+// parseProductListFilter reads the optional list filters from the query
+// string, converting price bounds from the display currency to base. It
+// returns a client-facing message for the first invalid parameter.
+func parseProductListFilter(c *gin.Context, quote currencies.Quote, base money.Currency) (products.ListFilter, string) {
+	var f products.ListFilter
+
+	f.Query = c.Query("q")
+	for _, v := range c.QueryArray("categoryId") {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				f.CategoryIDs = append(f.CategoryIDs, id)
+			}
+		}
+	}
+
+	if v := c.Query("minPrice"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return f, "invalid minPrice"
+		}
+		m := quote.ToBase(money.FromMajor(n, quote.Currency), base)
+		f.MinPrice = &m
+	}
+	if v := c.Query("maxPrice"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return f, "invalid maxPrice"
+		}
+		m := quote.ToBase(money.FromMajor(n, quote.Currency), base)
+		f.MaxPrice = &m
+	}
+
+	if v := c.Query("inStock"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, "invalid inStock"
+		}
+		f.InStockOnly = b
+	}
+	if v := c.Query("minRating"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 || n > 5 {
+			return f, "invalid minRating"
+		}
+		f.MinRating = &n
+	}
+
+	if v := c.Query("sort"); v != "" {
+		f.SortAsc = !strings.HasPrefix(v, "-")
+		f.SortBy = products.SortField(strings.TrimPrefix(v, "-"))
+		if !f.SortBy.Valid() {
+			return f, "invalid sort"
+		}
+	}
+
+	return f, ""
+}
+
+// LowStockProducts godoc
+// @Summary Products at or below their reorder threshold (admin only)
+// @Description Lowest stock first. Products without a threshold are never listed. Stock is on hand; available subtracts cart holds.
+// @Tags Admin Products
+// @Produce json
+// @Param offset query int true "Offset for pagination"
+// @Param limit query int true "Limit for pagination"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/products/low-stock [get]
+func (h *ProductsHandler) LowStock(c *gin.Context) {
+	offsetStr := c.Query("offset")
+	limitStr := c.Query("limit")
+
+	if offsetStr == "" || limitStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit are required"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	items, total, err := h.svc.List(c.Request.Context(), products.ListFilter{LowStockOnly: true, Offset: offset, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, gin.H{
+			"id":               it.ID,
+			"categoryId":       it.CategoryID,
+			"name":             it.Name,
+			"stock":            it.Stock,
+			"reserved":         it.Reserved,
+			"available":        it.Available(),
+			"reorderThreshold": it.ReorderThreshold,
+			"updatedAt":        it.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":  out,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
 // GetProduct godoc
 // @Summary Get product by ID
 // @Tags Products
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param currency query string false "Display currency, e.g. USD; defaults to the base currency"
+// @Param X-Currency header string false "Display currency when the currency query param is absent"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -121,6 +360,11 @@ func (h *ProductsHandler) List(c *gin.Context) {
 func (h *ProductsHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
+	quote, ok := displayQuote(c, h.rates)
+	if !ok {
+		return
+	}
+
 	it, err := h.svc.Get(c.Request.Context(), id)
 	if err != nil {
 		switch {
@@ -145,13 +389,19 @@ func (h *ProductsHandler) Get(c *gin.Context) {
 		})
 	}
 
+	price := quote.FromBase(it.Price)
 	c.JSON(http.StatusOK, gin.H{
 		"id":          it.ID,
 		"categoryId":  it.CategoryID,
 		"name":        it.Name,
 		"description": it.Description,
-		"price":       it.Price,
+		"price":       price.Major(),
+		"currency":    price.Currency,
 		"stock":       it.Stock,
+		"reserved":    it.Reserved,
+		"available":   it.Available(),
+		"rating":      it.RatingAvg,
+		"ratingCount": it.RatingCount,
 		"createdAt":   it.CreatedAt,
 		"updatedAt":   it.UpdatedAt,
 		"reviews":     reviews,
@@ -174,12 +424,16 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 		return
 	}
 
+	adminID, _ := userIDFromCtx(c)
 	it, err := h.svc.Create(c.Request.Context(), products.CreateInput{
 		CategoryID:  req.CategoryID,
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       money.FromMajor(req.Price, h.rates.Base()),
 		Stock:       req.Stock,
+		CreatedBy:   adminID,
+
+		ReorderThreshold: req.ReorderThreshold,
 	})
 	if err != nil {
 		switch {
@@ -191,6 +445,8 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price"})
 		case errors.Is(err, products.ErrInvalidStock):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stock"})
+		case errors.Is(err, products.ErrInvalidThreshold):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reorderThreshold"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		}
@@ -202,15 +458,22 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 		"categoryId":  it.CategoryID,
 		"name":        it.Name,
 		"description": it.Description,
-		"price":       it.Price,
+		"price":       it.Price.Major(),
+		"currency":    it.Price.Currency,
 		"stock":       it.Stock,
+		"reserved":    it.Reserved,
+		"available":   it.Available(),
 		"createdAt":   it.CreatedAt,
 		"updatedAt":   it.UpdatedAt,
+
+		"reorderThreshold": it.ReorderThreshold,
+		"lowStock":         it.LowStock(),
 	})
 }
 
 // UpdateProduct godoc
 // @Summary Update product
+// @Description Stock can't be set below what carts currently hold (409).
 // @Tags Admin Products
 // @Accept json
 // @Produce json
@@ -219,6 +482,7 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /admin/products/{id} [put]
 func (h *ProductsHandler) Update(c *gin.Context) {
 	id := c.Param("id")
@@ -229,12 +493,17 @@ func (h *ProductsHandler) Update(c *gin.Context) {
 		return
 	}
 
+	adminID, _ := userIDFromCtx(c)
 	it, err := h.svc.Update(c.Request.Context(), id, products.UpdateInput{
 		CategoryID:  req.CategoryID,
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       moneyPtr(req.Price, h.rates.Base()),
 		Stock:       req.Stock,
+		UpdatedBy:   adminID,
+		StockNote:   req.StockNote,
+
+		ReorderThreshold: req.ReorderThreshold,
 	})
 	if err != nil {
 		switch {
@@ -248,6 +517,10 @@ func (h *ProductsHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price"})
 		case errors.Is(err, products.ErrInvalidStock):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stock"})
+		case errors.Is(err, products.ErrInvalidThreshold):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reorderThreshold"})
+		case errors.Is(err, products.ErrStockBelowReserved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		case errors.Is(err, products.ErrNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		default:
@@ -261,10 +534,16 @@ func (h *ProductsHandler) Update(c *gin.Context) {
 		"categoryId":  it.CategoryID,
 		"name":        it.Name,
 		"description": it.Description,
-		"price":       it.Price,
+		"price":       it.Price.Major(),
+		"currency":    it.Price.Currency,
 		"stock":       it.Stock,
+		"reserved":    it.Reserved,
+		"available":   it.Available(),
 		"createdAt":   it.CreatedAt,
 		"updatedAt":   it.UpdatedAt,
+
+		"reorderThreshold": it.ReorderThreshold,
+		"lowStock":         it.LowStock(),
 	})
 }
 
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionsHandler struct {
+	svc  promotions.Service
+	base money.Currency
+}
+
+// NewPromotionsHandler builds the handler; amounts in request bodies are in base.
+func NewPromotionsHandler(svc promotions.Service, base money.Currency) *PromotionsHandler {
+	return &PromotionsHandler{svc: svc, base: base}
+}
+
+type CreatePromotionRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type" binding:"required" example:"percentage"`
+	Value          float64    `json:"value" binding:"required"`
+	MinOrderTotal  float64    `json:"minOrderTotal"`
+	CategoryIDs    []string   `json:"categoryIds"`
+	ProductIDs     []string   `json:"productIds"`
+	MaxUses        int64      `json:"maxUses"`
+	MaxUsesPerUser int64      `json:"maxUsesPerUser"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         bool       `json:"active"`
+}
+
+type UpdatePromotionRequest struct {
+	Description    *string    `json:"description"`
+	Type           *string    `json:"type"`
+	Value          *float64   `json:"value"`
+	MinOrderTotal  *float64   `json:"minOrderTotal"`
+	CategoryIDs    *[]string  `json:"categoryIds"`
+	ProductIDs     *[]string  `json:"productIds"`
+	MaxUses        *int64     `json:"maxUses"`
+	MaxUsesPerUser *int64     `json:"maxUsesPerUser"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         *bool      `json:"active"`
+}
+
+// ListPromotions godoc
+// @Summary List promo codes (admin only)
+// @Tags Admin Promotions
+// @Produce json
+// @Param offset query int true "Offset for pagination"
+// @Param limit query int true "Limit for pagination"
+// @Success 200 {array} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/promotions [get]
+func (h *PromotionsHandler) List(c *gin.Context) {
+	offsetStr := c.Query("offset")
+	limitStr := c.Query("limit")
+
+	if offsetStr == "" || limitStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit are required"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	items, total, err := h.svc.List(c.Request.Context(), promotions.ListFilter{Offset: offset, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, promotionToJSON(it))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":  out,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+// GetPromotion godoc
+// @Summary Get promo code by ID (admin only)
+// @Tags Admin Promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/promotions/{id} [get]
+func (h *PromotionsHandler) Get(c *gin.Context) {
+	it, err := h.svc.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotionToJSON(it))
+}
+
+// CreatePromotion godoc
+// @Summary Create promo code (admin only)
+// @Description type is "percentage" (value 0-100] or "fixed" (value is an amount). Empty categoryIds and productIds apply the code to the whole order; 0 limits mean unlimited.
+// @Tags Admin Promotions
+// @Accept json
+// @Produce json
+// @Param body body CreatePromotionRequest true "Promotion"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/promotions [post]
+func (h *PromotionsHandler) Create(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	it, err := h.svc.Create(c.Request.Context(), promotions.CreateInput{
+		Code:           req.Code,
+		Description:    req.Description,
+		Type:           promotions.DiscountType(req.Type),
+		Value:          req.Value,
+		MinOrderTotal:  money.FromMajor(req.MinOrderTotal, h.base),
+		CategoryIDs:    req.CategoryIDs,
+		ProductIDs:     req.ProductIDs,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Active:         req.Active,
+	})
+	if err != nil {
+		writePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotionToJSON(it))
+}
+
+// UpdatePromotion godoc
+// @Summary Update promo code (admin only)
+// @Tags Admin Promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Param body body UpdatePromotionRequest true "Patch"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/promotions/{id} [put]
+func (h *PromotionsHandler) Update(c *gin.Context) {
+	var req UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	in := promotions.UpdateInput{
+		Description:    req.Description,
+		Value:          req.Value,
+		MinOrderTotal:  moneyPtr(req.MinOrderTotal, h.base),
+		CategoryIDs:    req.CategoryIDs,
+		ProductIDs:     req.ProductIDs,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Active:         req.Active,
+	}
+	if req.Type != nil {
+		t := promotions.DiscountType(*req.Type)
+		in.Type = &t
+	}
+
+	it, err := h.svc.Update(c.Request.Context(), c.Param("id"), in)
+	if err != nil {
+		writePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotionToJSON(it))
+}
+
+// DeletePromotion godoc
+// @Summary Delete promo code (admin only)
+// @Tags Admin Promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/promotions/{id} [delete]
+func (h *PromotionsHandler) Delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		writePromotionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func writePromotionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, promotions.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, promotions.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, promotions.ErrCodeTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "code already exists"})
+	case errors.Is(err, promotions.ErrInvalidCode),
+		errors.Is(err, promotions.ErrInvalidType),
+		errors.Is(err, promotions.ErrInvalidValue),
+		errors.Is(err, promotions.ErrInvalidLimit),
+		errors.Is(err, promotions.ErrInvalidWindow),
+		errors.Is(err, promotions.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func promotionToJSON(p promotions.Promotion) gin.H {
+	return gin.H{
+		"id":             p.ID,
+		"code":           p.Code,
+		"description":    p.Description,
+		"type":           p.Type,
+		"value":          p.Value,
+		"minOrderTotal":  p.MinOrderTotal.Major(),
+		"currency":       p.MinOrderTotal.Currency,
+		"categoryIds":    p.CategoryIDs,
+		"productIds":     p.ProductIDs,
+		"maxUses":        p.MaxUses,
+		"maxUsesPerUser": p.MaxUsesPerUser,
+		"usedCount":      p.UsedCount,
+		"startsAt":       p.StartsAt,
+		"endsAt":         p.EndsAt,
+		"active":         p.Active,
+		"createdAt":      p.CreatedAt,
+		"updatedAt":      p.UpdatedAt,
+	}
+}
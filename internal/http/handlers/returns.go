@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/returns"
+	"github.com/gin-gonic/gin"
+)
+
+type ReturnsHandler struct {
+	svc returns.Service
+}
+
+func NewReturnsHandler(svc returns.Service) *ReturnsHandler {
+	return &ReturnsHandler{svc: svc}
+}
+
+type CreateReturnRequest struct {
+	Items []struct {
+		ProductID string `json:"productId" binding:"required"`
+		Quantity  int64  `json:"quantity" binding:"required"`
+	} `json:"items" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ReviewReturnRequest struct {
+	Note string `json:"note"`
+}
+
+type ReceiveReturnRequest struct {
+	// Restock puts the returned quantities back into product stock.
+	Restock bool   `json:"restock"`
+	Note    string `json:"note"`
+}
+
+// CreateReturn godoc
+// @Summary Request a return for lines of own delivered order (auth required)
+// @Description Each product can be returned up to the quantity bought, across all returns that were not rejected. The refund is the line's share of what was paid after discounts; delivery is not refunded.
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body CreateReturnRequest true "Return"
+// @Param Idempotency-Key header string false "Retry-safe key; a retry with the same key, query, X-Currency and body replays the first response"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/returns [post]
+func (h *ReturnsHandler) Create(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	in := returns.CreateInput{
+		Items:  make([]returns.Item, 0, len(req.Items)),
+		Reason: req.Reason,
+	}
+	for _, it := range req.Items {
+		in.Items = append(in.Items, returns.Item{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
+
+	created, err := h.svc.Create(c.Request.Context(), c.Param("id"), uid, in)
+	if err != nil {
+		writeReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, returnToJSON(created, false))
+}
+
+// ListReturns godoc
+// @Summary List returns (user: own, admin: all)
+// @Tags Returns
+// @Produce json
+// @Param offset query int true "Offset for pagination"
+// @Param limit query int true "Limit for pagination"
+// @Param status query string false "Return status" Enums(requested, approved, rejected, received)
+// @Param orderId query string false "Order ID"
+// @Success 200 {array} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /returns [get]
+func (h *ReturnsHandler) List(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	offsetStr := c.Query("offset")
+	limitStr := c.Query("limit")
+
+	if offsetStr == "" || limitStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit are required"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	f := returns.ListFilter{Offset: offset, Limit: limit}
+	if v := c.Query("status"); v != "" {
+		st := returns.Status(v)
+		if !st.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		f.Status = &st
+	}
+	if v := c.Query("orderId"); v != "" {
+		f.OrderID = &v
+	}
+
+	admin := isAdminFromCtx(c)
+
+	items, total, err := h.svc.List(c.Request.Context(), uid, admin, f)
+	if err != nil {
+		writeReturnError(c, err)
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, returnToJSON(it, admin))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":  out,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+// GetReturn godoc
+// @Summary Get return by ID (user: own, admin: any)
+// @Tags Returns
+// @Produce json
+// @Param id path string true "Return ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /returns/{id} [get]
+func (h *ReturnsHandler) Get(c *gin.Context) {
+	uid, ok := userIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	admin := isAdminFromCtx(c)
+
+	r, err := h.svc.Get(c.Request.Context(), c.Param("id"), uid, admin)
+	if err != nil {
+		writeReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, returnToJSON(r, admin))
+}
+
+// ApproveReturn godoc
+// @Summary Approve a requested return (admin only)
+// @Tags Admin Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param body body ReviewReturnRequest false "Optional note"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/returns/{id}/approve [post]
+func (h *ReturnsHandler) Approve(c *gin.Context) {
+	h.review(c, h.svc.Approve)
+}
+
+// RejectReturn godoc
+// @Summary Reject a requested return (admin only)
+// @Tags Admin Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param body body ReviewReturnRequest false "Optional note"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/returns/{id}/reject [post]
+func (h *ReturnsHandler) Reject(c *gin.Context) {
+	h.review(c, h.svc.Reject)
+}
+
+func (h *ReturnsHandler) review(c *gin.Context, apply func(ctx context.Context, id string, actorID string, in returns.ReviewInput) (returns.Return, error)) {
+	uid, _ := userIDFromCtx(c)
+
+	var req ReviewReturnRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	r, err := apply(c.Request.Context(), c.Param("id"), uid, returns.ReviewInput{Note: req.Note})
+	if err != nil {
+		writeReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, returnToJSON(r, true))
+}
+
+// ReceiveReturn godoc
+// @Summary Mark returned goods as received and refund them (admin only)
+// @Description Refunds the return through the order's payment, capped at what is left of it after earlier refunds, and adds the refund to the order, which is subtracted from sales revenue. Orders not paid through the provider get no refund. With restock=true the returned quantities go back into stock and are taken off the units sold.
+// @Tags Admin Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param body body ReceiveReturnRequest false "Restock and note"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/returns/{id}/receive [post]
+func (h *ReturnsHandler) Receive(c *gin.Context) {
+	uid, _ := userIDFromCtx(c)
+
+	var req ReceiveReturnRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	r, err := h.svc.Receive(c.Request.Context(), c.Param("id"), uid, returns.ReceiveInput{
+		Restock: req.Restock,
+		Note:    req.Note,
+	})
+	if err != nil {
+		writeReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, returnToJSON(r, true))
+}
+
+func writeReturnError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, returns.ErrInvalidID), errors.Is(err, orders.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, returns.ErrNotFound), errors.Is(err, orders.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, returns.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case errors.Is(err, returns.ErrInvalidItems),
+		errors.Is(err, returns.ErrInvalidProduct),
+		errors.Is(err, returns.ErrInvalidQty),
+		errors.Is(err, returns.ErrQtyExceeded),
+		errors.Is(err, returns.ErrInvalidReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, returns.ErrNotReturnable), errors.Is(err, payments.ErrRefundExceeded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, returns.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": "invalid return status transition"})
+	default:
+		log.Println("returns error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func returnToJSON(r returns.Return, admin bool) gin.H {
+	items := make([]gin.H, 0, len(r.Items))
+	for _, it := range r.Items {
+		items = append(items, gin.H{
+			"productId":    it.ProductID,
+			"productName":  it.ProductName,
+			"quantity":     it.Quantity,
+			"unitPrice":    it.UnitPrice.Major(),
+			"refundAmount": it.RefundAmount.Major(),
+		})
+	}
+
+	history := make([]gin.H, 0, len(r.StatusHistory))
+	for _, ch := range r.StatusHistory {
+		entry := gin.H{
+			"from":      ch.From,
+			"to":        ch.To,
+			"note":      ch.Note,
+			"changedAt": ch.ChangedAt,
+		}
+		if admin {
+			entry["changedBy"] = ch.ChangedBy
+		}
+		history = append(history, entry)
+	}
+
+	out := gin.H{
+		"id":            r.ID,
+		"orderId":       r.OrderID,
+		"items":         items,
+		"reason":        r.Reason,
+		"status":        r.Status,
+		"refundAmount":  r.RefundAmount.Major(),
+		"currency":      r.RefundAmount.Currency,
+		"restocked":     r.Restocked,
+		"statusHistory": history,
+		"createdAt":     r.CreatedAt,
+		"updatedAt":     r.UpdatedAt,
+	}
+	if admin {
+		out["userId"] = r.UserID
+	}
+	return out
+}
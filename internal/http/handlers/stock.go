@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/gin-gonic/gin"
+)
+
+type StockHandler struct {
+	svc stock.Service
+}
+
+func NewStockHandler(svc stock.Service) *StockHandler {
+	return &StockHandler{svc: svc}
+}
+
+// StockHistory godoc
+// @Summary Stock movements of a product (admin only)
+// @Description Newest first. reconciliation compares current stock with the sum of all movements; it is null for deleted products, whose history is kept.
+// @Tags Admin Stock
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param offset query int true "Offset for pagination"
+// @Param limit query int true "Limit for pagination"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/products/{id}/stock-history [get]
+func (h *StockHandler) History(c *gin.Context) {
+	offsetStr := c.Query("offset")
+	limitStr := c.Query("limit")
+
+	if offsetStr == "" || limitStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit are required"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	id := c.Param("id")
+	list, total, err := h.svc.History(c.Request.Context(), stock.ListFilter{ProductID: id, Offset: offset, Limit: limit})
+	if err != nil {
+		writeStockError(c, err)
+		return
+	}
+
+	var rec gin.H
+	r, err := h.svc.Check(c.Request.Context(), id)
+	switch {
+	case err == nil:
+		rec = reconciliationToJSON(r)
+	case !errors.Is(err, stock.ErrNotFound):
+		writeStockError(c, err)
+		return
+	}
+
+	out := make([]gin.H, 0, len(list))
+	for _, m := range list {
+		out = append(out, movementToJSON(m))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":          out,
+		"total":          total,
+		"offset":         offset,
+		"limit":          limit,
+		"reconciliation": rec,
+	})
+}
+
+// ReconcileStock godoc
+// @Summary Check stock against the movement ledger (admin only)
+// @Description Lists products whose stock differs from the sum of their movements; drift is stock minus ledger. all=true lists every product.
+// @Tags Admin Stock
+// @Produce json
+// @Param all query bool false "Include balanced products"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/stock/reconciliation [get]
+func (h *StockHandler) Reconcile(c *gin.Context) {
+	all := c.Query("all") == "true"
+
+	list, err := h.svc.Reconcile(c.Request.Context(), all)
+	if err != nil {
+		writeStockError(c, err)
+		return
+	}
+
+	balanced := true
+	out := make([]gin.H, 0, len(list))
+	for _, r := range list {
+		balanced = balanced && r.Balanced()
+		out = append(out, reconciliationToJSON(r))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":    out,
+		"balanced": balanced,
+	})
+}
+
+func writeStockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, stock.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, stock.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func movementToJSON(m stock.Movement) gin.H {
+	return gin.H{
+		"id":        m.ID,
+		"productId": m.ProductID,
+		"delta":     m.Delta,
+		"reason":    m.Reason,
+		"actorId":   m.ActorID,
+		"reference": m.Reference,
+		"note":      m.Note,
+		"createdAt": m.CreatedAt,
+	}
+}
+
+func reconciliationToJSON(r stock.Reconciliation) gin.H {
+	return gin.H{
+		"productId":   r.ProductID,
+		"productName": r.ProductName,
+		"stock":       r.Stock,
+		"ledger":      r.Ledger,
+		"movements":   r.Movements,
+		"drift":       r.Drift(),
+		"balanced":    r.Balanced(),
+	}
+}
@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/bnursik/aitu-ad-final-back/internal/http/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStock embeds the interface so only Reconcile needs writing.
+type fakeStock struct {
+	stock.Service
+	recs []stock.Reconciliation
+	err  error
+	all  bool
+}
+
+func (s *fakeStock) Reconcile(ctx context.Context, all bool) ([]stock.Reconciliation, error) {
+	s.all = all
+	return s.recs, s.err
+}
+
+type reconcileResponse struct {
+	Items []struct {
+		ProductID string `json:"productId"`
+		Stock     int64  `json:"stock"`
+		Ledger    int64  `json:"ledger"`
+		Drift     int64  `json:"drift"`
+		Balanced  bool   `json:"balanced"`
+	} `json:"items"`
+	Balanced bool `json:"balanced"`
+}
+
+func reconcile(t *testing.T, svc stock.Service, query string) (int, reconcileResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/stock/reconciliation", handlers.NewStockHandler(svc).Reconcile)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/stock/reconciliation"+query, nil))
+
+	var body reconcileResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %s: %v", w.Body, err)
+		}
+	}
+	return w.Code, body
+}
+
+func TestReconcileReportsDrift(t *testing.T) {
+	svc := &fakeStock{recs: []stock.Reconciliation{
+		{ProductID: "keyboard", Stock: 6, Ledger: 4},
+	}}
+
+	code, body := reconcile(t, svc, "")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if svc.all {
+		t.Error("balanced products requested without all=true")
+	}
+	if body.Balanced || len(body.Items) != 1 {
+		t.Fatalf("body = %+v, want one unbalanced item", body)
+	}
+	if it := body.Items[0]; it.ProductID != "keyboard" || it.Drift != 2 || it.Balanced {
+		t.Errorf("item = %+v, want keyboard drifting by 2", it)
+	}
+}
+
+func TestReconcileAllBalanced(t *testing.T) {
+	svc := &fakeStock{recs: []stock.Reconciliation{
+		{ProductID: "mouse", Stock: 9, Ledger: 9},
+		{ProductID: "keyboard", Stock: 4, Ledger: 4},
+	}}
+
+	code, body := reconcile(t, svc, "?all=true")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if !svc.all {
+		t.Error("all=true not passed to the service")
+	}
+	if !body.Balanced || len(body.Items) != 2 {
+		t.Errorf("body = %+v, want two balanced items", body)
+	}
+
+	// nothing drifted: an empty list is balanced
+	code, body = reconcile(t, &fakeStock{}, "")
+	if code != http.StatusOK || !body.Balanced || body.Items == nil || len(body.Items) != 0 {
+		t.Errorf("status %d, body %+v; want an empty balanced list", code, body)
+	}
+}
+
+func TestReconcileError(t *testing.T) {
+	code, _ := reconcile(t, &fakeStock{err: errors.New("boom")}, "")
+	if code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", code)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+	"github.com/gin-gonic/gin"
+)
+
+type TaxesHandler struct {
+	svc taxes.Service
+}
+
+func NewTaxesHandler(svc taxes.Service) *TaxesHandler {
+	return &TaxesHandler{svc: svc}
+}
+
+type CreateTaxRuleRequest struct {
+	Name string `json:"name" binding:"required" example:"VAT"`
+	// Percent; 0 exempts the matching lines from broader rules.
+	Rate float64 `json:"rate" example:"12"`
+	// Prices already include the tax, so it is not added to the order total.
+	Inclusive bool `json:"inclusive"`
+	// Empty matches every category / country / region.
+	CategoryID string `json:"categoryId"`
+	Country    string `json:"country"`
+	Region     string `json:"region"`
+	Active     bool   `json:"active"`
+}
+
+type UpdateTaxRuleRequest struct {
+	Name      *string  `json:"name"`
+	Rate      *float64 `json:"rate"`
+	Inclusive *bool    `json:"inclusive"`
+	// An empty string removes the restriction.
+	CategoryID *string `json:"categoryId"`
+	Country    *string `json:"country"`
+	Region     *string `json:"region"`
+	Active     *bool   `json:"active"`
+}
+
+// ListTaxRules godoc
+// @Summary List tax rules (admin only)
+// @Description Each order line is taxed by the most specific active rule matching its category and the shipping country/region.
+// @Tags Admin Taxes
+// @Produce json
+// @Param active query bool false "Only active rules"
+// @Success 200 {array} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/tax-rules [get]
+func (h *TaxesHandler) List(c *gin.Context) {
+	items, err := h.svc.List(c.Request.Context(), taxes.ListFilter{ActiveOnly: c.Query("active") == "true"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, taxRuleToJSON(it))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GetTaxRule godoc
+// @Summary Get tax rule (admin only)
+// @Tags Admin Taxes
+// @Produce json
+// @Param id path string true "Tax rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/tax-rules/{id} [get]
+func (h *TaxesHandler) Get(c *gin.Context) {
+	it, err := h.svc.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, taxRuleToJSON(it))
+}
+
+// CreateTaxRule godoc
+// @Summary Create tax rule (admin only)
+// @Description Applies to orders placed from now on; existing orders keep their tax lines.
+// @Tags Admin Taxes
+// @Accept json
+// @Produce json
+// @Param body body CreateTaxRuleRequest true "Tax rule"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /admin/tax-rules [post]
+func (h *TaxesHandler) Create(c *gin.Context) {
+	var req CreateTaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	it, err := h.svc.Create(c.Request.Context(), taxes.CreateInput{
+		Name:       req.Name,
+		Rate:       req.Rate,
+		Inclusive:  req.Inclusive,
+		CategoryID: req.CategoryID,
+		Country:    req.Country,
+		Region:     req.Region,
+		Active:     req.Active,
+	})
+	if err != nil {
+		writeTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, taxRuleToJSON(it))
+}
+
+// UpdateTaxRule godoc
+// @Summary Update tax rule (admin only)
+// @Tags Admin Taxes
+// @Accept json
+// @Produce json
+// @Param id path string true "Tax rule ID"
+// @Param body body UpdateTaxRuleRequest true "Patch"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/tax-rules/{id} [put]
+func (h *TaxesHandler) Update(c *gin.Context) {
+	var req UpdateTaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	it, err := h.svc.Update(c.Request.Context(), c.Param("id"), taxes.UpdateInput{
+		Name:       req.Name,
+		Rate:       req.Rate,
+		Inclusive:  req.Inclusive,
+		CategoryID: req.CategoryID,
+		Country:    req.Country,
+		Region:     req.Region,
+		Active:     req.Active,
+	})
+	if err != nil {
+		writeTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, taxRuleToJSON(it))
+}
+
+// DeleteTaxRule godoc
+// @Summary Delete tax rule (admin only)
+// @Tags Admin Taxes
+// @Produce json
+// @Param id path string true "Tax rule ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/tax-rules/{id} [delete]
+func (h *TaxesHandler) Delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		writeTaxError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func writeTaxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, taxes.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	case errors.Is(err, taxes.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, taxes.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+	case errors.Is(err, taxes.ErrInvalidRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rate must be between 0 and 100"})
+	case errors.Is(err, taxes.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid categoryId"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func taxRuleToJSON(r taxes.Rule) gin.H {
+	return gin.H{
+		"id":         r.ID,
+		"name":       r.Name,
+		"rate":       r.Rate,
+		"inclusive":  r.Inclusive,
+		"categoryId": r.CategoryID,
+		"country":    r.Country,
+		"region":     r.Region,
+		"active":     r.Active,
+		"createdAt":  r.CreatedAt,
+		"updatedAt":  r.UpdatedAt,
+	}
+}
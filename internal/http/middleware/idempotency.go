@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderIdempotencyKey   = "Idempotency-Key"
+	HeaderIdempotentReplay = "Idempotent-Replayed"
+	// HeaderCurrency picks the display currency, which changes the response,
+	// so it is part of what a key is checked against.
+	HeaderCurrency          = "X-Currency"
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry. The first request with a key runs normally and its response is stored
+// for ttl; retries with the same key, query string, X-Currency and body get
+// the stored response back.
+// Reusing a key with a different request, or while the first one is still
+// running, returns 409. Must run after AuthRequired, since keys are per user.
+//
+// 5xx responses are not stored, so a retry after a server error runs again.
+func Idempotency(repo idempotency.Repo, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		uid := c.GetString(CtxUserID)
+		if uid == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(c.Request, body)
+		ctx := c.Request.Context()
+		now := time.Now().UTC()
+
+		err = repo.Reserve(ctx, idempotency.Record{
+			UserID:      uid,
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if errors.Is(err, idempotency.ErrKeyExists) {
+			replay(c, repo, uid, key, hash)
+			return
+		}
+		if err != nil {
+			log.Printf("idempotency reserve: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// the client may have gone away; the outcome still has to be recorded
+		ctx = context.WithoutCancel(ctx)
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			if err := repo.Release(ctx, uid, key); err != nil {
+				log.Printf("idempotency release: %v", err)
+			}
+			return
+		}
+		if err := repo.Complete(ctx, uid, key, status, w.Header().Get("Content-Type"), w.buf.Bytes()); err != nil {
+			log.Printf("idempotency complete: %v", err)
+		}
+	}
+}
+
+func replay(c *gin.Context, repo idempotency.Repo, uid, key, hash string) {
+	rec, err := repo.Get(c.Request.Context(), uid, key)
+	if err != nil {
+		// expired or released between Reserve and Get; the client can retry
+		if errors.Is(err, idempotency.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+			return
+		}
+		log.Printf("idempotency get: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	switch {
+	case rec.RequestHash != hash:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !rec.Completed:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+	default:
+		c.Header(HeaderIdempotentReplay, "true")
+		c.Data(rec.StatusCode, rec.ContentType, rec.Body)
+		c.Abort()
+	}
+}
+
+// requestHash fingerprints everything that shapes the response: the same
+// body posted with another ?currency= or X-Currency is a different request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get(HeaderCurrency)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// capturingWriter copies the response body so it can be stored for replay.
+type capturingWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/idempotency"
+	"github.com/bnursik/aitu-ad-final-back/internal/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// memKeys keeps idempotency records in a map; records never expire.
+type memKeys map[string]idempotency.Record
+
+func (m memKeys) Reserve(ctx context.Context, r idempotency.Record) error {
+	if _, ok := m[r.UserID+"/"+r.Key]; ok {
+		return idempotency.ErrKeyExists
+	}
+	m[r.UserID+"/"+r.Key] = r
+	return nil
+}
+
+func (m memKeys) Get(ctx context.Context, userID, key string) (idempotency.Record, error) {
+	r, ok := m[userID+"/"+key]
+	if !ok {
+		return idempotency.Record{}, idempotency.ErrNotFound
+	}
+	return r, nil
+}
+
+func (m memKeys) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error {
+	r := m[userID+"/"+key]
+	r.Completed, r.StatusCode, r.ContentType, r.Body = true, statusCode, contentType, body
+	m[userID+"/"+key] = r
+	return nil
+}
+
+func (m memKeys) Release(ctx context.Context, userID, key string) error {
+	delete(m, userID+"/"+key)
+	return nil
+}
+
+func TestIdempotencyKeyCoversQueryAndCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.POST("/orders",
+		func(c *gin.Context) { c.Set(middleware.CtxUserID, "user-1") },
+		middleware.Idempotency(memKeys{}, time.Hour),
+		func(c *gin.Context) {
+			calls++
+			c.JSON(http.StatusCreated, gin.H{"call": calls})
+		},
+	)
+	send := func(query, currency, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders"+query, strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+		if currency != "" {
+			req.Header.Set(middleware.HeaderCurrency, currency)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("?currency=USD", "", `{"a":1}`); w.Code != http.StatusCreated {
+		t.Fatalf("first request = %d %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name            string
+		query, currency string
+		body            string
+		want            int
+	}{
+		{"same request", "?currency=USD", "", `{"a":1}`, http.StatusCreated},
+		{"other query", "?currency=EUR", "", `{"a":1}`, http.StatusConflict},
+		{"no query", "", "", `{"a":1}`, http.StatusConflict},
+		{"currency header added", "?currency=USD", "EUR", `{"a":1}`, http.StatusConflict},
+		{"other body", "?currency=USD", "", `{"a":2}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.query, tt.currency, tt.body)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+			replayed := w.Header().Get(middleware.HeaderIdempotentReplay) == "true"
+			if replayed != (tt.want == http.StatusCreated) {
+				t.Errorf("replayed = %v", replayed)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+)
+
+var htmlTmpl = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Code}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; margin: 40px; }
+h1 { margin: 0 0 4px; }
+.meta, .bill { margin-bottom: 24px; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.num { text-align: right; }
+tfoot td { border: none; }
+tfoot tr.total td { font-weight: bold; border-top: 2px solid #222; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Code}}</h1>
+<div class="meta">
+<div>Issued: {{.IssuedAt}}</div>
+<div>Order: {{.OrderID}} ({{.OrderDate}})</div>
+<div>Payment: {{.PaidStatus}}</div>
+</div>
+{{if .BillTo}}<div class="bill">
+<strong>Bill to</strong>
+{{range .BillTo}}<div>{{.}}</div>
+{{end}}</div>
+{{end}}<table>
+<thead>
+<tr><th>Item</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Amount</th></tr>
+</thead>
+<tbody>
+{{range .Lines}}<tr><td>{{.Name}}</td><td class="num">{{.Quantity}}</td><td class="num">{{.UnitPrice}}</td><td class="num">{{.LineTotal}}</td></tr>
+{{end}}</tbody>
+<tfoot>
+<tr><td colspan="3" class="num">Subtotal</td><td class="num">{{.Subtotal}}</td></tr>
+{{range .Discounts}}<tr><td colspan="3" class="num">{{.Label}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}{{with .Delivery}}<tr><td colspan="3" class="num">{{.Label}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td colspan="3" class="num">{{.Label}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}<tr class="total"><td colspan="3" class="num">Total ({{.Currency}})</td><td class="num">{{.Total}}</td></tr>
+{{with .Charged}}<tr><td colspan="3" class="num">{{.Label}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// HTML writes doc as a standalone, print-friendly HTML page.
+func HTML(w io.Writer, doc invoices.Document) error {
+	return htmlTmpl.Execute(w, newView(doc))
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/go-pdf/fpdf"
+)
+
+// column widths in mm; they add up to the printable width of A4 with 15mm margins
+var pdfCols = [4]float64{105, 20, 27, 28}
+
+// PDF writes doc as a single A4 PDF. It uses the built-in Helvetica font, so
+// characters outside Windows-1252 print as '?'.
+func PDF(w io.Writer, doc invoices.Document) error {
+	v := newView(doc)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetTitle("Invoice "+v.Code, true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Invoice "+v.Code, "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, "Issued: "+v.IssuedAt, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Order: "+v.OrderID+" ("+v.OrderDate+")", "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Payment: "+v.PaidStatus, "", 1, "L", false, 0, "")
+	pdf.Ln(5)
+
+	if len(v.BillTo) > 0 {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(0, 5, "Bill to", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		for _, l := range v.BillTo {
+			pdf.CellFormat(0, 5, tr(l), "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(5)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, h := range []string{"Item", "Qty", "Unit price", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(pdfCols[i], 7, h, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, l := range v.Lines {
+		pdf.CellFormat(pdfCols[0], 7, tr(l.Name), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(pdfCols[1], 7, strconv.FormatInt(l.Quantity, 10), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(pdfCols[2], 7, l.UnitPrice, "B", 0, "R", false, 0, "")
+		pdf.CellFormat(pdfCols[3], 7, l.LineTotal, "B", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	total := func(label, amount string) {
+		pdf.CellFormat(pdfCols[0]+pdfCols[1]+pdfCols[2], 6, tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(pdfCols[3], 6, amount, "", 1, "R", false, 0, "")
+	}
+	total("Subtotal", v.Subtotal)
+	for _, d := range v.Discounts {
+		total(d.Label, d.Amount)
+	}
+	if v.Delivery != nil {
+		total(v.Delivery.Label, v.Delivery.Amount)
+	}
+	for _, t := range v.Taxes {
+		total(t.Label, t.Amount)
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	total("Total ("+v.Currency+")", v.Total)
+	if v.Charged != nil {
+		pdf.SetFont("Helvetica", "", 10)
+		total(v.Charged.Label, v.Charged.Amount)
+	}
+
+	return pdf.Output(w)
+}
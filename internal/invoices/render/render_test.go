@@ -0,0 +1,88 @@
+package render_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/invoices/render"
+)
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func testDocument() invoices.Document {
+	at := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	return invoices.Document{
+		Invoice: invoices.Invoice{ID: "inv-1", OrderID: "order-1", Number: 42, IssuedAt: at},
+		Order: orders.Order{
+			ID:            "order-1",
+			PaymentStatus: orders.PaymentPaid,
+			Items: []orders.Item{
+				{ProductName: "Mouse <Pro>", Quantity: 2, UnitPrice: kzt(2500), LineTotal: kzt(5000)},
+				{ProductName: "Keyboard", Quantity: 1, UnitPrice: kzt(7990), LineTotal: kzt(7990)},
+			},
+			ShippingAddress: &orders.ShippingAddress{FullName: "Ada Lovelace", Line1: "1 Analytical St", City: "London", PostalCode: "N1"},
+			Delivery:        &orders.DeliveryMethod{Name: "Courier", Fee: kzt(500)},
+			Subtotal:        kzt(12990),
+			Discounts:       []orders.Discount{{Code: "SAVE10", Amount: kzt(1299)}},
+			TotalPrice:      kzt(12191),
+			CreatedAt:       at.Add(-48 * time.Hour),
+		},
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.HTML(&buf, testDocument()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"Invoice INV-000042",
+		"Issued: 2026-03-14",
+		"Order: order-1 (2026-03-12)",
+		"Payment: paid",
+		"Mouse &lt;Pro&gt;",
+		"N1, London",
+		">50.00<", ">79.90<", ">129.90<",
+		"Discount SAVE10", ">-12.99<",
+		"Delivery: Courier", ">5.00<",
+		">121.91<",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML is missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<Pro>") {
+		t.Error("product name is not escaped")
+	}
+}
+
+func TestHTMLUnpaid(t *testing.T) {
+	doc := testDocument()
+	doc.Order.PaymentStatus = ""
+	doc.Order.ShippingAddress = nil
+
+	var buf bytes.Buffer
+	if err := render.HTML(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Payment: unpaid") || strings.Contains(out, "Bill to") {
+		t.Errorf("HTML for an unpaid order without an address:\n%s", out)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.PDF(&buf, testDocument()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("output starts %q, want a PDF header", buf.Bytes()[:min(8, buf.Len())])
+	}
+}
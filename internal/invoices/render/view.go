@@ -0,0 +1,131 @@
+// Package render turns an invoice document into printable HTML or PDF.
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+)
+
+// view is the format-independent content of an invoice, with amounts
+// already formatted so HTML and PDF print identical figures.
+type view struct {
+	Code      string
+	IssuedAt  string
+	OrderID   string
+	OrderDate string
+	BillTo    []string
+	Lines     []line
+	Subtotal  string
+	Discounts []adjustment
+	Delivery  *adjustment
+	Taxes     []adjustment
+	Total     string
+	Currency  string
+	// Charged is the total in the customer's currency when they shopped in
+	// one other than the base currency.
+	Charged    *adjustment
+	PaidStatus string
+}
+
+type line struct {
+	Name      string
+	Quantity  int64
+	UnitPrice string
+	LineTotal string
+}
+
+type adjustment struct {
+	Label  string
+	Amount string
+}
+
+func newView(doc invoices.Document) view {
+	o := doc.Order
+
+	v := view{
+		Code:       doc.Invoice.Code(),
+		IssuedAt:   formatDate(doc.Invoice.IssuedAt),
+		OrderID:    o.ID,
+		OrderDate:  formatDate(o.CreatedAt),
+		BillTo:     billTo(o.ShippingAddress),
+		Subtotal:   o.Subtotal.Decimal(),
+		Total:      o.TotalPrice.Decimal(),
+		Currency:   string(o.TotalPrice.Currency),
+		PaidStatus: string(o.PaymentStatus),
+	}
+	if v.PaidStatus == "" {
+		v.PaidStatus = string(orders.PaymentUnpaid)
+	}
+
+	for _, it := range o.Items {
+		v.Lines = append(v.Lines, line{
+			Name:      it.ProductName,
+			Quantity:  it.Quantity,
+			UnitPrice: it.UnitPrice.Decimal(),
+			LineTotal: it.LineTotal.Decimal(),
+		})
+	}
+	for _, d := range o.Discounts {
+		v.Discounts = append(v.Discounts, adjustment{
+			Label:  "Discount " + d.Code,
+			Amount: d.Amount.Neg().Decimal(),
+		})
+	}
+	if d := o.Delivery; d != nil {
+		v.Delivery = &adjustment{Label: "Delivery: " + d.Name, Amount: d.Fee.Decimal()}
+	}
+	for _, t := range o.Taxes {
+		label := fmt.Sprintf("%s %s%%", t.Name, strconv.FormatFloat(t.Rate, 'f', -1, 64))
+		if t.Inclusive {
+			label += " (included)"
+		}
+		v.Taxes = append(v.Taxes, adjustment{Label: label, Amount: t.Amount.Decimal()})
+	}
+	if len(v.Taxes) == 0 {
+		v.Taxes = []adjustment{{Label: "Tax", Amount: money.Zero(o.TotalPrice.Currency).Decimal()}}
+	}
+	if dp := o.Display; dp != nil {
+		v.Charged = &adjustment{
+			Label:  fmt.Sprintf("Charged (%s, 1 %s = %s %s)", dp.Currency, dp.Currency, strconv.FormatFloat(dp.Rate, 'f', -1, 64), v.Currency),
+			Amount: dp.TotalPrice.Decimal(),
+		}
+	}
+
+	return v
+}
+
+func billTo(a *orders.ShippingAddress) []string {
+	if a == nil {
+		return nil
+	}
+
+	var out []string
+	add := func(parts ...string) {
+		var kept []string
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) > 0 {
+			out = append(out, strings.Join(kept, ", "))
+		}
+	}
+	add(a.FullName)
+	add(a.Line1)
+	add(a.Line2)
+	add(a.PostalCode, a.City, a.Region)
+	add(a.Country)
+	add(a.Phone)
+	return out
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,65 @@
+// Package filenotifier appends low-stock alerts to a file as JSON Lines, for
+// a cron job or log shipper to pick up.
+package filenotifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+)
+
+type Notifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns a notifier appending to path. The file is opened per alert, so
+// it may be rotated or removed while the app runs.
+func New(path string) *Notifier {
+	return &Notifier{path: path}
+}
+
+var _ products.Notifier = (*Notifier)(nil)
+
+type alertLine struct {
+	Type        string    `json:"type"`
+	ProductID   string    `json:"productId"`
+	ProductName string    `json:"productName"`
+	Stock       int64     `json:"stock"`
+	Threshold   int64     `json:"threshold"`
+	OrderID     string    `json:"orderId"`
+	At          time.Time `json:"at"`
+}
+
+func (n *Notifier) NotifyLowStock(ctx context.Context, a products.StockAlert) error {
+	line, err := json.Marshal(alertLine{
+		Type:        "low_stock",
+		ProductID:   a.ProductID,
+		ProductName: a.ProductName,
+		Stock:       a.Stock,
+		Threshold:   a.Threshold,
+		OrderID:     a.OrderID,
+		At:          a.At,
+	})
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open alerts file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write alert: %w", err)
+	}
+	return f.Close()
+}
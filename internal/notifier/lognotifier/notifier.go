@@ -0,0 +1,30 @@
+// Package lognotifier writes low-stock alerts to the standard logger.
+package lognotifier
+
+import (
+	"context"
+	"log"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+)
+
+type Notifier struct {
+	logger *log.Logger
+}
+
+// New returns a notifier that logs through logger, or the standard logger
+// when it is nil.
+func New(logger *log.Logger) *Notifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Notifier{logger: logger}
+}
+
+var _ products.Notifier = (*Notifier)(nil)
+
+func (n *Notifier) NotifyLowStock(ctx context.Context, a products.StockAlert) error {
+	n.logger.Printf("low stock: product %s (%q) is at %d, reorder threshold %d, after order %s",
+		a.ProductID, a.ProductName, a.Stock, a.Threshold, a.OrderID)
+	return nil
+}
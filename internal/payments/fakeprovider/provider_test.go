@@ -0,0 +1,66 @@
+package fakeprovider_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/payments/fakeprovider"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	p := fakeprovider.New("whsec")
+	body := []byte(`{"type":"payment.succeeded","providerRef":"fake_pi_1"}`)
+
+	tests := []struct {
+		name      string
+		p         *fakeprovider.Provider
+		payload   []byte
+		signature string
+		want      error
+	}{
+		{"valid", p, body, p.Sign(body), nil},
+		{"signature padded with spaces", p, body, " " + p.Sign(body) + "\n", nil},
+		{"missing signature", p, body, "", payments.ErrInvalidSignature},
+		{"not hex", p, body, "not-a-signature", payments.ErrInvalidSignature},
+		{"tampered payload", p, []byte(strings.Replace(string(body), "fake_pi_1", "fake_pi_2", 1)), p.Sign(body), payments.ErrInvalidSignature},
+		{"other secret", fakeprovider.New("other"), body, p.Sign(body), payments.ErrInvalidSignature},
+		{"no secret configured", fakeprovider.New(""), body, fakeprovider.New("").Sign(body), payments.ErrInvalidSignature},
+		{"signed but not JSON", p, []byte("ok"), p.Sign([]byte("ok")), payments.ErrInvalidEvent},
+		{"signed but no ref", p, []byte(`{"type":"payment.failed"}`), p.Sign([]byte(`{"type":"payment.failed"}`)), payments.ErrInvalidEvent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := tt.p.VerifyWebhook(tt.payload, tt.signature)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyWebhook = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (ev.Type != payments.EventSucceeded || ev.ProviderRef != "fake_pi_1") {
+				t.Errorf("event = %+v", ev)
+			}
+		})
+	}
+}
+
+func TestCaptureAndRefundKnownIntentsOnly(t *testing.T) {
+	ctx := context.Background()
+	p := fakeprovider.New("whsec")
+	amount := money.New(1000, money.Default)
+
+	intent, err := p.CreateIntent(ctx, "order-1", amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Capture(ctx, intent.Ref, amount); err != nil {
+		t.Errorf("Capture = %v", err)
+	}
+	if err := p.Refund(ctx, intent.Ref, amount); err != nil {
+		t.Errorf("Refund = %v", err)
+	}
+	if err := p.Refund(ctx, "pi_elsewhere", amount); err == nil {
+		t.Error("Refund of an unknown intent succeeded")
+	}
+}
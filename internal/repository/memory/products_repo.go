@@ -0,0 +1,481 @@
+package memrepo
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductsRepo is an in-memory products.Repo. IDs are ObjectID hex strings,
+// so malformed IDs fail with the same errors as in Mongo.
+type ProductsRepo struct {
+	mu       sync.RWMutex
+	products map[string]products.Product
+}
+
+func NewProductsRepo() *ProductsRepo {
+	return &ProductsRepo{products: make(map[string]products.Product)}
+}
+
+var _ products.Repo = (*ProductsRepo)(nil)
+
+func (r *ProductsRepo) List(ctx context.Context, f products.ListFilter) ([]products.Product, error) {
+	list, err := r.filter(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Offset >= int64(len(list)) {
+		return []products.Product{}, nil
+	}
+	list = list[f.Offset:]
+	if f.Limit > 0 && f.Limit < int64(len(list)) {
+		list = list[:f.Limit]
+	}
+	return list, nil
+}
+
+func (r *ProductsRepo) Count(ctx context.Context, f products.ListFilter) (int64, error) {
+	list, err := r.filter(f)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(list)), nil
+}
+
+func (r *ProductsRepo) Facets(ctx context.Context, f products.ListFilter, priceBreaks []money.Money) (products.Facets, error) {
+	out := products.Facets{
+		Categories: []products.CategoryCount{},
+		Prices:     products.PriceBuckets(priceBreaks),
+		Ratings:    products.RatingBuckets(),
+	}
+
+	all, err := r.filter(f.Except(""))
+	if err != nil {
+		return products.Facets{}, err
+	}
+	out.Total = int64(len(all))
+
+	list, err := r.filter(f.Except(products.FacetCategory))
+	if err != nil {
+		return products.Facets{}, err
+	}
+	counts := make(map[string]int64)
+	for _, p := range list {
+		counts[p.CategoryID]++
+	}
+	for id, n := range counts {
+		out.Categories = append(out.Categories, products.CategoryCount{CategoryID: id, Count: n})
+	}
+	sort.Slice(out.Categories, func(i, j int) bool {
+		a, b := out.Categories[i], out.Categories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.CategoryID < b.CategoryID
+	})
+
+	if list, err = r.filter(f.Except(products.FacetPrice)); err != nil {
+		return products.Facets{}, err
+	}
+	for _, p := range list {
+		// the last bucket whose lower bound the price reaches
+		for i := len(out.Prices) - 1; i >= 0; i-- {
+			if p.Price.Amount >= out.Prices[i].Min.Amount {
+				out.Prices[i].Count++
+				break
+			}
+		}
+	}
+
+	if list, err = r.filter(f.Except(products.FacetRating)); err != nil {
+		return products.Facets{}, err
+	}
+	for _, p := range list {
+		for i := range out.Ratings {
+			if p.RatingCount > 0 && p.RatingAvg >= out.Ratings[i].MinRating {
+				out.Ratings[i].Count++
+			}
+		}
+	}
+
+	if list, err = r.filter(f.Except(products.FacetAvailability)); err != nil {
+		return products.Facets{}, err
+	}
+	for _, p := range list {
+		if p.Available() > 0 {
+			out.InStock++
+		} else {
+			out.OutOfStock++
+		}
+	}
+	return out, nil
+}
+
+func (r *ProductsRepo) GetByID(ctx context.Context, id string) (products.Product, error) {
+	if !validID(id) {
+		return products.Product{}, products.ErrInvalidID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	return clone(p), nil
+}
+
+func (r *ProductsRepo) GetByIDs(ctx context.Context, ids []string) (map[string]products.Product, error) {
+	for _, id := range ids {
+		if !validID(id) {
+			return nil, products.ErrInvalidID
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]products.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := r.products[id]; ok {
+			out[id] = clone(p)
+		}
+	}
+	return out, nil
+}
+
+func (r *ProductsRepo) Create(ctx context.Context, p products.Product) (products.Product, error) {
+	if !validID(p.CategoryID) {
+		return products.Product{}, products.ErrInvalidCategory
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.ID = primitive.NewObjectID().Hex()
+	p.Reserved = 0
+	p.Reviews = nil
+	p.RatingAvg, p.RatingCount, p.Sold = 0, 0, 0
+	r.products[p.ID] = clone(p)
+	return p, nil
+}
+
+func (r *ProductsRepo) Update(ctx context.Context, id string, in products.UpdateInput) (products.Product, error) {
+	if !validID(id) {
+		return products.Product{}, products.ErrInvalidID
+	}
+	if in.CategoryID != nil && !validID(*in.CategoryID) {
+		return products.Product{}, products.ErrInvalidCategory
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	if in.Stock != nil && *in.Stock < p.Reserved {
+		return products.Product{}, products.ErrStockBelowReserved
+	}
+	if in.CategoryID != nil {
+		p.CategoryID = *in.CategoryID
+	}
+	if in.Name != nil {
+		p.Name = *in.Name
+	}
+	if in.Description != nil {
+		p.Description = *in.Description
+	}
+	if in.Price != nil {
+		p.Price = *in.Price
+	}
+	if in.Stock != nil {
+		p.Stock = *in.Stock
+	}
+	if in.ReorderThreshold != nil {
+		p.ReorderThreshold = *in.ReorderThreshold
+	}
+	p.UpdatedAt = time.Now().UTC()
+	r.products[id] = p
+	return clone(p), nil
+}
+
+func (r *ProductsRepo) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return products.ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return products.ErrNotFound
+	}
+	if p.Stock >= 1 {
+		return products.ErrCannotDeleteProduct
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *ProductsRepo) DecrementStock(ctx context.Context, productID string, qty int64) (products.Product, error) {
+	var out products.Product
+	err := r.modify(productID, func(p *products.Product) error {
+		if qty > 0 && p.Stock-p.Reserved < qty {
+			return products.ErrInsufficientStock
+		}
+		if qty > 0 {
+			p.Stock -= qty
+			p.UpdatedAt = time.Now().UTC()
+		}
+		out = clone(*p)
+		return nil
+	})
+	return out, err
+}
+
+func (r *ProductsRepo) IncrementStock(ctx context.Context, productID string, qty int64) error {
+	return r.modify(productID, func(p *products.Product) error {
+		if qty > 0 {
+			p.Stock += qty
+			p.UpdatedAt = time.Now().UTC()
+		}
+		return nil
+	})
+}
+
+func (r *ProductsRepo) Reserve(ctx context.Context, productID string, qty int64) error {
+	return r.modify(productID, func(p *products.Product) error {
+		if qty <= 0 {
+			return nil
+		}
+		if p.Stock-p.Reserved < qty {
+			return products.ErrInsufficientStock
+		}
+		p.Reserved += qty
+		return nil
+	})
+}
+
+func (r *ProductsRepo) Unreserve(ctx context.Context, productID string, qty int64) error {
+	return r.modify(productID, func(p *products.Product) error {
+		if qty > 0 {
+			p.Reserved = max(0, p.Reserved-qty)
+		}
+		return nil
+	})
+}
+
+func (r *ProductsRepo) AddReview(ctx context.Context, productID string, rev products.Review) (products.Review, error) {
+	rev.ID = primitive.NewObjectID().Hex()
+	err := r.modify(productID, func(p *products.Product) error {
+		p.Reviews = append(p.Reviews, rev)
+		refreshRating(p)
+		return nil
+	})
+	if err != nil {
+		return products.Review{}, err
+	}
+	return rev, nil
+}
+
+func (r *ProductsRepo) DeleteReview(ctx context.Context, productID string, reviewID string) error {
+	if validID(productID) && !validID(reviewID) {
+		return products.ErrInvalidReviewID
+	}
+	return r.modify(productID, func(p *products.Product) error {
+		for i, rev := range p.Reviews {
+			if rev.ID == reviewID {
+				p.Reviews = append(p.Reviews[:i:i], p.Reviews[i+1:]...)
+				refreshRating(p)
+				return nil
+			}
+		}
+		return products.ErrNotFound
+	})
+}
+
+func (r *ProductsRepo) AddSold(ctx context.Context, productID string, qty int64) error {
+	return r.modify(productID, func(p *products.Product) error {
+		p.Sold += qty
+		return nil
+	})
+}
+
+// ---- helpers ----
+
+// filter applies f and orders the result like the Mongo repo; see
+// products.ListFilter.SortBy. Ties go by ID, in the direction of the sort
+// when one is given.
+func (r *ProductsRepo) filter(f products.ListFilter) ([]products.Product, error) {
+	var categories map[string]bool
+	if len(f.CategoryIDs) > 0 {
+		categories = make(map[string]bool, len(f.CategoryIDs))
+		for _, id := range f.CategoryIDs {
+			id = strings.TrimSpace(id)
+			if !validID(id) {
+				return nil, products.ErrInvalidCategory
+			}
+			categories[id] = true
+		}
+	}
+
+	var search *products.Search
+	if f.Query != "" {
+		s := products.ParseSearch(f.Query)
+		search = &s
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type hit struct {
+		p     products.Product
+		score float64
+	}
+	hits := make([]hit, 0, len(r.products))
+	for _, p := range r.products {
+		if categories != nil && !categories[p.CategoryID] {
+			continue
+		}
+		if f.MinPrice != nil && p.Price.Amount < f.MinPrice.Amount {
+			continue
+		}
+		if f.MaxPrice != nil && p.Price.Amount > f.MaxPrice.Amount {
+			continue
+		}
+		if f.InStockOnly && p.Available() <= 0 {
+			continue
+		}
+		if f.MinRating != nil && (p.RatingCount == 0 || p.RatingAvg < *f.MinRating) {
+			continue
+		}
+		if f.LowStockOnly && !p.LowStock() {
+			continue
+		}
+		h := hit{p: p}
+		if search != nil {
+			if h.score = search.Score(p); h.score == 0 {
+				continue
+			}
+		}
+		hits = append(hits, h)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		switch {
+		case f.SortBy != "":
+			if c := compareBy(f.SortBy, a.p, b.p); c != 0 {
+				return (c < 0) == f.SortAsc
+			}
+			return (a.p.ID < b.p.ID) == f.SortAsc
+		case search != nil:
+			if a.score != b.score {
+				return a.score > b.score
+			}
+		case f.LowStockOnly:
+			if a.p.Stock != b.p.Stock {
+				return a.p.Stock < b.p.Stock
+			}
+		default:
+			if c := compareBy(products.SortByCreatedAt, a.p, b.p); c != 0 {
+				return c > 0
+			}
+			return a.p.ID > b.p.ID
+		}
+		return a.p.ID < b.p.ID
+	})
+
+	out := make([]products.Product, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, clone(h.p))
+	}
+	return out, nil
+}
+
+// compareBy compares a and b on field, ascending.
+func compareBy(field products.SortField, a, b products.Product) int {
+	switch field {
+	case products.SortByPrice:
+		return cmp.Compare(a.Price.Amount, b.Price.Amount)
+	case products.SortByRating:
+		return cmp.Compare(a.RatingAvg, b.RatingAvg)
+	case products.SortBySold:
+		return cmp.Compare(a.Sold, b.Sold)
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
+
+// refreshRating recomputes the rating summary from p's reviews.
+func refreshRating(p *products.Product) {
+	p.RatingCount = int64(len(p.Reviews))
+	p.RatingAvg = 0
+	if p.RatingCount == 0 {
+		return
+	}
+	var sum int64
+	for _, rev := range p.Reviews {
+		sum += rev.Rating
+	}
+	p.RatingAvg = float64(sum) / float64(p.RatingCount)
+}
+
+// snapshot implements snapshotter for UnitOfWork.
+func (r *ProductsRepo) snapshot() func() {
+	r.mu.RLock()
+	saved := make(map[string]products.Product, len(r.products))
+	for id, p := range r.products {
+		saved[id] = clone(p)
+	}
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.products = saved
+		r.mu.Unlock()
+	}
+}
+
+// modify runs fn on the stored product under the write lock. The product is
+// only saved if fn succeeds.
+func (r *ProductsRepo) modify(id string, fn func(p *products.Product) error) error {
+	if !validID(id) {
+		return products.ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return products.ErrNotFound
+	}
+	p = clone(p)
+	if err := fn(&p); err != nil {
+		return err
+	}
+	r.products[id] = p
+	return nil
+}
+
+func validID(id string) bool {
+	return primitive.IsValidObjectID(id)
+}
+
+// clone copies p so callers can't change stored reviews through the slice.
+func clone(p products.Product) products.Product {
+	p.Reviews = append([]products.Review{}, p.Reviews...)
+	return p
+}
@@ -0,0 +1,223 @@
+package memrepo_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProductsRepoListQuery(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.NewProductsRepo()
+	category := primitive.NewObjectID().Hex()
+
+	names := map[string]string{} // product ID -> name
+	for _, p := range []products.Product{
+		{Name: "Wireless Keyboard", Description: "Quiet keys with a rechargeable battery"},
+		{Name: "Mechanical Keyboard Switch Kit", Description: "Wired, with hot-swappable switches"},
+		{Name: "Keyboard", Description: "Compact layout"},
+		{Name: "Gaming Mouse", Description: "Pairs with any keyboard"},
+		{Name: "AA Batteries", Description: "Pack of four"},
+		{Name: "USB Fast Charger", Description: "Charges phones and batteries"},
+	} {
+		p.CategoryID = category
+		p.Price = money.New(1000, money.Default)
+		created, err := repo.Create(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names[created.ID] = created.Name
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // in relevance order
+	}{
+		// a name match outweighs a description match, and a term weighs
+		// more in a shorter field
+		{"relevance order", "keyboards", []string{
+			"Keyboard", "Wireless Keyboard", "Mechanical Keyboard Switch Kit", "Gaming Mouse",
+		}},
+		{"stemmed", "battery", []string{"AA Batteries", "USB Fast Charger", "Wireless Keyboard"}},
+		{"exclusion", "keyboard -wired", []string{"Keyboard", "Wireless Keyboard", "Gaming Mouse"}},
+		{"phrase", `"fast charger"`, []string{"USB Fast Charger"}},
+		{"phrase and words", `keyboard "rechargeable battery"`, []string{"Wireless Keyboard"}},
+		{"stop words only", "the with", []string{}},
+		{"no match", "monitor", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := repo.List(ctx, products.ListFilter{Query: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(list))
+			for _, p := range list {
+				got = append(got, names[p.ID])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductsRepoRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.NewProductsRepo()
+	tx := memrepo.NewUnitOfWork(repo)
+
+	p, err := repo.Create(ctx, products.Product{CategoryID: primitive.NewObjectID().Hex(), Name: "Mouse", Price: money.New(1000, money.Default), Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.DecrementStock(ctx, p.ID, 2); err != nil {
+			return err
+		}
+		// the second take fails and the first is undone with it
+		_, err := repo.DecrementStock(ctx, p.ID, 4)
+		return err
+	})
+	if !errors.Is(err, products.ErrInsufficientStock) {
+		t.Fatalf("WithinTx = %v, want %v", err, products.ErrInsufficientStock)
+	}
+	got, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock != 5 {
+		t.Errorf("stock = %d after rollback, want 5", got.Stock)
+	}
+}
+
+// seedCatalog stocks two mice and two keyboards with prices, stock, reviews
+// and sales to filter on. It returns product names by ID and the category IDs.
+func seedCatalog(t *testing.T, repo *memrepo.ProductsRepo) (names map[string]string, mice, keyboards string) {
+	t.Helper()
+	ctx := context.Background()
+	mice, keyboards = primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+
+	type seed struct {
+		name     string
+		category string
+		price    int64
+		stock    int64
+		ratings  []int64
+		sold     int64
+	}
+	names = map[string]string{}
+	for _, s := range []seed{
+		{"Basic Mouse", mice, 1500, 10, []int64{3}, 40},
+		{"Gaming Mouse", mice, 6000, 0, []int64{5, 4}, 25},
+		{"Office Keyboard", keyboards, 4000, 3, nil, 60},
+		{"Mechanical Keyboard", keyboards, 9000, 1, []int64{5}, 5},
+	} {
+		p, err := repo.Create(ctx, products.Product{CategoryID: s.category, Name: s.name, Price: money.New(s.price, money.Default), Stock: s.stock})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rating := range s.ratings {
+			if _, err := repo.AddReview(ctx, p.ID, products.Review{Rating: rating}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repo.AddSold(ctx, p.ID, s.sold); err != nil {
+			t.Fatal(err)
+		}
+		names[p.ID] = s.name
+	}
+	return names, mice, keyboards
+}
+
+func TestProductsRepoFilterAndSort(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.NewProductsRepo()
+	names, mice, keyboards := seedCatalog(t, repo)
+
+	kzt := func(minor int64) *money.Money {
+		m := money.New(minor, money.Default)
+		return &m
+	}
+	rating := func(r float64) *float64 { return &r }
+
+	tests := []struct {
+		name string
+		f    products.ListFilter
+		want []string
+	}{
+		{"price range, cheapest first", products.ListFilter{MinPrice: kzt(1500), MaxPrice: kzt(6000), SortBy: products.SortByPrice, SortAsc: true},
+			[]string{"Basic Mouse", "Office Keyboard", "Gaming Mouse"}},
+		{"in stock only, best selling", products.ListFilter{InStockOnly: true, SortBy: products.SortBySold},
+			[]string{"Office Keyboard", "Basic Mouse", "Mechanical Keyboard"}},
+		// unreviewed products never match a minimum rating
+		{"min rating, best rated", products.ListFilter{MinRating: rating(4), SortBy: products.SortByRating},
+			[]string{"Mechanical Keyboard", "Gaming Mouse"}},
+		{"any of several categories", products.ListFilter{CategoryIDs: []string{keyboards}, SortBy: products.SortByPrice},
+			[]string{"Mechanical Keyboard", "Office Keyboard"}},
+		{"newest first by default", products.ListFilter{CategoryIDs: []string{mice, keyboards}},
+			[]string{"Mechanical Keyboard", "Office Keyboard", "Gaming Mouse", "Basic Mouse"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := repo.List(ctx, tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(list))
+			for _, p := range list {
+				got = append(got, names[p.ID])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.List(ctx, products.ListFilter{CategoryIDs: []string{"not-an-id"}}); !errors.Is(err, products.ErrInvalidCategory) {
+		t.Errorf("List with a malformed category = %v, want %v", err, products.ErrInvalidCategory)
+	}
+}
+
+func TestProductsRepoFacets(t *testing.T) {
+	repo := memrepo.NewProductsRepo()
+	_, mice, keyboards := seedCatalog(t, repo)
+
+	f := products.ListFilter{CategoryIDs: []string{mice}, InStockOnly: true, Limit: 1}
+	got, err := repo.Facets(context.Background(), f, []money.Money{money.New(5000, money.Default)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the basic mouse is a mouse in stock
+	if got.Total != 1 {
+		t.Errorf("total = %d, want 1", got.Total)
+	}
+	// every category, still in stock only
+	wantCategories := []products.CategoryCount{{CategoryID: keyboards, Count: 2}, {CategoryID: mice, Count: 1}}
+	if !reflect.DeepEqual(got.Categories, wantCategories) {
+		t.Errorf("categories = %+v, want %+v", got.Categories, wantCategories)
+	}
+	if len(got.Prices) != 2 || got.Prices[0].Count != 1 || got.Prices[1].Count != 0 {
+		t.Errorf("prices = %+v, want 1 under 50 and 0 above", got.Prices)
+	}
+	var ratings []int64
+	for _, b := range got.Ratings {
+		ratings = append(ratings, b.Count)
+	}
+	if want := []int64{0, 1, 1, 1}; !reflect.DeepEqual(ratings, want) {
+		t.Errorf("ratings 4+..1+ = %v, want %v", ratings, want)
+	}
+	// every mouse, in stock or not
+	if got.InStock != 1 || got.OutOfStock != 1 {
+		t.Errorf("in stock %d, out of stock %d; want 1 and 1", got.InStock, got.OutOfStock)
+	}
+}
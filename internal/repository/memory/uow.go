@@ -0,0 +1,66 @@
+// Package memrepo holds in-memory repositories for tests and local tools.
+// They keep the contracts of the Mongo repositories, including search and
+// error semantics, but no data survives the process.
+package memrepo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+// snapshotter is a memory repo whose state a UnitOfWork can roll back. The
+// returned func restores the state as of the snapshot.
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// UnitOfWork runs callbacks one at a time over the memory repos it was given,
+// restoring their state if the callback fails. Callbacks on other goroutines
+// wait, so the repos never see a half-applied unit of work.
+type UnitOfWork struct {
+	mu    sync.Mutex
+	repos []snapshotter
+}
+
+func NewUnitOfWork(repos ...snapshotter) *UnitOfWork {
+	return &UnitOfWork{repos: repos}
+}
+
+var _ uow.UnitOfWork = (*UnitOfWork)(nil)
+
+type txKey struct{}
+
+func (u *UnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// already inside a unit of work: join it instead of deadlocking on mu
+	if ctx.Value(txKey{}) == u {
+		return fn(ctx)
+	}
+
+	runHooks, err := u.run(ctx, fn)
+	if err != nil {
+		return err
+	}
+	// hooks may start units of work of their own, so they run unlocked
+	runHooks(ctx)
+	return nil
+}
+
+func (u *UnitOfWork) run(ctx context.Context, fn func(ctx context.Context) error) (func(ctx context.Context), error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	restores := make([]func(), 0, len(u.repos))
+	for _, r := range u.repos {
+		restores = append(restores, r.snapshot())
+	}
+	hctx, runHooks := uow.WithHooks(context.WithValue(ctx, txKey{}, u))
+	if err := fn(hctx); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return nil, err
+	}
+	return runHooks, nil
+}
@@ -0,0 +1,118 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// counter is the smallest snapshotter: one value a unit of work can roll back.
+type counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *counter) add(d int) {
+	c.mu.Lock()
+	c.n += d
+	c.mu.Unlock()
+}
+
+func (c *counter) get() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func (c *counter) snapshot() func() {
+	saved := c.get()
+	return func() {
+		c.mu.Lock()
+		c.n = saved
+		c.mu.Unlock()
+	}
+}
+
+func TestUnitOfWorkCommits(t *testing.T) {
+	c := &counter{}
+	u := NewUnitOfWork(c)
+
+	err := u.WithinTx(context.Background(), func(ctx context.Context) error {
+		c.add(2)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.get(); got != 2 {
+		t.Errorf("n = %d, want 2", got)
+	}
+}
+
+func TestUnitOfWorkRollsBack(t *testing.T) {
+	c, other := &counter{n: 1}, &counter{n: 10}
+	u := NewUnitOfWork(c, other)
+	boom := errors.New("third decrement failed")
+
+	err := u.WithinTx(context.Background(), func(ctx context.Context) error {
+		c.add(5)
+		other.add(-3)
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithinTx = %v, want %v", err, boom)
+	}
+	if c.get() != 1 || other.get() != 10 {
+		t.Errorf("after rollback n = %d and %d, want 1 and 10", c.get(), other.get())
+	}
+}
+
+func TestUnitOfWorkJoinsOuter(t *testing.T) {
+	c := &counter{}
+	u := NewUnitOfWork(c)
+	boom := errors.New("outer failed")
+
+	err := u.WithinTx(context.Background(), func(ctx context.Context) error {
+		// a nested call joins the outer unit of work instead of deadlocking,
+		// and its changes go when the outer one rolls back
+		if err := u.WithinTx(ctx, func(ctx context.Context) error {
+			c.add(1)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithinTx = %v, want %v", err, boom)
+	}
+	if got := c.get(); got != 0 {
+		t.Errorf("n = %d, want 0", got)
+	}
+}
+
+func TestUnitOfWorkSerializes(t *testing.T) {
+	c := &counter{}
+	u := NewUnitOfWork(c)
+
+	// each callback reads, then writes; interleaved callbacks would lose updates
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = u.WithinTx(context.Background(), func(ctx context.Context) error {
+				n := c.get()
+				c.mu.Lock()
+				c.n = n + 1
+				c.mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if got := c.get(); got != 50 {
+		t.Errorf("n = %d, want 50", got)
+	}
+}
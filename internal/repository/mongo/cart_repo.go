@@ -0,0 +1,135 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/cart"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CartRepo struct {
+	col *mongo.Collection
+}
+
+func NewCartRepo(db *mongo.Database) *CartRepo {
+	return &CartRepo{col: db.Collection("carts")}
+}
+
+type cartItemDoc struct {
+	ProductID primitive.ObjectID `bson:"productId"`
+	Quantity  int64              `bson:"quantity"`
+	AddedAt   time.Time          `bson:"addedAt"`
+}
+
+type cartDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"userId"`
+	Items     []cartItemDoc      `bson:"items"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+func (r *CartRepo) EnsureIndexes(ctx context.Context) error {
+	// one cart per user
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *CartRepo) Get(ctx context.Context, userID string) (cart.Cart, error) {
+	var d cartDoc
+	if err := r.col.FindOne(ctx, bson.M{"userId": userID}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return cart.Cart{UserID: userID, Items: []cart.Item{}}, nil
+		}
+		return cart.Cart{}, fmt.Errorf("find cart: %w", err)
+	}
+
+	out := cart.Cart{
+		UserID:    d.UserID,
+		Items:     make([]cart.Item, 0, len(d.Items)),
+		UpdatedAt: d.UpdatedAt,
+	}
+	for _, it := range d.Items {
+		out.Items = append(out.Items, cart.Item{
+			ProductID: it.ProductID.Hex(),
+			Quantity:  it.Quantity,
+			AddedAt:   it.AddedAt,
+		})
+	}
+	return out, nil
+}
+
+func (r *CartRepo) SetItem(ctx context.Context, userID string, item cart.Item) error {
+	pid, err := primitive.ObjectIDFromHex(item.ProductID)
+	if err != nil {
+		return cart.ErrInvalidProduct
+	}
+	now := time.Now().UTC()
+
+	// existing line: update its quantity in place
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"userId": userID, "items.productId": pid},
+		bson.M{"$set": bson.M{"items.$.quantity": item.Quantity, "updatedAt": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("update cart item: %w", err)
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	// new line: push it, creating the cart on first use
+	_, err = r.col.UpdateOne(ctx,
+		bson.M{"userId": userID},
+		bson.M{
+			"$push": bson.M{"items": cartItemDoc{ProductID: pid, Quantity: item.Quantity, AddedAt: item.AddedAt}},
+			"$set":  bson.M{"updatedAt": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("push cart item: %w", err)
+	}
+	return nil
+}
+
+func (r *CartRepo) RemoveItem(ctx context.Context, userID string, productID string) error {
+	pid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return cart.ErrInvalidProduct
+	}
+
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"userId": userID, "items.productId": pid},
+		bson.M{
+			"$pull": bson.M{"items": bson.M{"productId": pid}},
+			"$set":  bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("pull cart item: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return cart.ErrItemNotFound
+	}
+	return nil
+}
+
+func (r *CartRepo) Clear(ctx context.Context, userID string) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"userId": userID},
+		bson.M{"$set": bson.M{"items": []cartItemDoc{}, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		return fmt.Errorf("clear cart: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CurrenciesRepo struct {
+	col *mongo.Collection
+}
+
+func NewCurrenciesRepo(db *mongo.Database) *CurrenciesRepo {
+	return &CurrenciesRepo{col: db.Collection("exchange_rates")}
+}
+
+// exchangeRateDoc is keyed by currency code; there is one rate per currency.
+type exchangeRateDoc struct {
+	Currency  string    `bson:"_id"`
+	Base      string    `bson:"base"`
+	Rate      float64   `bson:"rate"`
+	UpdatedBy string    `bson:"updatedBy"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+func (r *CurrenciesRepo) List(ctx context.Context, base money.Currency) ([]currencies.Rate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cur, err := r.col.Find(ctx, bson.M{"base": string(base)}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find exchange rates: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []exchangeRateDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode exchange rates: %w", err)
+	}
+
+	out := make([]currencies.Rate, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapExchangeRateDoc(d))
+	}
+	return out, nil
+}
+
+func (r *CurrenciesRepo) Get(ctx context.Context, base, c money.Currency) (currencies.Rate, error) {
+	var d exchangeRateDoc
+	err := r.col.FindOne(ctx, bson.M{"_id": string(c), "base": string(base)}).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return currencies.Rate{}, currencies.ErrNotFound
+		}
+		return currencies.Rate{}, fmt.Errorf("find exchange rate: %w", err)
+	}
+	return mapExchangeRateDoc(d), nil
+}
+
+func (r *CurrenciesRepo) Upsert(ctx context.Context, base money.Currency, rt currencies.Rate) (currencies.Rate, error) {
+	doc := exchangeRateDoc{
+		Currency:  string(rt.Currency),
+		Base:      string(base),
+		Rate:      rt.Rate,
+		UpdatedBy: rt.UpdatedBy,
+		UpdatedAt: rt.UpdatedAt,
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.col.ReplaceOne(ctx, bson.M{"_id": doc.Currency}, doc, opts); err != nil {
+		return currencies.Rate{}, fmt.Errorf("upsert exchange rate: %w", err)
+	}
+	return rt, nil
+}
+
+func (r *CurrenciesRepo) Delete(ctx context.Context, base, c money.Currency) error {
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": string(c), "base": string(base)})
+	if err != nil {
+		return fmt.Errorf("delete exchange rate: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return currencies.ErrNotFound
+	}
+	return nil
+}
+
+func mapExchangeRateDoc(d exchangeRateDoc) currencies.Rate {
+	return currencies.Rate{
+		Currency:  money.Currency(d.Currency),
+		Rate:      d.Rate,
+		UpdatedBy: d.UpdatedBy,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
@@ -0,0 +1,170 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/delivery"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeliveryRepo struct {
+	col  *mongo.Collection
+	base money.Currency
+}
+
+// NewDeliveryRepo builds the repo. Amounts stored without a currency, from
+// before amounts carried one, are read as base.
+func NewDeliveryRepo(db *mongo.Database, base money.Currency) *DeliveryRepo {
+	return &DeliveryRepo{col: db.Collection("delivery_methods"), base: base}
+}
+
+type deliveryMethodDoc struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Name          string             `bson:"name"`
+	Description   string             `bson:"description,omitempty"`
+	Fee           decimalAmount      `bson:"fee"`
+	Currency      string             `bson:"currency,omitempty"`
+	EstimatedDays int64              `bson:"estimatedDays"`
+	Active        bool               `bson:"active"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt"`
+}
+
+func (r *DeliveryRepo) List(ctx context.Context, f delivery.ListFilter) ([]delivery.Method, error) {
+	filter := bson.M{}
+	if f.ActiveOnly {
+		filter["active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "fee", Value: 1}, {Key: "name", Value: 1}})
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find delivery methods: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []deliveryMethodDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode delivery methods: %w", err)
+	}
+
+	out := make([]delivery.Method, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapDeliveryMethodDoc(d, r.base))
+	}
+	return out, nil
+}
+
+func (r *DeliveryRepo) GetByID(ctx context.Context, id string) (delivery.Method, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return delivery.Method{}, delivery.ErrInvalidID
+	}
+
+	var d deliveryMethodDoc
+	if err := r.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return delivery.Method{}, delivery.ErrNotFound
+		}
+		return delivery.Method{}, fmt.Errorf("find delivery method: %w", err)
+	}
+	return mapDeliveryMethodDoc(d, r.base), nil
+}
+
+func (r *DeliveryRepo) Create(ctx context.Context, m delivery.Method) (delivery.Method, error) {
+	doc := deliveryMethodDoc{
+		ID:            primitive.NewObjectID(),
+		Name:          m.Name,
+		Description:   m.Description,
+		Fee:           toDecimal(m.Fee),
+		Currency:      string(m.Fee.Currency),
+		EstimatedDays: m.EstimatedDays,
+		Active:        m.Active,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		return delivery.Method{}, fmt.Errorf("insert delivery method: %w", err)
+	}
+
+	m.ID = doc.ID.Hex()
+	return m, nil
+}
+
+func (r *DeliveryRepo) Update(ctx context.Context, id string, in delivery.UpdateInput) (delivery.Method, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return delivery.Method{}, delivery.ErrInvalidID
+	}
+
+	set := bson.M{
+		"updatedAt": time.Now().UTC(),
+	}
+	if in.Name != nil {
+		set["name"] = *in.Name
+	}
+	if in.Description != nil {
+		set["description"] = *in.Description
+	}
+	if in.Fee != nil {
+		set["fee"] = toDecimal(*in.Fee)
+		set["currency"] = string(in.Fee.Currency)
+	}
+	if in.EstimatedDays != nil {
+		set["estimatedDays"] = *in.EstimatedDays
+	}
+	if in.Active != nil {
+		set["active"] = *in.Active
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d deliveryMethodDoc
+	err = r.col.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$set": set}, opts).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return delivery.Method{}, delivery.ErrNotFound
+		}
+		return delivery.Method{}, fmt.Errorf("update delivery method: %w", err)
+	}
+
+	return mapDeliveryMethodDoc(d, r.base), nil
+}
+
+func (r *DeliveryRepo) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return delivery.ErrInvalidID
+	}
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("delete delivery method: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return delivery.ErrNotFound
+	}
+	return nil
+}
+
+func mapDeliveryMethodDoc(d deliveryMethodDoc, base money.Currency) delivery.Method {
+	return delivery.Method{
+		ID:            d.ID.Hex(),
+		Name:          d.Name,
+		Description:   d.Description,
+		Fee:           d.Fee.toMoney(d.Currency, base),
+		EstimatedDays: d.EstimatedDays,
+		Active:        d.Active,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/idempotency"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepo struct {
+	col *mongo.Collection
+}
+
+func NewIdempotencyRepo(db *mongo.Database) *IdempotencyRepo {
+	return &IdempotencyRepo{col: db.Collection("idempotency_keys")}
+}
+
+type idempotencyDoc struct {
+	UserID      string    `bson:"userId"`
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"requestHash"`
+	Completed   bool      `bson:"completed"`
+	StatusCode  int       `bson:"statusCode,omitempty"`
+	ContentType string    `bson:"contentType,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+func (r *IdempotencyRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	// Mongo removes expired keys in the background (roughly once a minute);
+	// reads also filter on expiresAt so a late sweep never replays a stale response.
+	_, err = r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *IdempotencyRepo) Reserve(ctx context.Context, rec idempotency.Record) error {
+	doc := idempotencyDoc{
+		UserID:      rec.UserID,
+		Key:         rec.Key,
+		RequestHash: rec.RequestHash,
+		CreatedAt:   rec.CreatedAt,
+		ExpiresAt:   rec.ExpiresAt,
+	}
+
+	// Only an expired record matches the filter and gets overwritten. A live one
+	// doesn't match, so the upsert tries to insert and hits the unique index.
+	filter := bson.M{
+		"userId":    rec.UserID,
+		"key":       rec.Key,
+		"expiresAt": bson.M{"$lte": rec.CreatedAt},
+	}
+	opts := options.Replace().SetUpsert(true)
+
+	if _, err := r.col.ReplaceOne(ctx, filter, doc, opts); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return idempotency.ErrKeyExists
+		}
+		return fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Get(ctx context.Context, userID, key string) (idempotency.Record, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"key":       key,
+		"expiresAt": bson.M{"$gt": time.Now().UTC()},
+	}
+
+	var d idempotencyDoc
+	if err := r.col.FindOne(ctx, filter).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return idempotency.Record{}, idempotency.ErrNotFound
+		}
+		return idempotency.Record{}, fmt.Errorf("find idempotency key: %w", err)
+	}
+
+	return idempotency.Record{
+		UserID:      d.UserID,
+		Key:         d.Key,
+		RequestHash: d.RequestHash,
+		Completed:   d.Completed,
+		StatusCode:  d.StatusCode,
+		ContentType: d.ContentType,
+		Body:        d.Body,
+		CreatedAt:   d.CreatedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}, nil
+}
+
+func (r *IdempotencyRepo) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error {
+	update := bson.M{"$set": bson.M{
+		"completed":   true,
+		"statusCode":  statusCode,
+		"contentType": contentType,
+		"body":        body,
+	}}
+
+	res, err := r.col.UpdateOne(ctx, bson.M{"userId": userID, "key": key}, update)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return idempotency.ErrNotFound
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Release(ctx context.Context, userID, key string) error {
+	_, err := r.col.DeleteOne(ctx, bson.M{"userId": userID, "key": key, "completed": false})
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invoiceCounterID is the counters document holding the last invoice number.
+const invoiceCounterID = "invoices"
+
+type InvoicesRepo struct {
+	col      *mongo.Collection
+	counters *mongo.Collection
+}
+
+func NewInvoicesRepo(db *mongo.Database) *InvoicesRepo {
+	return &InvoicesRepo{
+		col:      db.Collection("invoices"),
+		counters: db.Collection("counters"),
+	}
+}
+
+type invoiceDoc struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID  primitive.ObjectID `bson:"orderId"`
+	UserID   string             `bson:"userId"`
+	Number   int64              `bson:"number"`
+	IssuedAt time.Time          `bson:"issuedAt"`
+}
+
+func (r *InvoicesRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// one invoice per order
+		{Keys: bson.D{{Key: "orderId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "number", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+func (r *InvoicesRepo) GetByOrderID(ctx context.Context, orderID string) (invoices.Invoice, error) {
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return invoices.Invoice{}, invoices.ErrNotFound
+	}
+
+	var d invoiceDoc
+	err = r.col.FindOne(ctx, bson.M{"orderId": oid}).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return invoices.Invoice{}, invoices.ErrNotFound
+		}
+		return invoices.Invoice{}, fmt.Errorf("find invoice: %w", err)
+	}
+
+	return mapInvoiceDoc(d), nil
+}
+
+func (r *InvoicesRepo) NextNumber(ctx context.Context) (int64, error) {
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var d struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": invoiceCounterID},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		opts,
+	).Decode(&d)
+	if err != nil {
+		return 0, fmt.Errorf("next invoice number: %w", err)
+	}
+
+	return d.Seq, nil
+}
+
+func (r *InvoicesRepo) Create(ctx context.Context, inv invoices.Invoice) (invoices.Invoice, error) {
+	orderOID, err := primitive.ObjectIDFromHex(inv.OrderID)
+	if err != nil {
+		return invoices.Invoice{}, invoices.ErrNotFound
+	}
+
+	doc := invoiceDoc{
+		ID:       primitive.NewObjectID(),
+		OrderID:  orderOID,
+		UserID:   inv.UserID,
+		Number:   inv.Number,
+		IssuedAt: inv.IssuedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return invoices.Invoice{}, invoices.ErrExists
+		}
+		return invoices.Invoice{}, fmt.Errorf("insert invoice: %w", err)
+	}
+
+	return mapInvoiceDoc(doc), nil
+}
+
+func mapInvoiceDoc(d invoiceDoc) invoices.Invoice {
+	return invoices.Invoice{
+		ID:       d.ID.Hex(),
+		OrderID:  d.OrderID.Hex(),
+		UserID:   d.UserID,
+		Number:   d.Number,
+		IssuedAt: d.IssuedAt,
+	}
+}
@@ -0,0 +1,325 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrations holds one-off data migrations run via cmd/migrate.
+type Migrations struct {
+	ordersCol     *mongo.Collection
+	productsCol   *mongo.Collection
+	deliveryCol   *mongo.Collection
+	promotionsCol *mongo.Collection
+	paymentsCol   *mongo.Collection
+	returnsCol    *mongo.Collection
+	stock         *StockRepo
+	base          money.Currency
+}
+
+// NewMigrations builds the migrations. Amounts without a currency are taken
+// to be in base.
+func NewMigrations(db *mongo.Database, base money.Currency) *Migrations {
+	return &Migrations{
+		ordersCol:     db.Collection("orders"),
+		productsCol:   db.Collection("products"),
+		deliveryCol:   db.Collection("delivery_methods"),
+		promotionsCol: db.Collection("promotions"),
+		paymentsCol:   db.Collection("payments"),
+		returnsCol:    db.Collection("returns"),
+		stock:         NewStockRepo(db),
+		base:          base,
+	}
+}
+
+// BackfillOrderPriceSnapshots fills productName/unitPrice/lineTotal/totalPrice on
+// orders created before prices were snapshotted at checkout. It uses the current
+// product price, which is the best information left for those orders.
+// Returns the number of updated orders.
+func (m *Migrations) BackfillOrderPriceSnapshots(ctx context.Context) (int64, error) {
+	cur, err := m.ordersCol.Find(ctx, bson.M{"totalPrice": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, fmt.Errorf("find orders without snapshot: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	productCache := make(map[primitive.ObjectID]productDoc, 128)
+	var updated int64
+
+	for cur.Next(ctx) {
+		var d orderDoc
+		if err := cur.Decode(&d); err != nil {
+			return updated, fmt.Errorf("decode order: %w", err)
+		}
+
+		total := money.Zero(m.base)
+		for i := range d.Items {
+			pid := d.Items[i].ProductID
+
+			p, ok := productCache[pid]
+			if !ok {
+				if err := m.productsCol.FindOne(ctx, bson.M{"_id": pid}).Decode(&p); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+					return updated, fmt.Errorf("find product %s: %w", pid.Hex(), err)
+				}
+				// deleted products keep a zero price; there is nothing better to record
+				productCache[pid] = p
+			}
+
+			d.Items[i].ProductName = p.Name
+			price := p.Price.toMoney(p.Currency, m.base)
+			line := price.Mul(d.Items[i].Quantity)
+			d.Items[i].UnitPrice = toDecimal(price)
+			d.Items[i].LineTotal = toDecimal(line)
+			total = total.Add(line)
+		}
+
+		_, err := m.ordersCol.UpdateOne(ctx,
+			bson.M{"_id": d.ID},
+			bson.M{"$set": bson.M{
+				"items":      d.Items,
+				"totalPrice": toDecimal(total),
+				"currency":   string(total.Currency),
+			}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("update order %s: %w", d.ID.Hex(), err)
+		}
+		updated++
+	}
+	if err := cur.Err(); err != nil {
+		return updated, fmt.Errorf("iterate orders: %w", err)
+	}
+
+	return updated, nil
+}
+
+// MoneyToDecimal rewrites amounts stored as doubles to Decimal128, rounded to
+// whole minor units, and records the base currency as the currency. Documents that
+// already carry a currency were written by the current code and are skipped.
+// Returns the number of updated documents across all collections.
+func (m *Migrations) MoneyToDecimal(ctx context.Context) (int64, error) {
+	steps := []struct {
+		col *mongo.Collection
+		set func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error)
+	}{
+		{m.productsCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d productDoc
+			err := cur.Decode(&d)
+			return d.ID, bson.M{"price": roundAmount(d.Price)}, err
+		}},
+		{m.deliveryCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d deliveryMethodDoc
+			err := cur.Decode(&d)
+			return d.ID, bson.M{"fee": roundAmount(d.Fee)}, err
+		}},
+		{m.promotionsCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d promotionDoc
+			err := cur.Decode(&d)
+			return d.ID, bson.M{"minOrderTotal": roundAmount(d.MinOrderTotal)}, err
+		}},
+		{m.ordersCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d orderDoc
+			if err := cur.Decode(&d); err != nil {
+				return d.ID, nil, err
+			}
+			set := bson.M{"totalPrice": roundAmount(d.TotalPrice)}
+			if len(d.Items) > 0 {
+				for i := range d.Items {
+					d.Items[i].UnitPrice = roundAmount(d.Items[i].UnitPrice)
+					d.Items[i].LineTotal = roundAmount(d.Items[i].LineTotal)
+				}
+				set["items"] = d.Items
+			}
+			if d.Delivery != nil {
+				set["delivery.fee"] = roundAmount(d.Delivery.Fee)
+			}
+			if len(d.Discounts) > 0 {
+				for i := range d.Discounts {
+					d.Discounts[i].Amount = roundAmount(d.Discounts[i].Amount)
+				}
+				set["discounts"] = d.Discounts
+			}
+			if len(d.Refunds) > 0 {
+				for i := range d.Refunds {
+					d.Refunds[i].Amount = roundAmount(d.Refunds[i].Amount)
+				}
+				set["refunds"] = d.Refunds
+			}
+			return d.ID, set, nil
+		}},
+		{m.paymentsCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d paymentDoc
+			err := cur.Decode(&d)
+			return d.ID, bson.M{"amount": roundAmount(d.Amount)}, err
+		}},
+		{m.returnsCol, func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error) {
+			var d returnDoc
+			if err := cur.Decode(&d); err != nil {
+				return d.ID, nil, err
+			}
+			set := bson.M{"refundAmount": roundAmount(d.RefundAmount)}
+			if len(d.Items) > 0 {
+				for i := range d.Items {
+					d.Items[i].UnitPrice = roundAmount(d.Items[i].UnitPrice)
+					d.Items[i].RefundAmount = roundAmount(d.Items[i].RefundAmount)
+				}
+				set["items"] = d.Items
+			}
+			return d.ID, set, nil
+		}},
+	}
+
+	var updated int64
+	for _, st := range steps {
+		n, err := rewriteAmounts(ctx, st.col, m.base, st.set)
+		updated += n
+		if err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// StockOpeningBalances writes an import movement for every product whose
+// stock isn't explained by its ledger, so stock set before the ledger existed
+// reconciles. Run it once when the ledger is introduced; later drift should
+// be investigated, not papered over. Returns the number of movements written.
+func (m *Migrations) StockOpeningBalances(ctx context.Context) (int64, error) {
+	recs, err := m.stock.Reconcile(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var n int64
+	for _, r := range recs {
+		if r.Balanced() {
+			continue
+		}
+		err := m.stock.Append(ctx, stock.Movement{
+			ProductID: r.ProductID,
+			Delta:     r.Drift(),
+			Reason:    stock.ReasonImport,
+			Note:      "opening balance",
+			CreatedAt: now,
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ProductListFields fills the rating summary and units sold that product
+// listings filter and sort on: ratings from the embedded reviews, sold from
+// the items of orders that weren't cancelled less restocked returns. Both are
+// recomputed from scratch, so re-running it also repairs drift; run it while
+// no orders are being placed. Returns the number of products changed.
+func (m *Migrations) ProductListFields(ctx context.Context) (int64, error) {
+	res, err := m.productsCol.UpdateMany(ctx, bson.M{},
+		mongo.Pipeline{{{Key: "$set", Value: ratingFields}}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("backfill ratings: %w", err)
+	}
+	updated := res.ModifiedCount
+
+	cur, err := m.ordersCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$ne": "cancelled"}}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$project", Value: bson.M{"productId": "$items.productId", "quantity": "$items.quantity"}}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll": m.returnsCol.Name(),
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"restocked": true}}},
+				{{Key: "$unwind", Value: "$items"}},
+				{{Key: "$project", Value: bson.M{
+					"productId": "$items.productId",
+					"quantity":  bson.M{"$multiply": bson.A{-1, "$items.quantity"}},
+				}}},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":  "$productId",
+			"sold": bson.M{"$sum": "$quantity"},
+		}}},
+	})
+	if err != nil {
+		return updated, fmt.Errorf("aggregate sold: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var sold []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Sold      int64              `bson:"sold"`
+	}
+	if err := cur.All(ctx, &sold); err != nil {
+		return updated, fmt.Errorf("decode sold: %w", err)
+	}
+
+	counted := make([]primitive.ObjectID, 0, len(sold))
+	for _, s := range sold {
+		res, err := m.productsCol.UpdateOne(ctx,
+			bson.M{"_id": s.ProductID},
+			bson.M{"$set": bson.M{"sold": s.Sold}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("set sold %s: %w", s.ProductID.Hex(), err)
+		}
+		updated += res.ModifiedCount
+		counted = append(counted, s.ProductID)
+	}
+
+	// products that were never ordered
+	res, err = m.productsCol.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$nin": counted}, "sold": bson.M{"$ne": 0}},
+		bson.M{"$set": bson.M{"sold": 0}},
+	)
+	if err != nil {
+		return updated, fmt.Errorf("reset sold: %w", err)
+	}
+	return updated + res.ModifiedCount, nil
+}
+
+func rewriteAmounts(ctx context.Context, col *mongo.Collection, base money.Currency, set func(cur *mongo.Cursor) (primitive.ObjectID, bson.M, error)) (int64, error) {
+	cur, err := col.Find(ctx, bson.M{"currency": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, fmt.Errorf("find %s without currency: %w", col.Name(), err)
+	}
+	defer cur.Close(ctx)
+
+	var updated int64
+	for cur.Next(ctx) {
+		id, fields, err := set(cur)
+		if err != nil {
+			return updated, fmt.Errorf("decode %s: %w", col.Name(), err)
+		}
+		fields["currency"] = string(base)
+
+		if _, err := col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields}); err != nil {
+			return updated, fmt.Errorf("update %s %s: %w", col.Name(), id.Hex(), err)
+		}
+		updated++
+	}
+	if err := cur.Err(); err != nil {
+		return updated, fmt.Errorf("iterate %s: %w", col.Name(), err)
+	}
+
+	return updated, nil
+}
+
+// roundAmount re-encodes a, whatever BSON type it was read from, as Decimal128
+// in whole minor units.
+func roundAmount(a decimalAmount) decimalAmount {
+	// the currency doesn't change the stored amount
+	return toDecimal(a.toMoney("", ""))
+}
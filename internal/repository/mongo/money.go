@@ -0,0 +1,97 @@
+package mongorepo
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// decimalAmount is a money amount as stored in Mongo: a Decimal128 in major
+// units (49.99, not 4999), so values are exact, still read naturally in the
+// shell, and sort and aggregate as numbers. The currency lives in a sibling
+// field of the document.
+//
+// Documents written before amounts were Decimal128 hold doubles; those are
+// still read, and cmd/migrate money-decimal128 rewrites them.
+type decimalAmount struct {
+	v primitive.Decimal128
+}
+
+// minorExp is the decimal exponent of one minor unit.
+const minorExp = -2
+
+func toDecimal(m money.Money) decimalAmount {
+	d, ok := primitive.ParseDecimal128FromBigInt(big.NewInt(m.Amount), minorExp)
+	if !ok {
+		// an int64 with two decimals always fits in 34 digits
+		panic("mongorepo: amount out of Decimal128 range")
+	}
+	return decimalAmount{v: d}
+}
+
+// toMoney rounds the stored value half away from zero to whole minor units.
+// cur is the document's currency field; documents from before amounts
+// carried a currency have none and were entered in base.
+func (a decimalAmount) toMoney(cur string, base money.Currency) money.Money {
+	c := money.Currency(cur)
+	if c == "" {
+		c = base
+	}
+
+	bi, exp, err := a.v.BigInt()
+	// NaN or infinity never come from this app
+	if err != nil || bi.Sign() == 0 {
+		return money.Zero(c)
+	}
+
+	switch shift := exp - minorExp; {
+	case shift > 0:
+		bi.Mul(bi, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+	case shift < 0:
+		den := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-shift)), nil)
+		q, r := new(big.Int).QuoRem(bi, den, new(big.Int))
+		if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+			if bi.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+		bi = q
+	}
+
+	return money.New(bi.Int64(), c)
+}
+
+func (a decimalAmount) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(a.v)
+}
+
+func (a *decimalAmount) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	rv := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeDecimal128:
+		a.v = rv.Decimal128()
+	case bson.TypeDouble:
+		// the shortest decimal that round-trips, so 49.99 reads as exactly 49.99
+		d, err := primitive.ParseDecimal128(strconv.FormatFloat(rv.Double(), 'f', -1, 64))
+		if err != nil {
+			return fmt.Errorf("amount %v: %w", rv.Double(), err)
+		}
+		a.v = d
+	case bson.TypeInt32:
+		a.v, _ = primitive.ParseDecimal128FromBigInt(big.NewInt(int64(rv.Int32())), 0)
+	case bson.TypeInt64:
+		a.v, _ = primitive.ParseDecimal128FromBigInt(big.NewInt(rv.Int64()), 0)
+	case bson.TypeNull, bson.TypeUndefined:
+		a.v = primitive.Decimal128{}
+	default:
+		return fmt.Errorf("amount: unexpected BSON type %s", t)
+	}
+	return nil
+}
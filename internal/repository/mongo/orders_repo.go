@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,39 +16,134 @@ import (
 )
 
 type OrdersRepo struct {
-	col *mongo.Collection
+	col  *mongo.Collection
+	base money.Currency
 }
 
-func NewOrdersRepo(db *mongo.Database) *OrdersRepo {
-	return &OrdersRepo{col: db.Collection("orders")}
+// NewOrdersRepo builds the repo. Amounts stored without a currency, from
+// before amounts carried one, are read as base.
+func NewOrdersRepo(db *mongo.Database, base money.Currency) *OrdersRepo {
+	return &OrdersRepo{col: db.Collection("orders"), base: base}
 }
 
 type orderItemDoc struct {
+	ProductID   primitive.ObjectID `bson:"productId"`
+	ProductName string             `bson:"productName"`
+	Quantity    int64              `bson:"quantity"`
+	UnitPrice   decimalAmount      `bson:"unitPrice"`
+	LineTotal   decimalAmount      `bson:"lineTotal"`
+}
+
+type shippingAddressDoc struct {
+	FullName   string `bson:"fullName"`
+	Phone      string `bson:"phone,omitempty"`
+	Line1      string `bson:"line1"`
+	Line2      string `bson:"line2,omitempty"`
+	City       string `bson:"city,omitempty"`
+	Region     string `bson:"region,omitempty"`
+	PostalCode string `bson:"postalCode,omitempty"`
+	Country    string `bson:"country,omitempty"`
+}
+
+type deliveryDoc struct {
+	MethodID primitive.ObjectID `bson:"methodId"`
+	Name     string             `bson:"name"`
+	Fee      decimalAmount      `bson:"fee"`
+}
+
+type discountDoc struct {
+	PromotionID primitive.ObjectID `bson:"promotionId"`
+	Code        string             `bson:"code"`
+	Amount      decimalAmount      `bson:"amount"`
+}
+
+type taxLineDoc struct {
+	RuleID    primitive.ObjectID `bson:"ruleId"`
+	Name      string             `bson:"name"`
+	Rate      float64            `bson:"rate"`
+	Inclusive bool               `bson:"inclusive"`
+	Base      decimalAmount      `bson:"base"`
+	Amount    decimalAmount      `bson:"amount"`
+}
+
+// displayDoc holds the amounts in the currency the customer shopped in.
+type displayDoc struct {
+	Currency   string        `bson:"currency"`
+	Rate       float64       `bson:"rate"`
+	Subtotal   decimalAmount `bson:"subtotal"`
+	TotalPrice decimalAmount `bson:"totalPrice"`
+}
+
+type shipmentItemDoc struct {
 	ProductID primitive.ObjectID `bson:"productId"`
 	Quantity  int64              `bson:"quantity"`
 }
 
-type orderDoc struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	UserID    string             `bson:"userId"`
-	Items     []orderItemDoc     `bson:"items"`
-	Status    string             `bson:"status"`
+type shipmentDoc struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Carrier        string             `bson:"carrier"`
+	TrackingNumber string             `bson:"trackingNumber"`
+	Items          []shipmentItemDoc  `bson:"items"`
+	ShippedAt      time.Time          `bson:"shippedAt"`
+	DeliveredAt    *time.Time         `bson:"deliveredAt,omitempty"`
+	CreatedBy      string             `bson:"createdBy"`
+}
+
+type refundDoc struct {
+	ReturnID  primitive.ObjectID `bson:"returnId,omitempty"`
+	Amount    decimalAmount      `bson:"amount"`
 	CreatedAt time.Time          `bson:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+type statusChangeDoc struct {
+	From      string    `bson:"from,omitempty"`
+	To        string    `bson:"to"`
+	ChangedBy string    `bson:"changedBy"`
+	Note      string    `bson:"note,omitempty"`
+	ChangedAt time.Time `bson:"changedAt"`
+}
+
+type orderDoc struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID          string              `bson:"userId"`
+	Items           []orderItemDoc      `bson:"items"`
+	Status          string              `bson:"status"`
+	PaymentStatus   string              `bson:"paymentStatus,omitempty"`
+	ShippingAddress *shippingAddressDoc `bson:"shippingAddress,omitempty"`
+	Delivery        *deliveryDoc        `bson:"delivery,omitempty"`
+	Discounts       []discountDoc       `bson:"discounts,omitempty"`
+	Taxes           []taxLineDoc        `bson:"taxes,omitempty"`
+	TotalPrice      decimalAmount       `bson:"totalPrice"`
+	// Currency applies to every amount on the order; missing means the base currency.
+	Currency      string            `bson:"currency,omitempty"`
+	Display       *displayDoc       `bson:"display,omitempty"`
+	Refunds       []refundDoc       `bson:"refunds,omitempty"`
+	Shipments     []shipmentDoc     `bson:"shipments,omitempty"`
+	StatusHistory []statusChangeDoc `bson:"statusHistory,omitempty"`
+	CreatedAt     time.Time         `bson:"createdAt"`
+	UpdatedAt     time.Time         `bson:"updatedAt"`
+}
+
+func (r *OrdersRepo) EnsureIndexes(ctx context.Context) error {
+	// Each listing filter leads its own index with the sort key after it, so the
+	// common "filter + newest first" admin queries never sort in memory.
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "items.productId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "totalPrice", Value: 1}}},
+	})
+	return err
 }
 
 func (r *OrdersRepo) List(ctx context.Context, userID *string, f orders.ListFilter) ([]orders.Order, error) {
-	filter := bson.M{}
-	if userID != nil {
-		filter["userId"] = *userID
+	filter, err := orderListFilter(userID, f)
+	if err != nil {
+		return nil, err
 	}
 
-	opts := options.Find().
-		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
-		SetSkip(f.Offset).
-		SetLimit(f.Limit)
-
-	cur, err := r.col.Find(ctx, filter, opts)
+	cur, err := r.col.Find(ctx, filter, orderListOptions(f))
 	if err != nil {
 		return nil, fmt.Errorf("find orders: %w", err)
 	}
@@ -60,11 +156,56 @@ func (r *OrdersRepo) List(ctx context.Context, userID *string, f orders.ListFilt
 
 	out := make([]orders.Order, 0, len(docs))
 	for _, d := range docs {
-		out = append(out, mapOrderDoc(d))
+		out = append(out, mapOrderDoc(d, r.base))
 	}
 	return out, nil
 }
 
+func (r *OrdersRepo) Each(ctx context.Context, userID *string, f orders.ListFilter, fn func(orders.Order) error) error {
+	filter, err := orderListFilter(userID, f)
+	if err != nil {
+		return err
+	}
+
+	cur, err := r.col.Find(ctx, filter, orderListOptions(f).SetBatchSize(500))
+	if err != nil {
+		return fmt.Errorf("find orders: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var d orderDoc
+		if err := cur.Decode(&d); err != nil {
+			return fmt.Errorf("decode order: %w", err)
+		}
+		if err := fn(mapOrderDoc(d, r.base)); err != nil {
+			return err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("iterate orders: %w", err)
+	}
+	return nil
+}
+
+// orderListOptions sorts by f.SortBy with _id as a tie-breaker, so paging
+// and streaming see a stable order.
+func orderListOptions(f orders.ListFilter) *options.FindOptions {
+	field := f.SortBy
+	if field == "" {
+		field = orders.SortByCreatedAt
+	}
+	dir := -1
+	if f.SortAsc {
+		dir = 1
+	}
+
+	return options.Find().
+		SetSort(bson.D{{Key: string(field), Value: dir}, {Key: "_id", Value: dir}}).
+		SetSkip(f.Offset).
+		SetLimit(f.Limit)
+}
+
 func (r *OrdersRepo) GetByID(ctx context.Context, id string, userID *string) (orders.Order, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -84,7 +225,7 @@ func (r *OrdersRepo) GetByID(ctx context.Context, id string, userID *string) (or
 		return orders.Order{}, fmt.Errorf("find order: %w", err)
 	}
 
-	return mapOrderDoc(d), nil
+	return mapOrderDoc(d, r.base), nil
 }
 
 func (r *OrdersRepo) Create(ctx context.Context, o orders.Order) (orders.Order, error) {
@@ -94,16 +235,84 @@ func (r *OrdersRepo) Create(ctx context.Context, o orders.Order) (orders.Order,
 		if err != nil {
 			return orders.Order{}, orders.ErrInvalidProduct
 		}
-		items = append(items, orderItemDoc{ProductID: pid, Quantity: it.Quantity})
+		items = append(items, orderItemDoc{
+			ProductID:   pid,
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+			UnitPrice:   toDecimal(it.UnitPrice),
+			LineTotal:   toDecimal(it.LineTotal),
+		})
+	}
+
+	discounts := make([]discountDoc, 0, len(o.Discounts))
+	for _, dc := range o.Discounts {
+		promoID, err := primitive.ObjectIDFromHex(dc.PromotionID)
+		if err != nil {
+			return orders.Order{}, fmt.Errorf("insert order: invalid promotion id %q", dc.PromotionID)
+		}
+		discounts = append(discounts, discountDoc{PromotionID: promoID, Code: dc.Code, Amount: toDecimal(dc.Amount)})
+	}
+
+	var taxLines []taxLineDoc
+	for _, t := range o.Taxes {
+		ruleID, err := primitive.ObjectIDFromHex(t.RuleID)
+		if err != nil {
+			return orders.Order{}, fmt.Errorf("insert order: invalid tax rule id %q", t.RuleID)
+		}
+		taxLines = append(taxLines, taxLineDoc{
+			RuleID:    ruleID,
+			Name:      t.Name,
+			Rate:      t.Rate,
+			Inclusive: t.Inclusive,
+			Base:      toDecimal(t.Base),
+			Amount:    toDecimal(t.Amount),
+		})
+	}
+
+	history := make([]statusChangeDoc, 0, len(o.StatusHistory))
+	for _, ch := range o.StatusHistory {
+		history = append(history, toStatusChangeDoc(ch))
+	}
+
+	var addr *shippingAddressDoc
+	if a := o.ShippingAddress; a != nil {
+		addr = &shippingAddressDoc{
+			FullName:   a.FullName,
+			Phone:      a.Phone,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+		}
+	}
+
+	var dlv *deliveryDoc
+	if m := o.Delivery; m != nil {
+		methodID, err := primitive.ObjectIDFromHex(m.MethodID)
+		if err != nil {
+			return orders.Order{}, fmt.Errorf("insert order: invalid delivery method id %q", m.MethodID)
+		}
+		dlv = &deliveryDoc{MethodID: methodID, Name: m.Name, Fee: toDecimal(m.Fee)}
 	}
 
 	doc := orderDoc{
-		ID:        primitive.NewObjectID(),
-		UserID:    o.UserID,
-		Items:     items,
-		Status:    string(o.Status),
-		CreatedAt: o.CreatedAt,
-		UpdatedAt: o.UpdatedAt,
+		ID:              primitive.NewObjectID(),
+		UserID:          o.UserID,
+		Items:           items,
+		Status:          string(o.Status),
+		PaymentStatus:   string(o.PaymentStatus),
+		ShippingAddress: addr,
+		Delivery:        dlv,
+		Discounts:       discounts,
+		Taxes:           taxLines,
+		TotalPrice:      toDecimal(o.TotalPrice),
+		Currency:        string(o.TotalPrice.Currency),
+		Display:         toDisplayDoc(o.Display),
+		StatusHistory:   history,
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
 	}
 
 	if _, err := r.col.InsertOne(ctx, doc); err != nil {
@@ -114,7 +323,43 @@ func (r *OrdersRepo) Create(ctx context.Context, o orders.Order) (orders.Order,
 	return o, nil
 }
 
-func (r *OrdersRepo) UpdateStatus(ctx context.Context, id string, status orders.Status) (orders.Order, error) {
+func (r *OrdersRepo) UpdateStatus(ctx context.Context, id string, ch orders.StatusChange) (orders.Order, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return orders.Order{}, orders.ErrInvalidID
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	// matching on the current status makes concurrent transitions lose cleanly
+	var d orderDoc
+	err = r.col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": oid, "status": string(ch.From)},
+		bson.M{
+			"$set":  bson.M{"status": string(ch.To), "updatedAt": ch.ChangedAt},
+			"$push": bson.M{"statusHistory": toStatusChangeDoc(ch)},
+		},
+		opts,
+	).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			n, err := r.col.CountDocuments(ctx, bson.M{"_id": oid})
+			if err != nil {
+				return orders.Order{}, fmt.Errorf("check order: %w", err)
+			}
+			if n == 0 {
+				return orders.Order{}, orders.ErrNotFound
+			}
+			return orders.Order{}, orders.ErrInvalidTransition
+		}
+		return orders.Order{}, fmt.Errorf("update order status: %w", err)
+	}
+
+	return mapOrderDoc(d, r.base), nil
+}
+
+func (r *OrdersRepo) SetPaymentStatus(ctx context.Context, id string, ps orders.PaymentStatus, at time.Time) (orders.Order, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return orders.Order{}, orders.ErrInvalidID
@@ -126,42 +371,273 @@ func (r *OrdersRepo) UpdateStatus(ctx context.Context, id string, status orders.
 	err = r.col.FindOneAndUpdate(
 		ctx,
 		bson.M{"_id": oid},
-		bson.M{"$set": bson.M{"status": string(status), "updatedAt": time.Now().UTC()}},
+		bson.M{"$set": bson.M{"paymentStatus": string(ps), "updatedAt": at}},
 		opts,
 	).Decode(&d)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return orders.Order{}, orders.ErrNotFound
 		}
-		return orders.Order{}, fmt.Errorf("update order status: %w", err)
+		return orders.Order{}, fmt.Errorf("update payment status: %w", err)
 	}
 
-	return mapOrderDoc(d), nil
+	return mapOrderDoc(d, r.base), nil
 }
 
-func mapOrderDoc(d orderDoc) orders.Order {
+func (r *OrdersRepo) AddRefund(ctx context.Context, id string, rf orders.Refund) (orders.Order, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return orders.Order{}, orders.ErrInvalidID
+	}
+	var returnOID primitive.ObjectID
+	if rf.ReturnID != "" {
+		if returnOID, err = primitive.ObjectIDFromHex(rf.ReturnID); err != nil {
+			return orders.Order{}, orders.ErrInvalidID
+		}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d orderDoc
+	err = r.col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": oid},
+		bson.M{
+			"$set": bson.M{"updatedAt": rf.CreatedAt},
+			"$push": bson.M{"refunds": refundDoc{
+				ReturnID:  returnOID,
+				Amount:    toDecimal(rf.Amount),
+				CreatedAt: rf.CreatedAt,
+			}},
+		},
+		opts,
+	).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return orders.Order{}, orders.ErrNotFound
+		}
+		return orders.Order{}, fmt.Errorf("add order refund: %w", err)
+	}
+
+	return mapOrderDoc(d, r.base), nil
+}
+
+func (r *OrdersRepo) AddShipment(ctx context.Context, id string, from orders.Status, sh orders.Shipment, ch *orders.StatusChange) (orders.Order, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return orders.Order{}, orders.ErrInvalidID
+	}
+
+	items := make([]shipmentItemDoc, 0, len(sh.Items))
+	for _, it := range sh.Items {
+		pid, err := primitive.ObjectIDFromHex(it.ProductID)
+		if err != nil {
+			return orders.Order{}, orders.ErrInvalidProduct
+		}
+		items = append(items, shipmentItemDoc{ProductID: pid, Quantity: it.Quantity})
+	}
+
+	doc := shipmentDoc{
+		ID:             primitive.NewObjectID(),
+		Carrier:        sh.Carrier,
+		TrackingNumber: sh.TrackingNumber,
+		Items:          items,
+		ShippedAt:      sh.ShippedAt,
+		CreatedBy:      sh.CreatedBy,
+	}
+
+	set := bson.M{"updatedAt": sh.ShippedAt}
+	push := bson.M{"shipments": doc}
+	if ch != nil {
+		set["status"] = string(ch.To)
+		push["statusHistory"] = toStatusChangeDoc(*ch)
+	}
+
+	return r.guardedUpdate(ctx, bson.M{"_id": oid, "status": string(from)}, bson.M{"$set": set, "$push": push})
+}
+
+func (r *OrdersRepo) SetShipmentDelivered(ctx context.Context, id string, shipmentID string, from orders.Status, at time.Time, ch *orders.StatusChange) (orders.Order, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return orders.Order{}, orders.ErrInvalidID
+	}
+	sid, err := primitive.ObjectIDFromHex(shipmentID)
+	if err != nil {
+		return orders.Order{}, orders.ErrShipmentNotFound
+	}
+
+	set := bson.M{"shipments.$.deliveredAt": at, "updatedAt": at}
+	update := bson.M{"$set": set}
+	if ch != nil {
+		set["status"] = string(ch.To)
+		update["$push"] = bson.M{"statusHistory": toStatusChangeDoc(*ch)}
+	}
+
+	return r.guardedUpdate(ctx, bson.M{"_id": oid, "status": string(from), "shipments._id": sid}, update)
+}
+
+// guardedUpdate applies update to the order matching filter, which pins the
+// current status. A miss is reported as ErrNotFound if the order doesn't exist
+// and ErrInvalidTransition if it moved on in the meantime.
+func (r *OrdersRepo) guardedUpdate(ctx context.Context, filter bson.M, update bson.M) (orders.Order, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d orderDoc
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			n, err := r.col.CountDocuments(ctx, bson.M{"_id": filter["_id"]})
+			if err != nil {
+				return orders.Order{}, fmt.Errorf("check order: %w", err)
+			}
+			if n == 0 {
+				return orders.Order{}, orders.ErrNotFound
+			}
+			return orders.Order{}, orders.ErrInvalidTransition
+		}
+		return orders.Order{}, fmt.Errorf("update order: %w", err)
+	}
+
+	return mapOrderDoc(d, r.base), nil
+}
+
+func mapOrderDoc(d orderDoc, base money.Currency) orders.Order {
+	cur := d.Currency
+	subtotal := money.Zero(d.TotalPrice.toMoney(cur, base).Currency)
 	items := make([]orders.Item, 0, len(d.Items))
 	for _, it := range d.Items {
+		line := it.LineTotal.toMoney(cur, base)
+		subtotal = subtotal.Add(line)
 		items = append(items, orders.Item{
-			ProductID: it.ProductID.Hex(),
-			Quantity:  it.Quantity,
+			ProductID:   it.ProductID.Hex(),
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+			UnitPrice:   it.UnitPrice.toMoney(cur, base),
+			LineTotal:   line,
+		})
+	}
+
+	discounts := make([]orders.Discount, 0, len(d.Discounts))
+	for _, dc := range d.Discounts {
+		discounts = append(discounts, orders.Discount{
+			PromotionID: dc.PromotionID.Hex(),
+			Code:        dc.Code,
+			Amount:      dc.Amount.toMoney(cur, base),
+		})
+	}
+
+	var taxes []orders.TaxLine
+	for _, t := range d.Taxes {
+		taxes = append(taxes, orders.TaxLine{
+			RuleID:    t.RuleID.Hex(),
+			Name:      t.Name,
+			Rate:      t.Rate,
+			Inclusive: t.Inclusive,
+			Base:      t.Base.toMoney(cur, base),
+			Amount:    t.Amount.toMoney(cur, base),
+		})
+	}
+
+	history := make([]orders.StatusChange, 0, len(d.StatusHistory))
+	for _, ch := range d.StatusHistory {
+		history = append(history, orders.StatusChange{
+			From:      orders.Status(ch.From),
+			To:        orders.Status(ch.To),
+			ChangedBy: ch.ChangedBy,
+			Note:      ch.Note,
+			ChangedAt: ch.ChangedAt,
 		})
 	}
 
+	var addr *orders.ShippingAddress
+	if a := d.ShippingAddress; a != nil {
+		addr = &orders.ShippingAddress{
+			FullName:   a.FullName,
+			Phone:      a.Phone,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+		}
+	}
+
+	var dlv *orders.DeliveryMethod
+	if m := d.Delivery; m != nil {
+		dlv = &orders.DeliveryMethod{MethodID: m.MethodID.Hex(), Name: m.Name, Fee: m.Fee.toMoney(cur, base)}
+	}
+
+	var shipments []orders.Shipment
+	for _, sh := range d.Shipments {
+		items := make([]orders.ShipmentItem, 0, len(sh.Items))
+		for _, it := range sh.Items {
+			items = append(items, orders.ShipmentItem{ProductID: it.ProductID.Hex(), Quantity: it.Quantity})
+		}
+		shipments = append(shipments, orders.Shipment{
+			ID:             sh.ID.Hex(),
+			Carrier:        sh.Carrier,
+			TrackingNumber: sh.TrackingNumber,
+			Items:          items,
+			ShippedAt:      sh.ShippedAt,
+			DeliveredAt:    sh.DeliveredAt,
+			CreatedBy:      sh.CreatedBy,
+		})
+	}
+
+	var refunds []orders.Refund
+	for _, rf := range d.Refunds {
+		out := orders.Refund{
+			Amount:    rf.Amount.toMoney(cur, base),
+			CreatedAt: rf.CreatedAt,
+		}
+		if !rf.ReturnID.IsZero() {
+			out.ReturnID = rf.ReturnID.Hex()
+		}
+		refunds = append(refunds, out)
+	}
+
+	// orders placed before payments existed have no paymentStatus
+	ps := orders.PaymentStatus(d.PaymentStatus)
+	if ps == "" {
+		ps = orders.PaymentUnpaid
+	}
+
 	return orders.Order{
-		ID:        d.ID.Hex(),
-		UserID:    d.UserID,
-		Items:     items,
-		Status:    orders.Status(d.Status),
-		CreatedAt: d.CreatedAt,
-		UpdatedAt: d.UpdatedAt,
+		ID:              d.ID.Hex(),
+		UserID:          d.UserID,
+		Items:           items,
+		Status:          orders.Status(d.Status),
+		PaymentStatus:   ps,
+		ShippingAddress: addr,
+		Delivery:        dlv,
+		Subtotal:        subtotal,
+		Discounts:       discounts,
+		Taxes:           taxes,
+		TotalPrice:      d.TotalPrice.toMoney(cur, base),
+		Refunds:         refunds,
+		Display:         mapDisplayDoc(d.Display),
+		Shipments:       shipments,
+		StatusHistory:   history,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
 	}
 }
 
-func (r *OrdersRepo) Count(ctx context.Context, userID *string) (int64, error) {
-	filter := bson.M{}
-	if userID != nil && strings.TrimSpace(*userID) != "" {
-		filter["userId"] = *userID
+func toStatusChangeDoc(ch orders.StatusChange) statusChangeDoc {
+	return statusChangeDoc{
+		From:      string(ch.From),
+		To:        string(ch.To),
+		ChangedBy: ch.ChangedBy,
+		Note:      ch.Note,
+		ChangedAt: ch.ChangedAt,
+	}
+}
+
+func (r *OrdersRepo) Count(ctx context.Context, userID *string, f orders.ListFilter) (int64, error) {
+	filter, err := orderListFilter(userID, f)
+	if err != nil {
+		return 0, err
 	}
 
 	n, err := r.col.CountDocuments(ctx, filter)
@@ -170,3 +646,74 @@ func (r *OrdersRepo) Count(ctx context.Context, userID *string) (int64, error) {
 	}
 	return n, nil
 }
+
+// orderListFilter builds the query shared by List and Count. A userID scope
+// always wins over f.UserID so customers can't widen their own listing.
+func orderListFilter(userID *string, f orders.ListFilter) (bson.M, error) {
+	filter := bson.M{}
+	if userID != nil && strings.TrimSpace(*userID) != "" {
+		filter["userId"] = *userID
+	} else if f.UserID != nil {
+		filter["userId"] = *f.UserID
+	}
+
+	if f.Status != nil {
+		filter["status"] = *f.Status
+	}
+	if f.ProductID != nil {
+		pid, err := primitive.ObjectIDFromHex(*f.ProductID)
+		if err != nil {
+			return nil, orders.ErrInvalidProduct
+		}
+		filter["items.productId"] = pid
+	}
+
+	created := bson.M{}
+	if f.CreatedFrom != nil {
+		created["$gte"] = *f.CreatedFrom
+	}
+	if f.CreatedTo != nil {
+		created["$lt"] = *f.CreatedTo
+	}
+	if len(created) > 0 {
+		filter["createdAt"] = created
+	}
+
+	total := bson.M{}
+	if f.MinTotal != nil {
+		total["$gte"] = toDecimal(*f.MinTotal)
+	}
+	if f.MaxTotal != nil {
+		total["$lte"] = toDecimal(*f.MaxTotal)
+	}
+	if len(total) > 0 {
+		filter["totalPrice"] = total
+	}
+
+	return filter, nil
+}
+
+func toDisplayDoc(dp *orders.Display) *displayDoc {
+	if dp == nil {
+		return nil
+	}
+	return &displayDoc{
+		Currency:   string(dp.Currency),
+		Rate:       dp.Rate,
+		Subtotal:   toDecimal(dp.Subtotal),
+		TotalPrice: toDecimal(dp.TotalPrice),
+	}
+}
+
+func mapDisplayDoc(d *displayDoc) *orders.Display {
+	if d == nil {
+		return nil
+	}
+	// display amounts are only ever written with their currency
+	return &orders.Display{
+		Currency:   money.Currency(d.Currency),
+		Rate:       d.Rate,
+		Subtotal:   d.Subtotal.toMoney(d.Currency, ""),
+		TotalPrice: d.TotalPrice.toMoney(d.Currency, ""),
+	}
+}
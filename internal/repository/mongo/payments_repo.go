@@ -0,0 +1,151 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentsRepo struct {
+	col  *mongo.Collection
+	base money.Currency
+}
+
+// NewPaymentsRepo builds the repo. Amounts stored without a currency, from
+// before amounts carried one, are read as base.
+func NewPaymentsRepo(db *mongo.Database, base money.Currency) *PaymentsRepo {
+	return &PaymentsRepo{col: db.Collection("payments"), base: base}
+}
+
+type paymentDoc struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID      primitive.ObjectID `bson:"orderId"`
+	UserID       string             `bson:"userId"`
+	Provider     string             `bson:"provider"`
+	ProviderRef  string             `bson:"providerRef"`
+	ClientSecret string             `bson:"clientSecret,omitempty"`
+	Amount       decimalAmount      `bson:"amount"`
+	Currency     string             `bson:"currency,omitempty"`
+	Status       string             `bson:"status"`
+	CreatedAt    time.Time          `bson:"createdAt"`
+	UpdatedAt    time.Time          `bson:"updatedAt"`
+}
+
+func (r *PaymentsRepo) EnsureIndexes(ctx context.Context) error {
+	// webhooks look payments up by the provider's reference
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "providerRef", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	return err
+}
+
+func (r *PaymentsRepo) Create(ctx context.Context, p payments.Payment) (payments.Payment, error) {
+	orderOID, err := primitive.ObjectIDFromHex(p.OrderID)
+	if err != nil {
+		return payments.Payment{}, payments.ErrInvalidID
+	}
+
+	doc := paymentDoc{
+		ID:           primitive.NewObjectID(),
+		OrderID:      orderOID,
+		UserID:       p.UserID,
+		Provider:     p.Provider,
+		ProviderRef:  p.ProviderRef,
+		ClientSecret: p.ClientSecret,
+		Amount:       toDecimal(p.Amount),
+		Currency:     string(p.Amount.Currency),
+		Status:       string(p.Status),
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		return payments.Payment{}, fmt.Errorf("insert payment: %w", err)
+	}
+
+	p.ID = doc.ID.Hex()
+	return p, nil
+}
+
+func (r *PaymentsRepo) GetByProviderRef(ctx context.Context, provider, ref string) (payments.Payment, error) {
+	var d paymentDoc
+	if err := r.col.FindOne(ctx, bson.M{"provider": provider, "providerRef": ref}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return payments.Payment{}, payments.ErrNotFound
+		}
+		return payments.Payment{}, fmt.Errorf("find payment: %w", err)
+	}
+	return mapPaymentDoc(d, r.base), nil
+}
+
+func (r *PaymentsRepo) GetLatestByOrderID(ctx context.Context, orderID string) (payments.Payment, error) {
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return payments.Payment{}, payments.ErrInvalidID
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	var d paymentDoc
+	if err := r.col.FindOne(ctx, bson.M{"orderId": oid}, opts).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return payments.Payment{}, payments.ErrNotFound
+		}
+		return payments.Payment{}, fmt.Errorf("find payment: %w", err)
+	}
+	return mapPaymentDoc(d, r.base), nil
+}
+
+func (r *PaymentsRepo) UpdateStatus(ctx context.Context, id string, st payments.Status, at time.Time) (payments.Payment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return payments.Payment{}, payments.ErrInvalidID
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d paymentDoc
+	err = r.col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"status": string(st), "updatedAt": at}},
+		opts,
+	).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return payments.Payment{}, payments.ErrNotFound
+		}
+		return payments.Payment{}, fmt.Errorf("update payment status: %w", err)
+	}
+	return mapPaymentDoc(d, r.base), nil
+}
+
+func mapPaymentDoc(d paymentDoc, base money.Currency) payments.Payment {
+	return payments.Payment{
+		ID:           d.ID.Hex(),
+		OrderID:      d.OrderID.Hex(),
+		UserID:       d.UserID,
+		Provider:     d.Provider,
+		ProviderRef:  d.ProviderRef,
+		ClientSecret: d.ClientSecret,
+		Amount:       d.Amount.toMoney(d.Currency, base),
+		Status:       payments.Status(d.Status),
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,11 +16,52 @@ import (
 )
 
 type ProductsRepo struct {
-	col *mongo.Collection
+	col  *mongo.Collection
+	base money.Currency
 }
 
-func NewProductsRepo(db *mongo.Database) *ProductsRepo {
-	return &ProductsRepo{col: db.Collection("products")}
+// NewProductsRepo builds the repo. Amounts stored without a currency, from
+// before amounts carried one, are read as base.
+func NewProductsRepo(db *mongo.Database, base money.Currency) *ProductsRepo {
+	return &ProductsRepo{col: db.Collection("products"), base: base}
+}
+
+// textIndex is the products text index. Its weights must match
+// products.NameWeight and products.DescriptionWeight so the in-memory repo
+// ranks alike.
+const textIndex = "products_text"
+
+// sortKeys maps list sort fields to document fields.
+var sortKeys = map[products.SortField]string{
+	products.SortByCreatedAt: "createdAt",
+	products.SortByPrice:     "price",
+	products.SortByRating:    "ratingAvg",
+	products.SortBySold:      "sold",
+}
+
+func (r *ProductsRepo) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{{
+		// a collection has at most one text index; changing the weights means
+		// dropping products_text first
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName(textIndex).
+			SetDefaultLanguage("english").
+			SetWeights(bson.D{
+				{Key: "name", Value: products.NameWeight},
+				{Key: "description", Value: products.DescriptionWeight},
+			}),
+	}}
+	// every sort, on its own and within a category; the _id tie-breaker
+	// runs the same way as the field, so one index serves both directions
+	for _, key := range sortKeys {
+		models = append(models,
+			mongo.IndexModel{Keys: bson.D{{Key: key, Value: 1}, {Key: "_id", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "categoryId", Value: 1}, {Key: key, Value: 1}, {Key: "_id", Value: 1}}},
+		)
+	}
+	_, err := r.col.Indexes().CreateMany(ctx, models)
+	return err
 }
 
 type reviewDoc struct {
@@ -35,27 +77,34 @@ type productDoc struct {
 	CategoryID  primitive.ObjectID `bson:"categoryId"`
 	Name        string             `bson:"name"`
 	Description string             `bson:"description,omitempty"`
-	Price       float64            `bson:"price"`
+	Price       decimalAmount      `bson:"price"`
+	Currency    string             `bson:"currency,omitempty"`
 	Stock       int64              `bson:"stock"`
-	CreatedAt   time.Time          `bson:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt"`
-	Reviews     []reviewDoc        `bson:"reviews,omitempty"`
+	Reserved    int64              `bson:"reserved"`
+	// 0 or missing turns low-stock alerts off
+	ReorderThreshold int64 `bson:"reorderThreshold,omitempty"`
+	// kept in step with reviews and orders; see products.Product
+	RatingAvg   float64     `bson:"ratingAvg"`
+	RatingCount int64       `bson:"ratingCount"`
+	Sold        int64       `bson:"sold"`
+	CreatedAt   time.Time   `bson:"createdAt"`
+	UpdatedAt   time.Time   `bson:"updatedAt"`
+	Reviews     []reviewDoc `bson:"reviews,omitempty"`
 }
 
 func (r *ProductsRepo) List(ctx context.Context, f products.ListFilter) ([]products.Product, error) {
-	filter := bson.M{}
-	if f.CategoryID != nil && strings.TrimSpace(*f.CategoryID) != "" {
-		oid, err := primitive.ObjectIDFromHex(*f.CategoryID)
-		if err != nil {
-			return nil, products.ErrInvalidCategory
-		}
-		filter["categoryId"] = oid
+	filter, err := productsFilter(f)
+	if err != nil {
+		return nil, err
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSort(productsSort(f)).
 		SetSkip(f.Offset).
 		SetLimit(f.Limit)
+	if f.Query != "" && f.SortBy == "" {
+		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+	}
 
 	cur, err := r.col.Find(ctx, filter, opts)
 	if err != nil {
@@ -70,7 +119,7 @@ func (r *ProductsRepo) List(ctx context.Context, f products.ListFilter) ([]produ
 
 	out := make([]products.Product, 0, len(docs))
 	for _, d := range docs {
-		out = append(out, mapProductDoc(d))
+		out = append(out, mapProductDoc(d, r.base))
 	}
 	return out, nil
 }
@@ -88,7 +137,40 @@ func (r *ProductsRepo) GetByID(ctx context.Context, id string) (products.Product
 		}
 		return products.Product{}, fmt.Errorf("find product: %w", err)
 	}
-	return mapProductDoc(d), nil
+	return mapProductDoc(d, r.base), nil
+}
+
+func (r *ProductsRepo) GetByIDs(ctx context.Context, ids []string) (map[string]products.Product, error) {
+	out := make(map[string]products.Product, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, products.ErrInvalidID
+		}
+		oids = append(oids, oid)
+	}
+
+	cur, err := r.col.Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return nil, fmt.Errorf("find products: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []productDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode products: %w", err)
+	}
+
+	for _, d := range docs {
+		p := mapProductDoc(d, r.base)
+		out[p.ID] = p
+	}
+	return out, nil
 }
 
 func (r *ProductsRepo) Create(ctx context.Context, p products.Product) (products.Product, error) {
@@ -102,11 +184,14 @@ func (r *ProductsRepo) Create(ctx context.Context, p products.Product) (products
 		CategoryID:  catOID,
 		Name:        p.Name,
 		Description: p.Description,
-		Price:       p.Price,
+		Price:       toDecimal(p.Price),
+		Currency:    string(p.Price.Currency),
 		Stock:       p.Stock,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 		Reviews:     []reviewDoc{},
+
+		ReorderThreshold: p.ReorderThreshold,
 	}
 
 	if _, err := r.col.InsertOne(ctx, doc); err != nil {
@@ -141,29 +226,44 @@ func (r *ProductsRepo) Update(ctx context.Context, id string, in products.Update
 		set["description"] = *in.Description
 	}
 	if in.Price != nil {
-		set["price"] = *in.Price
+		set["price"] = toDecimal(*in.Price)
+		set["currency"] = string(in.Price.Currency)
 	}
 	if in.Stock != nil {
 		set["stock"] = *in.Stock
 	}
+	if in.ReorderThreshold != nil {
+		set["reorderThreshold"] = *in.ReorderThreshold
+	}
+
+	filter := bson.M{"_id": oid}
+	if in.Stock != nil {
+		filter["$expr"] = bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$reserved", 0}}, *in.Stock}}
+	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	var d productDoc
 	err = r.col.FindOneAndUpdate(
 		ctx,
-		bson.M{"_id": oid},
+		filter,
 		bson.M{"$set": set},
 		opts,
 	).Decode(&d)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return products.Product{}, products.ErrNotFound
+			if in.Stock == nil {
+				return products.Product{}, products.ErrNotFound
+			}
+			if err := r.stockMiss(ctx, oid); !errors.Is(err, products.ErrInsufficientStock) {
+				return products.Product{}, err
+			}
+			return products.Product{}, products.ErrStockBelowReserved
 		}
 		return products.Product{}, fmt.Errorf("update product: %w", err)
 	}
 
-	return mapProductDoc(d), nil
+	return mapProductDoc(d, r.base), nil
 }
 
 func (r *ProductsRepo) Delete(ctx context.Context, id string) error {
@@ -190,7 +290,34 @@ func (r *ProductsRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *ProductsRepo) DecrementStock(ctx context.Context, productID string, qty int64) error {
+func (r *ProductsRepo) DecrementStock(ctx context.Context, productID string, qty int64) (products.Product, error) {
+	oid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return products.Product{}, products.ErrInvalidID
+	}
+	if qty <= 0 {
+		return r.GetByID(ctx, productID)
+	}
+
+	var d productDoc
+	err = r.col.FindOneAndUpdate(ctx,
+		bson.M{"_id": oid, "$expr": availableAtLeast(qty)},
+		bson.M{
+			"$inc": bson.M{"stock": -qty},
+			"$set": bson.M{"updatedAt": time.Now().UTC()},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return products.Product{}, r.stockMiss(ctx, oid)
+		}
+		return products.Product{}, fmt.Errorf("decrement stock: %w", err)
+	}
+	return mapProductDoc(d, r.base), nil
+}
+
+func (r *ProductsRepo) IncrementStock(ctx context.Context, productID string, qty int64) error {
 	oid, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
 		return products.ErrInvalidID
@@ -200,28 +327,90 @@ func (r *ProductsRepo) DecrementStock(ctx context.Context, productID string, qty
 	}
 
 	res, err := r.col.UpdateOne(ctx,
-		bson.M{"_id": oid, "stock": bson.M{"$gte": qty}},
+		bson.M{"_id": oid},
 		bson.M{
-			"$inc":  bson.M{"stock": -qty},
-			"$set":  bson.M{"updatedAt": time.Now().UTC()},
+			"$inc": bson.M{"stock": qty},
+			"$set": bson.M{"updatedAt": time.Now().UTC()},
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("decrement stock: %w", err)
+		return fmt.Errorf("increment stock: %w", err)
 	}
 	if res.MatchedCount == 0 {
-		n, err := r.col.CountDocuments(ctx, bson.M{"_id": oid})
-		if err != nil {
-			return fmt.Errorf("check product: %w", err)
-		}
-		if n == 0 {
-			return products.ErrNotFound
-		}
-		return products.ErrInsufficientStock
+		return products.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ProductsRepo) Reserve(ctx context.Context, productID string, qty int64) error {
+	oid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return products.ErrInvalidID
+	}
+	if qty <= 0 {
+		return nil
+	}
+
+	// holds are bookkeeping, not edits, so updatedAt is left alone
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": oid, "$expr": availableAtLeast(qty)},
+		bson.M{"$inc": bson.M{"reserved": qty}},
+	)
+	if err != nil {
+		return fmt.Errorf("reserve stock: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return r.stockMiss(ctx, oid)
+	}
+	return nil
+}
+
+func (r *ProductsRepo) Unreserve(ctx context.Context, productID string, qty int64) error {
+	oid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return products.ErrInvalidID
+	}
+	if qty <= 0 {
+		return nil
+	}
+
+	// clamped at zero so a hold released twice can't free other carts' stock
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"reserved": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{bson.M{"$ifNull": bson.A{"$reserved", 0}}, qty}}}},
+		}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("unreserve stock: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return products.ErrNotFound
 	}
 	return nil
 }
 
+// availableAtLeast matches products whose unreserved stock covers qty.
+// Products saved before reservations existed have no reserved field.
+func availableAtLeast(qty int64) bson.M {
+	return bson.M{"$gte": bson.A{
+		bson.M{"$subtract": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+		qty,
+	}}
+}
+
+// stockMiss explains a stock update that matched nothing.
+func (r *ProductsRepo) stockMiss(ctx context.Context, oid primitive.ObjectID) error {
+	n, err := r.col.CountDocuments(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("check product: %w", err)
+	}
+	if n == 0 {
+		return products.ErrNotFound
+	}
+	return products.ErrInsufficientStock
+}
+
 func (r *ProductsRepo) AddReview(ctx context.Context, productID string, rev products.Review) (products.Review, error) {
 	pid, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
@@ -247,6 +436,9 @@ func (r *ProductsRepo) AddReview(ctx context.Context, productID string, rev prod
 	if res.MatchedCount == 0 {
 		return products.Review{}, products.ErrNotFound
 	}
+	if err := r.refreshRating(ctx, pid); err != nil {
+		return products.Review{}, err
+	}
 
 	return products.Review{
 		ID:        doc.ID.Hex(),
@@ -283,20 +475,70 @@ func (r *ProductsRepo) DeleteReview(ctx context.Context, productID string, revie
 	if res.ModifiedCount == 0 {
 		return products.ErrNotFound
 	}
+	return r.refreshRating(ctx, pid)
+}
+
+// ratingFields recomputes the stored rating summary from the embedded
+// reviews, for use in a pipeline $set.
+var ratingFields = bson.M{
+	"ratingCount": bson.M{"$size": bson.M{"$ifNull": bson.A{"$reviews", bson.A{}}}},
+	"ratingAvg":   bson.M{"$ifNull": bson.A{bson.M{"$avg": "$reviews.rating"}, 0}},
+}
+
+// refreshRating recomputes the product's rating summary after its reviews
+// changed. It derives it from the reviews rather than adjusting it, so it
+// can't drift.
+func (r *ProductsRepo) refreshRating(ctx context.Context, pid primitive.ObjectID) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": pid},
+		mongo.Pipeline{{{Key: "$set", Value: ratingFields}}},
+	)
+	if err != nil {
+		return fmt.Errorf("refresh rating: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductsRepo) AddSold(ctx context.Context, productID string, qty int64) error {
+	oid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return products.ErrInvalidID
+	}
+	if qty == 0 {
+		return nil
+	}
+
+	// like holds, sales counts are bookkeeping and leave updatedAt alone
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$inc": bson.M{"sold": qty}},
+	)
+	if err != nil {
+		return fmt.Errorf("add sold: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return products.ErrNotFound
+	}
 	return nil
 }
 
-func mapProductDoc(d productDoc) products.Product {
+func mapProductDoc(d productDoc, base money.Currency) products.Product {
 	out := products.Product{
 		ID:          d.ID.Hex(),
 		CategoryID:  d.CategoryID.Hex(),
 		Name:        d.Name,
 		Description: d.Description,
-		Price:       d.Price,
+		Price:       d.Price.toMoney(d.Currency, base),
 		Stock:       d.Stock,
+		Reserved:    d.Reserved,
 		CreatedAt:   d.CreatedAt,
-		UpdatedAt:   d.UpdatedAt,
-		Reviews:     make([]products.Review, 0, len(d.Reviews)),
+
+		ReorderThreshold: d.ReorderThreshold,
+		RatingAvg:        d.RatingAvg,
+		RatingCount:      d.RatingCount,
+		Sold:             d.Sold,
+		UpdatedAt:        d.UpdatedAt,
+		Reviews:          make([]products.Review, 0, len(d.Reviews)),
 	}
 	for _, r := range d.Reviews {
 		out.Reviews = append(out.Reviews, products.Review{
@@ -311,13 +553,9 @@ func mapProductDoc(d productDoc) products.Product {
 }
 
 func (r *ProductsRepo) Count(ctx context.Context, f products.ListFilter) (int64, error) {
-	filter := bson.M{}
-	if f.CategoryID != nil && strings.TrimSpace(*f.CategoryID) != "" {
-		oid, err := primitive.ObjectIDFromHex(*f.CategoryID)
-		if err != nil {
-			return 0, products.ErrInvalidCategory
-		}
-		filter["categoryId"] = oid
+	filter, err := productsFilter(f)
+	if err != nil {
+		return 0, err
 	}
 
 	n, err := r.col.CountDocuments(ctx, filter)
@@ -326,3 +564,247 @@ func (r *ProductsRepo) Count(ctx context.Context, f products.ListFilter) (int64,
 	}
 	return n, nil
 }
+
+func productsFilter(f products.ListFilter) (bson.M, error) {
+	filter := bson.M{}
+	if len(f.CategoryIDs) > 0 {
+		oids := make([]primitive.ObjectID, 0, len(f.CategoryIDs))
+		for _, id := range f.CategoryIDs {
+			oid, err := primitive.ObjectIDFromHex(strings.TrimSpace(id))
+			if err != nil {
+				return nil, products.ErrInvalidCategory
+			}
+			oids = append(oids, oid)
+		}
+		filter["categoryId"] = bson.M{"$in": oids}
+	}
+	if f.Query != "" {
+		// Mongo parses phrases and negations itself; see products.Search
+		filter["$text"] = bson.M{"$search": f.Query, "$language": "english"}
+	}
+
+	price := bson.M{}
+	if f.MinPrice != nil {
+		price["$gte"] = toDecimal(*f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		price["$lte"] = toDecimal(*f.MaxPrice)
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+	if f.MinRating != nil {
+		// products without reviews have ratingAvg 0 and a count of 0
+		filter["ratingCount"] = bson.M{"$gt": 0}
+		filter["ratingAvg"] = bson.M{"$gte": *f.MinRating}
+	}
+
+	var exprs bson.A
+	if f.InStockOnly {
+		// stock > 0 narrows by index before the reserved stock is checked
+		filter["stock"] = bson.M{"$gt": 0}
+		exprs = append(exprs, availableAtLeast(1))
+	}
+	if f.LowStockOnly {
+		// see products.Product.LowStock
+		filter["reorderThreshold"] = bson.M{"$gt": 0}
+		exprs = append(exprs, bson.M{"$lte": bson.A{"$stock", "$reorderThreshold"}})
+	}
+	switch len(exprs) {
+	case 0:
+	case 1:
+		filter["$expr"] = exprs[0]
+	default:
+		filter["$expr"] = bson.M{"$and": exprs}
+	}
+	return filter, nil
+}
+
+// Facets counts every breakdown in one $facet aggregation. The search and
+// low-stock filters are shared by all breakdowns and matched up front, where
+// $text has to be; each breakdown then matches the rest of its filters.
+func (r *ProductsRepo) Facets(ctx context.Context, f products.ListFilter, priceBreaks []money.Money) (products.Facets, error) {
+	shared, err := productsFilter(products.ListFilter{Query: f.Query, LowStockOnly: f.LowStockOnly})
+	if err != nil {
+		return products.Facets{}, err
+	}
+	// the filters a breakdown applies, less the shared ones matched already
+	match := func(facet products.Facet) (bson.M, error) {
+		g := f.Except(facet)
+		g.Query, g.LowStockOnly = "", false
+		return productsFilter(g)
+	}
+	all, err := match("")
+	if err != nil {
+		return products.Facets{}, err
+	}
+	byCategory, err := match(products.FacetCategory)
+	if err != nil {
+		return products.Facets{}, err
+	}
+	byPrice, err := match(products.FacetPrice)
+	if err != nil {
+		return products.Facets{}, err
+	}
+	byRating, err := match(products.FacetRating)
+	if err != nil {
+		return products.Facets{}, err
+	}
+	byAvailability, err := match(products.FacetAvailability)
+	if err != nil {
+		return products.Facets{}, err
+	}
+
+	boundaries := bson.A{toDecimal(money.Money{})}
+	for _, b := range priceBreaks {
+		boundaries = append(boundaries, toDecimal(b))
+	}
+	// one sum per step, gathered into an array in RatingSteps order
+	ratings := bson.M{"_id": nil}
+	ratingCounts := make(bson.A, 0, len(products.RatingSteps))
+	for i, step := range products.RatingSteps {
+		key := fmt.Sprintf("r%d", i)
+		ratings[key] = bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{
+				bson.M{"$gt": bson.A{"$ratingCount", 0}},
+				bson.M{"$gte": bson.A{"$ratingAvg", step}},
+			}}, 1, 0,
+		}}}
+		ratingCounts = append(ratingCounts, "$"+key)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: shared}},
+		{{Key: "$facet", Value: bson.M{
+			"total": []bson.M{
+				{"$match": all},
+				{"$count": "n"},
+			},
+			"categories": []bson.M{
+				{"$match": byCategory},
+				{"$group": bson.M{"_id": "$categoryId", "count": bson.M{"$sum": 1}}},
+				{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"prices": []bson.M{
+				{"$match": byPrice},
+				{"$bucket": bson.M{
+					"groupBy":    "$price",
+					"boundaries": boundaries,
+					// everything at or above the last break
+					"default": "over",
+					"output":  bson.M{"count": bson.M{"$sum": 1}},
+				}},
+			},
+			"ratings": []bson.M{
+				{"$match": byRating},
+				{"$group": ratings},
+				{"$project": bson.M{"_id": 0, "counts": ratingCounts}},
+			},
+			"availability": []bson.M{
+				{"$match": byAvailability},
+				{"$group": bson.M{
+					"_id":     nil,
+					"total":   bson.M{"$sum": 1},
+					"inStock": bson.M{"$sum": bson.M{"$cond": bson.A{availableAtLeast(1), 1, 0}}},
+				}},
+			},
+		}}},
+	}
+
+	cur, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return products.Facets{}, fmt.Errorf("aggregate product facets: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var results []struct {
+		Total []struct {
+			N int64 `bson:"n"`
+		} `bson:"total"`
+		Categories []struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		} `bson:"categories"`
+		Prices []struct {
+			ID    bson.RawValue `bson:"_id"`
+			Count int64         `bson:"count"`
+		} `bson:"prices"`
+		Ratings []struct {
+			Counts []int64 `bson:"counts"`
+		} `bson:"ratings"`
+		Availability []struct {
+			Total   int64 `bson:"total"`
+			InStock int64 `bson:"inStock"`
+		} `bson:"availability"`
+	}
+	if err := cur.All(ctx, &results); err != nil {
+		return products.Facets{}, fmt.Errorf("decode product facets: %w", err)
+	}
+
+	out := products.Facets{
+		Categories: []products.CategoryCount{},
+		Prices:     products.PriceBuckets(priceBreaks),
+		Ratings:    products.RatingBuckets(),
+	}
+	if len(results) == 0 {
+		return out, nil
+	}
+	res := results[0]
+
+	if len(res.Total) > 0 {
+		out.Total = res.Total[0].N
+	}
+	for _, c := range res.Categories {
+		out.Categories = append(out.Categories, products.CategoryCount{CategoryID: c.ID.Hex(), Count: c.Count})
+	}
+	for _, p := range res.Prices {
+		// buckets are keyed by their lower boundary, the open one by "over"
+		i := len(out.Prices) - 1
+		if p.ID.Type != bson.TypeString {
+			var lo decimalAmount
+			if err := lo.UnmarshalBSONValue(p.ID.Type, p.ID.Value); err != nil {
+				return products.Facets{}, fmt.Errorf("decode price bucket: %w", err)
+			}
+			i = priceBucketIndex(out.Prices, lo.toMoney("", r.base).Amount)
+		}
+		out.Prices[i].Count += p.Count
+	}
+	if len(res.Ratings) > 0 {
+		for i, n := range res.Ratings[0].Counts {
+			out.Ratings[i].Count = n
+		}
+	}
+	if len(res.Availability) > 0 {
+		out.InStock = res.Availability[0].InStock
+		out.OutOfStock = res.Availability[0].Total - res.Availability[0].InStock
+	}
+	return out, nil
+}
+
+// priceBucketIndex finds the bucket whose lower bound is min, in minor units.
+func priceBucketIndex(buckets []products.PriceBucket, min int64) int {
+	for i, b := range buckets {
+		if b.Min.Amount == min {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
+
+// productsSort orders a listing as described on products.ListFilter.SortBy.
+func productsSort(f products.ListFilter) bson.D {
+	dir := -1
+	if f.SortAsc {
+		dir = 1
+	}
+	switch {
+	case f.SortBy != "":
+		return bson.D{{Key: sortKeys[f.SortBy], Value: dir}, {Key: "_id", Value: dir}}
+	case f.Query != "":
+		return bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}
+	case f.LowStockOnly:
+		// most urgent first
+		return bson.D{{Key: "stock", Value: 1}, {Key: "_id", Value: 1}}
+	}
+	return bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}
+}
@@ -0,0 +1,375 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PromotionsRepo struct {
+	col            *mongo.Collection
+	redemptionsCol *mongo.Collection
+	base           money.Currency
+}
+
+// NewPromotionsRepo builds the repo. Amounts stored without a currency,
+// from before amounts carried one, are read as base.
+func NewPromotionsRepo(db *mongo.Database, base money.Currency) *PromotionsRepo {
+	return &PromotionsRepo{
+		col:            db.Collection("promotions"),
+		redemptionsCol: db.Collection("promotion_redemptions"),
+		base:           base,
+	}
+}
+
+type promotionDoc struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty"`
+	Code           string               `bson:"code"`
+	Description    string               `bson:"description,omitempty"`
+	Type           string               `bson:"type"`
+	Value          float64              `bson:"value"`
+	MinOrderTotal  decimalAmount        `bson:"minOrderTotal"`
+	Currency       string               `bson:"currency,omitempty"`
+	CategoryIDs    []primitive.ObjectID `bson:"categoryIds"`
+	ProductIDs     []primitive.ObjectID `bson:"productIds"`
+	MaxUses        int64                `bson:"maxUses"`
+	MaxUsesPerUser int64                `bson:"maxUsesPerUser"`
+	UsedCount      int64                `bson:"usedCount"`
+	StartsAt       *time.Time           `bson:"startsAt,omitempty"`
+	EndsAt         *time.Time           `bson:"endsAt,omitempty"`
+	Active         bool                 `bson:"active"`
+	CreatedAt      time.Time            `bson:"createdAt"`
+	UpdatedAt      time.Time            `bson:"updatedAt"`
+}
+
+type redemptionDoc struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	PromotionID primitive.ObjectID `bson:"promotionId"`
+	UserID      string             `bson:"userId"`
+	OrderID     primitive.ObjectID `bson:"orderId"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+}
+
+func (r *PromotionsRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	// per-user usage checks
+	_, err = r.redemptionsCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotionId", Value: 1}, {Key: "userId", Value: 1}},
+	})
+	return err
+}
+
+func (r *PromotionsRepo) List(ctx context.Context, f promotions.ListFilter) ([]promotions.Promotion, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(f.Offset).
+		SetLimit(f.Limit)
+
+	cur, err := r.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find promotions: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []promotionDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode promotions: %w", err)
+	}
+
+	out := make([]promotions.Promotion, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapPromotionDoc(d, r.base))
+	}
+	return out, nil
+}
+
+func (r *PromotionsRepo) Count(ctx context.Context) (int64, error) {
+	n, err := r.col.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("count promotions: %w", err)
+	}
+	return n, nil
+}
+
+func (r *PromotionsRepo) GetByID(ctx context.Context, id string) (promotions.Promotion, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return promotions.Promotion{}, promotions.ErrInvalidID
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *PromotionsRepo) GetByCode(ctx context.Context, code string) (promotions.Promotion, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+func (r *PromotionsRepo) findOne(ctx context.Context, filter bson.M) (promotions.Promotion, error) {
+	var d promotionDoc
+	if err := r.col.FindOne(ctx, filter).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return promotions.Promotion{}, promotions.ErrNotFound
+		}
+		return promotions.Promotion{}, fmt.Errorf("find promotion: %w", err)
+	}
+	return mapPromotionDoc(d, r.base), nil
+}
+
+func (r *PromotionsRepo) Create(ctx context.Context, p promotions.Promotion) (promotions.Promotion, error) {
+	catIDs, err := toObjectIDs(p.CategoryIDs)
+	if err != nil {
+		return promotions.Promotion{}, promotions.ErrInvalidScope
+	}
+	prodIDs, err := toObjectIDs(p.ProductIDs)
+	if err != nil {
+		return promotions.Promotion{}, promotions.ErrInvalidScope
+	}
+
+	doc := promotionDoc{
+		ID:             primitive.NewObjectID(),
+		Code:           p.Code,
+		Description:    p.Description,
+		Type:           string(p.Type),
+		Value:          p.Value,
+		MinOrderTotal:  toDecimal(p.MinOrderTotal),
+		Currency:       string(p.MinOrderTotal.Currency),
+		CategoryIDs:    catIDs,
+		ProductIDs:     prodIDs,
+		MaxUses:        p.MaxUses,
+		MaxUsesPerUser: p.MaxUsesPerUser,
+		StartsAt:       p.StartsAt,
+		EndsAt:         p.EndsAt,
+		Active:         p.Active,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return promotions.Promotion{}, promotions.ErrCodeTaken
+		}
+		return promotions.Promotion{}, fmt.Errorf("insert promotion: %w", err)
+	}
+
+	return mapPromotionDoc(doc, r.base), nil
+}
+
+func (r *PromotionsRepo) Update(ctx context.Context, id string, in promotions.UpdateInput) (promotions.Promotion, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return promotions.Promotion{}, promotions.ErrInvalidID
+	}
+
+	set := bson.M{
+		"updatedAt": time.Now().UTC(),
+	}
+	if in.Description != nil {
+		set["description"] = *in.Description
+	}
+	if in.Type != nil {
+		set["type"] = string(*in.Type)
+	}
+	if in.Value != nil {
+		set["value"] = *in.Value
+	}
+	if in.MinOrderTotal != nil {
+		set["minOrderTotal"] = toDecimal(*in.MinOrderTotal)
+		set["currency"] = string(in.MinOrderTotal.Currency)
+	}
+	if in.CategoryIDs != nil {
+		ids, err := toObjectIDs(*in.CategoryIDs)
+		if err != nil {
+			return promotions.Promotion{}, promotions.ErrInvalidScope
+		}
+		set["categoryIds"] = ids
+	}
+	if in.ProductIDs != nil {
+		ids, err := toObjectIDs(*in.ProductIDs)
+		if err != nil {
+			return promotions.Promotion{}, promotions.ErrInvalidScope
+		}
+		set["productIds"] = ids
+	}
+	if in.MaxUses != nil {
+		set["maxUses"] = *in.MaxUses
+	}
+	if in.MaxUsesPerUser != nil {
+		set["maxUsesPerUser"] = *in.MaxUsesPerUser
+	}
+	if in.StartsAt != nil {
+		set["startsAt"] = *in.StartsAt
+	}
+	if in.EndsAt != nil {
+		set["endsAt"] = *in.EndsAt
+	}
+	if in.Active != nil {
+		set["active"] = *in.Active
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d promotionDoc
+	err = r.col.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$set": set}, opts).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return promotions.Promotion{}, promotions.ErrNotFound
+		}
+		return promotions.Promotion{}, fmt.Errorf("update promotion: %w", err)
+	}
+
+	return mapPromotionDoc(d, r.base), nil
+}
+
+func (r *PromotionsRepo) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return promotions.ErrInvalidID
+	}
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("delete promotion: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return promotions.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PromotionsRepo) CountRedemptions(ctx context.Context, promotionID string, userID string) (int64, error) {
+	pid, err := primitive.ObjectIDFromHex(promotionID)
+	if err != nil {
+		return 0, promotions.ErrInvalidID
+	}
+
+	n, err := r.redemptionsCol.CountDocuments(ctx, bson.M{"promotionId": pid, "userId": userID})
+	if err != nil {
+		return 0, fmt.Errorf("count redemptions: %w", err)
+	}
+	return n, nil
+}
+
+func (r *PromotionsRepo) Redeem(ctx context.Context, rd promotions.Redemption) error {
+	pid, err := primitive.ObjectIDFromHex(rd.PromotionID)
+	if err != nil {
+		return promotions.ErrInvalidID
+	}
+	orderID, err := primitive.ObjectIDFromHex(rd.OrderID)
+	if err != nil {
+		return fmt.Errorf("redeem promotion: invalid order id %q", rd.OrderID)
+	}
+
+	// the counter only moves while under the global limit (maxUses 0 = unlimited)
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{
+			"_id": pid,
+			"$expr": bson.M{"$or": []interface{}{
+				bson.M{"$lte": []interface{}{"$maxUses", 0}},
+				bson.M{"$lt": []interface{}{"$usedCount", "$maxUses"}},
+			}},
+		},
+		bson.M{"$inc": bson.M{"usedCount": 1}},
+	)
+	if err != nil {
+		return fmt.Errorf("increment promotion usage: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return promotions.ErrUsageLimit
+	}
+
+	_, err = r.redemptionsCol.InsertOne(ctx, redemptionDoc{
+		ID:          primitive.NewObjectID(),
+		PromotionID: pid,
+		UserID:      rd.UserID,
+		OrderID:     orderID,
+		CreatedAt:   rd.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("insert redemption: %w", err)
+	}
+	return nil
+}
+
+func (r *PromotionsRepo) Release(ctx context.Context, promotionID string, orderID string) error {
+	pid, err := primitive.ObjectIDFromHex(promotionID)
+	if err != nil {
+		return promotions.ErrInvalidID
+	}
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return fmt.Errorf("release promotion: invalid order id %q", orderID)
+	}
+
+	// the redemption goes first so a repeated release can't lower the
+	// counter twice
+	res, err := r.redemptionsCol.DeleteOne(ctx, bson.M{"promotionId": pid, "orderId": oid})
+	if err != nil {
+		return fmt.Errorf("delete redemption: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return nil
+	}
+
+	_, err = r.col.UpdateOne(ctx,
+		bson.M{"_id": pid, "usedCount": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"usedCount": -1}},
+	)
+	if err != nil {
+		return fmt.Errorf("decrement promotion usage: %w", err)
+	}
+	return nil
+}
+
+func mapPromotionDoc(d promotionDoc, base money.Currency) promotions.Promotion {
+	return promotions.Promotion{
+		ID:             d.ID.Hex(),
+		Code:           d.Code,
+		Description:    d.Description,
+		Type:           promotions.DiscountType(d.Type),
+		Value:          d.Value,
+		MinOrderTotal:  d.MinOrderTotal.toMoney(d.Currency, base),
+		CategoryIDs:    toHexIDs(d.CategoryIDs),
+		ProductIDs:     toHexIDs(d.ProductIDs),
+		MaxUses:        d.MaxUses,
+		MaxUsesPerUser: d.MaxUsesPerUser,
+		UsedCount:      d.UsedCount,
+		StartsAt:       d.StartsAt,
+		EndsAt:         d.EndsAt,
+		Active:         d.Active,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func toObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	out := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, oid)
+	}
+	return out, nil
+}
+
+func toHexIDs(ids []primitive.ObjectID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.Hex())
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReservationsRepo struct {
+	col *mongo.Collection
+}
+
+func NewReservationsRepo(db *mongo.Database) *ReservationsRepo {
+	return &ReservationsRepo{col: db.Collection("reservations")}
+}
+
+type reservationDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"userId"`
+	ProductID primitive.ObjectID `bson:"productId"`
+	Quantity  int64              `bson:"quantity"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+func (r *ReservationsRepo) EnsureIndexes(ctx context.Context) error {
+	// no TTL index: expired holds must also be released on the product, which
+	// the sweeper does before deleting them
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "productId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}},
+	})
+	return err
+}
+
+func (r *ReservationsRepo) Get(ctx context.Context, userID, productID string) (reservations.Reservation, error) {
+	pid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return reservations.Reservation{}, reservations.ErrInvalidProduct
+	}
+
+	var d reservationDoc
+	if err := r.col.FindOne(ctx, bson.M{"userId": userID, "productId": pid}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return reservations.Reservation{}, reservations.ErrNotFound
+		}
+		return reservations.Reservation{}, fmt.Errorf("find reservation: %w", err)
+	}
+	return mapReservationDoc(d), nil
+}
+
+func (r *ReservationsRepo) ListByUser(ctx context.Context, userID string) ([]reservations.Reservation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	return r.find(ctx, bson.M{"userId": userID}, opts)
+}
+
+func (r *ReservationsRepo) ListExpired(ctx context.Context, now time.Time, limit int64) ([]reservations.Reservation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expiresAt", Value: 1}}).
+		SetLimit(limit)
+	return r.find(ctx, bson.M{"expiresAt": bson.M{"$lte": now}}, opts)
+}
+
+func (r *ReservationsRepo) Upsert(ctx context.Context, res reservations.Reservation) error {
+	pid, err := primitive.ObjectIDFromHex(res.ProductID)
+	if err != nil {
+		return reservations.ErrInvalidProduct
+	}
+
+	_, err = r.col.UpdateOne(ctx,
+		bson.M{"userId": res.UserID, "productId": pid},
+		bson.M{
+			"$set": bson.M{
+				"quantity":  res.Quantity,
+				"expiresAt": res.ExpiresAt,
+				"updatedAt": res.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{"createdAt": res.CreatedAt},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *ReservationsRepo) Delete(ctx context.Context, userID, productID string) (reservations.Reservation, error) {
+	pid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return reservations.Reservation{}, reservations.ErrInvalidProduct
+	}
+	return r.deleteOne(ctx, bson.M{"userId": userID, "productId": pid})
+}
+
+func (r *ReservationsRepo) DeleteExpired(ctx context.Context, userID, productID string, now time.Time) (reservations.Reservation, error) {
+	pid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return reservations.Reservation{}, reservations.ErrInvalidProduct
+	}
+	return r.deleteOne(ctx, bson.M{"userId": userID, "productId": pid, "expiresAt": bson.M{"$lte": now}})
+}
+
+func (r *ReservationsRepo) deleteOne(ctx context.Context, filter bson.M) (reservations.Reservation, error) {
+	var d reservationDoc
+	if err := r.col.FindOneAndDelete(ctx, filter).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return reservations.Reservation{}, reservations.ErrNotFound
+		}
+		return reservations.Reservation{}, fmt.Errorf("delete reservation: %w", err)
+	}
+	return mapReservationDoc(d), nil
+}
+
+func (r *ReservationsRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]reservations.Reservation, error) {
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find reservations: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []reservationDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode reservations: %w", err)
+	}
+
+	out := make([]reservations.Reservation, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapReservationDoc(d))
+	}
+	return out, nil
+}
+
+func mapReservationDoc(d reservationDoc) reservations.Reservation {
+	return reservations.Reservation{
+		UserID:    d.UserID,
+		ProductID: d.ProductID.Hex(),
+		Quantity:  d.Quantity,
+		ExpiresAt: d.ExpiresAt,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
@@ -0,0 +1,315 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/returns"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReturnsRepo struct {
+	col  *mongo.Collection
+	base money.Currency
+}
+
+// NewReturnsRepo builds the repo. Amounts stored without a currency, from
+// before amounts carried one, are read as base.
+func NewReturnsRepo(db *mongo.Database, base money.Currency) *ReturnsRepo {
+	return &ReturnsRepo{col: db.Collection("returns"), base: base}
+}
+
+type returnItemDoc struct {
+	ProductID    primitive.ObjectID `bson:"productId"`
+	ProductName  string             `bson:"productName"`
+	Quantity     int64              `bson:"quantity"`
+	UnitPrice    decimalAmount      `bson:"unitPrice"`
+	RefundAmount decimalAmount      `bson:"refundAmount"`
+}
+
+type returnDoc struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID       primitive.ObjectID `bson:"orderId"`
+	UserID        string             `bson:"userId"`
+	Items         []returnItemDoc    `bson:"items"`
+	Reason        string             `bson:"reason"`
+	Status        string             `bson:"status"`
+	RefundAmount  decimalAmount      `bson:"refundAmount"`
+	Currency      string             `bson:"currency,omitempty"`
+	Restocked     bool               `bson:"restocked"`
+	StatusHistory []statusChangeDoc  `bson:"statusHistory,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt"`
+}
+
+func (r *ReturnsRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ReturnsRepo) Create(ctx context.Context, rt returns.Return) (returns.Return, error) {
+	orderOID, err := primitive.ObjectIDFromHex(rt.OrderID)
+	if err != nil {
+		return returns.Return{}, returns.ErrInvalidID
+	}
+
+	items := make([]returnItemDoc, 0, len(rt.Items))
+	for _, it := range rt.Items {
+		pid, err := primitive.ObjectIDFromHex(it.ProductID)
+		if err != nil {
+			return returns.Return{}, returns.ErrInvalidProduct
+		}
+		items = append(items, returnItemDoc{
+			ProductID:    pid,
+			ProductName:  it.ProductName,
+			Quantity:     it.Quantity,
+			UnitPrice:    toDecimal(it.UnitPrice),
+			RefundAmount: toDecimal(it.RefundAmount),
+		})
+	}
+
+	history := make([]statusChangeDoc, 0, len(rt.StatusHistory))
+	for _, ch := range rt.StatusHistory {
+		history = append(history, toReturnStatusChangeDoc(ch))
+	}
+
+	doc := returnDoc{
+		ID:            primitive.NewObjectID(),
+		OrderID:       orderOID,
+		UserID:        rt.UserID,
+		Items:         items,
+		Reason:        rt.Reason,
+		Status:        string(rt.Status),
+		RefundAmount:  toDecimal(rt.RefundAmount),
+		Currency:      string(rt.RefundAmount.Currency),
+		Restocked:     rt.Restocked,
+		StatusHistory: history,
+		CreatedAt:     rt.CreatedAt,
+		UpdatedAt:     rt.UpdatedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		return returns.Return{}, fmt.Errorf("insert return: %w", err)
+	}
+
+	rt.ID = doc.ID.Hex()
+	return rt, nil
+}
+
+func (r *ReturnsRepo) GetByID(ctx context.Context, id string, userID *string) (returns.Return, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return returns.Return{}, returns.ErrInvalidID
+	}
+
+	filter := bson.M{"_id": oid}
+	if userID != nil {
+		filter["userId"] = *userID
+	}
+
+	var d returnDoc
+	if err := r.col.FindOne(ctx, filter).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return returns.Return{}, returns.ErrNotFound
+		}
+		return returns.Return{}, fmt.Errorf("find return: %w", err)
+	}
+	return mapReturnDoc(d, r.base), nil
+}
+
+func (r *ReturnsRepo) List(ctx context.Context, userID *string, f returns.ListFilter) ([]returns.Return, error) {
+	filter, err := returnListFilter(userID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(f.Offset).
+		SetLimit(f.Limit)
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find returns: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []returnDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode returns: %w", err)
+	}
+
+	out := make([]returns.Return, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapReturnDoc(d, r.base))
+	}
+	return out, nil
+}
+
+func (r *ReturnsRepo) Count(ctx context.Context, userID *string, f returns.ListFilter) (int64, error) {
+	filter, err := returnListFilter(userID, f)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count returns: %w", err)
+	}
+	return n, nil
+}
+
+func (r *ReturnsRepo) UpdateStatus(ctx context.Context, id string, ch returns.StatusChange, restocked bool) (returns.Return, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return returns.Return{}, returns.ErrInvalidID
+	}
+
+	set := bson.M{"status": string(ch.To), "updatedAt": ch.ChangedAt}
+	if restocked {
+		set["restocked"] = true
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d returnDoc
+	err = r.col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": oid, "status": string(ch.From)},
+		bson.M{
+			"$set":  set,
+			"$push": bson.M{"statusHistory": toReturnStatusChangeDoc(ch)},
+		},
+		opts,
+	).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			n, err := r.col.CountDocuments(ctx, bson.M{"_id": oid})
+			if err != nil {
+				return returns.Return{}, fmt.Errorf("check return: %w", err)
+			}
+			if n == 0 {
+				return returns.Return{}, returns.ErrNotFound
+			}
+			return returns.Return{}, returns.ErrInvalidTransition
+		}
+		return returns.Return{}, fmt.Errorf("update return status: %w", err)
+	}
+
+	return mapReturnDoc(d, r.base), nil
+}
+
+func (r *ReturnsRepo) ReturnedQuantities(ctx context.Context, orderID string) (map[string]int64, error) {
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, returns.ErrInvalidID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"orderId": oid,
+			"status":  bson.M{"$ne": string(returns.StatusRejected)},
+		}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$items.productId",
+			"quantity": bson.M{"$sum": "$items.quantity"},
+		}}},
+	}
+
+	cur, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate returned quantities: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Quantity  int64              `bson:"quantity"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("decode returned quantities: %w", err)
+	}
+
+	out := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		out[row.ProductID.Hex()] = row.Quantity
+	}
+	return out, nil
+}
+
+func returnListFilter(userID *string, f returns.ListFilter) (bson.M, error) {
+	filter := bson.M{}
+	if userID != nil {
+		filter["userId"] = *userID
+	}
+	if f.Status != nil {
+		filter["status"] = string(*f.Status)
+	}
+	if f.OrderID != nil {
+		oid, err := primitive.ObjectIDFromHex(*f.OrderID)
+		if err != nil {
+			return nil, returns.ErrInvalidID
+		}
+		filter["orderId"] = oid
+	}
+	return filter, nil
+}
+
+func toReturnStatusChangeDoc(ch returns.StatusChange) statusChangeDoc {
+	return statusChangeDoc{
+		From:      string(ch.From),
+		To:        string(ch.To),
+		ChangedBy: ch.ChangedBy,
+		Note:      ch.Note,
+		ChangedAt: ch.ChangedAt,
+	}
+}
+
+func mapReturnDoc(d returnDoc, base money.Currency) returns.Return {
+	items := make([]returns.Item, 0, len(d.Items))
+	for _, it := range d.Items {
+		items = append(items, returns.Item{
+			ProductID:    it.ProductID.Hex(),
+			ProductName:  it.ProductName,
+			Quantity:     it.Quantity,
+			UnitPrice:    it.UnitPrice.toMoney(d.Currency, base),
+			RefundAmount: it.RefundAmount.toMoney(d.Currency, base),
+		})
+	}
+
+	history := make([]returns.StatusChange, 0, len(d.StatusHistory))
+	for _, ch := range d.StatusHistory {
+		history = append(history, returns.StatusChange{
+			From:      returns.Status(ch.From),
+			To:        returns.Status(ch.To),
+			ChangedBy: ch.ChangedBy,
+			Note:      ch.Note,
+			ChangedAt: ch.ChangedAt,
+		})
+	}
+
+	return returns.Return{
+		ID:            d.ID.Hex(),
+		OrderID:       d.OrderID.Hex(),
+		UserID:        d.UserID,
+		Items:         items,
+		Reason:        d.Reason,
+		Status:        returns.Status(d.Status),
+		RefundAmount:  d.RefundAmount.toMoney(d.Currency, base),
+		Restocked:     d.Restocked,
+		StatusHistory: history,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/statistics"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,13 +15,17 @@ type StatisticsRepo struct {
 	ordersCol     *mongo.Collection
 	productsCol   *mongo.Collection
 	categoriesCol *mongo.Collection
+	base          money.Currency
 }
 
-func NewStatisticsRepo(db *mongo.Database) *StatisticsRepo {
+// NewStatisticsRepo builds the repo. Sales figures are summed from the
+// base-currency amounts on orders, never their display amounts.
+func NewStatisticsRepo(db *mongo.Database, base money.Currency) *StatisticsRepo {
 	return &StatisticsRepo{
 		ordersCol:     db.Collection("orders"),
 		productsCol:   db.Collection("products"),
 		categoriesCol: db.Collection("categories"),
+		base:          base,
 	}
 }
 
@@ -62,22 +67,15 @@ func (r *StatisticsRepo) getSalesStats(ctx context.Context, filter bson.M) (stat
 				}},
 			},
 			"totals": []bson.M{
-				{"$unwind": "$items"},
-				{"$lookup": bson.M{
-					"from":         "products",
-					"localField":   "items.productId",
-					"foreignField": "_id",
-					"as":           "product",
-				}},
-				{"$unwind": bson.M{"path": "$product", "preserveNullAndEmptyArrays": true}},
+				// revenue comes from the totals snapshotted at checkout (already net of discounts),
+				// not current product prices, minus refunds for returned items
 				{"$group": bson.M{
-					"_id":          nil,
-					"totalOrders":  bson.M{"$addToSet": "$_id"},
-					"totalRevenue": bson.M{"$sum": bson.M{"$multiply": []interface{}{"$items.quantity", bson.M{"$ifNull": []interface{}{"$product.price", 0}}}}},
-				}},
-				{"$project": bson.M{
-					"totalOrders":  bson.M{"$size": "$totalOrders"},
-					"totalRevenue": 1,
+					"_id":            nil,
+					"totalOrders":    bson.M{"$sum": 1},
+					"grossRevenue":   bson.M{"$sum": bson.M{"$ifNull": []interface{}{"$totalPrice", 0}}},
+					"totalDiscounts": bson.M{"$sum": bson.M{"$sum": "$discounts.amount"}},
+					"totalRefunds":   bson.M{"$sum": bson.M{"$sum": "$refunds.amount"}},
+					"totalTax":       bson.M{"$sum": bson.M{"$sum": "$taxes.amount"}},
 				}},
 			},
 		}}},
@@ -95,8 +93,11 @@ func (r *StatisticsRepo) getSalesStats(ctx context.Context, filter bson.M) (stat
 			Count int64  `bson:"count"`
 		} `bson:"statusCounts"`
 		Totals []struct {
-			TotalOrders  int64   `bson:"totalOrders"`
-			TotalRevenue float64 `bson:"totalRevenue"`
+			TotalOrders    int64         `bson:"totalOrders"`
+			GrossRevenue   decimalAmount `bson:"grossRevenue"`
+			TotalDiscounts decimalAmount `bson:"totalDiscounts"`
+			TotalRefunds   decimalAmount `bson:"totalRefunds"`
+			TotalTax       decimalAmount `bson:"totalTax"`
 		} `bson:"totals"`
 	}
 
@@ -104,13 +105,24 @@ func (r *StatisticsRepo) getSalesStats(ctx context.Context, filter bson.M) (stat
 		return statistics.SalesStatistics{}, fmt.Errorf("decode sales stats: %w", err)
 	}
 
-	stats := statistics.SalesStatistics{}
+	currency := string(r.base)
+	stats := statistics.SalesStatistics{
+		TotalRevenue:   money.Zero(r.base),
+		TotalDiscounts: money.Zero(r.base),
+		TotalRefunds:   money.Zero(r.base),
+		TotalTax:       money.Zero(r.base),
+		AverageOrder:   money.Zero(r.base),
+	}
 
 	if len(results) > 0 {
 		for _, sc := range results[0].StatusCounts {
 			switch sc.ID {
 			case "pending":
 				stats.PendingOrders = sc.Count
+			case "paid":
+				stats.PaidOrders = sc.Count
+			case "partially_shipped":
+				stats.PartiallyShippedOrders = sc.Count
 			case "shipped":
 				stats.ShippedOrders = sc.Count
 			case "delivered":
@@ -122,17 +134,20 @@ func (r *StatisticsRepo) getSalesStats(ctx context.Context, filter bson.M) (stat
 
 		if len(results[0].Totals) > 0 {
 			stats.TotalOrders = results[0].Totals[0].TotalOrders
-			stats.TotalRevenue = results[0].Totals[0].TotalRevenue
+			stats.TotalRefunds = results[0].Totals[0].TotalRefunds.toMoney(currency, r.base)
+			stats.TotalRevenue = results[0].Totals[0].GrossRevenue.toMoney(currency, r.base).Sub(stats.TotalRefunds)
+			stats.TotalDiscounts = results[0].Totals[0].TotalDiscounts.toMoney(currency, r.base)
+			stats.TotalTax = results[0].Totals[0].TotalTax.toMoney(currency, r.base)
 		}
 	}
 
 	// Calculate total from status counts if totals pipeline didn't return results
 	if stats.TotalOrders == 0 {
-		stats.TotalOrders = stats.PendingOrders + stats.ShippedOrders + stats.DeliveredOrders + stats.CancelledOrders
+		stats.TotalOrders = stats.PendingOrders + stats.PaidOrders + stats.PartiallyShippedOrders + stats.ShippedOrders + stats.DeliveredOrders + stats.CancelledOrders
 	}
 
 	if stats.TotalOrders > 0 {
-		stats.AverageOrder = stats.TotalRevenue / float64(stats.TotalOrders)
+		stats.AverageOrder = stats.TotalRevenue.MulRatio(1, stats.TotalOrders)
 	}
 
 	return stats, nil
@@ -0,0 +1,231 @@
+package mongorepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+)
+
+type StockRepo struct {
+	col         *mongo.Collection
+	productsCol *mongo.Collection
+}
+
+func NewStockRepo(db *mongo.Database) *StockRepo {
+	return &StockRepo{
+		col:         db.Collection("stock_movements"),
+		productsCol: db.Collection("products"),
+	}
+}
+
+type stockMovementDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID primitive.ObjectID `bson:"productId"`
+	Delta     int64              `bson:"delta"`
+	Reason    string             `bson:"reason"`
+	ActorID   string             `bson:"actorId,omitempty"`
+	Reference string             `bson:"reference,omitempty"`
+	Note      string             `bson:"note,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+func (r *StockRepo) EnsureIndexes(ctx context.Context) error {
+	// backs a product's history, newest first, and its ledger sum
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "productId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (r *StockRepo) Append(ctx context.Context, ms ...stock.Movement) error {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(ms))
+	for _, m := range ms {
+		pid, err := primitive.ObjectIDFromHex(m.ProductID)
+		if err != nil {
+			return stock.ErrInvalidID
+		}
+		docs = append(docs, stockMovementDoc{
+			ProductID: pid,
+			Delta:     m.Delta,
+			Reason:    string(m.Reason),
+			ActorID:   m.ActorID,
+			Reference: m.Reference,
+			Note:      m.Note,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+
+	if _, err := r.col.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("insert stock movements: %w", err)
+	}
+	return nil
+}
+
+func (r *StockRepo) List(ctx context.Context, f stock.ListFilter) ([]stock.Movement, error) {
+	pid, err := primitive.ObjectIDFromHex(f.ProductID)
+	if err != nil {
+		return nil, stock.ErrInvalidID
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(f.Offset).
+		SetLimit(f.Limit)
+
+	cur, err := r.col.Find(ctx, bson.M{"productId": pid}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find stock movements: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []stockMovementDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode stock movements: %w", err)
+	}
+
+	out := make([]stock.Movement, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapStockMovementDoc(d))
+	}
+	return out, nil
+}
+
+func (r *StockRepo) Count(ctx context.Context, f stock.ListFilter) (int64, error) {
+	pid, err := primitive.ObjectIDFromHex(f.ProductID)
+	if err != nil {
+		return 0, stock.ErrInvalidID
+	}
+
+	n, err := r.col.CountDocuments(ctx, bson.M{"productId": pid})
+	if err != nil {
+		return 0, fmt.Errorf("count stock movements: %w", err)
+	}
+	return n, nil
+}
+
+// Reconcile reads stock and sums the ledger separately, rather than a
+// $lookup per product, so the whole ledger is scanned once. Both reads run in
+// one snapshot transaction: stock and its movements are written together, so
+// a change committed between the two reads would otherwise show as drift.
+func (r *StockRepo) Reconcile(ctx context.Context, productID string) ([]stock.Reconciliation, error) {
+	productsFilter := bson.M{}
+	ledgerFilter := bson.M{}
+	if productID != "" {
+		pid, err := primitive.ObjectIDFromHex(productID)
+		if err != nil {
+			return nil, stock.ErrInvalidID
+		}
+		productsFilter["_id"] = pid
+		ledgerFilter["productId"] = pid
+	}
+
+	// inside a caller's transaction the reads already share its snapshot
+	if mongo.SessionFromContext(ctx) != nil {
+		return r.reconcile(ctx, productsFilter, ledgerFilter, productID != "")
+	}
+
+	sess, err := r.col.Database().Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	defer sess.EndSession(ctx)
+
+	var out []stock.Reconciliation
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		var err error
+		out, err = r.reconcile(sc, productsFilter, ledgerFilter, productID != "")
+		return nil, err
+	}, options.Transaction().SetReadConcern(readconcern.Snapshot()))
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *StockRepo) reconcile(ctx context.Context, productsFilter, ledgerFilter bson.M, one bool) ([]stock.Reconciliation, error) {
+	cur, err := r.col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: ledgerFilter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$productId",
+			"ledger":    bson.M{"$sum": "$delta"},
+			"movements": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aggregate stock ledger: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var sums []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Ledger    int64              `bson:"ledger"`
+		Movements int64              `bson:"movements"`
+	}
+	if err := cur.All(ctx, &sums); err != nil {
+		return nil, fmt.Errorf("decode stock ledger: %w", err)
+	}
+	ledger := make(map[primitive.ObjectID]int, len(sums))
+	for i, s := range sums {
+		ledger[s.ProductID] = i
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "stock": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	pcur, err := r.productsCol.Find(ctx, productsFilter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find products: %w", err)
+	}
+	defer pcur.Close(ctx)
+
+	var prods []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Name  string             `bson:"name"`
+		Stock int64              `bson:"stock"`
+	}
+	if err := pcur.All(ctx, &prods); err != nil {
+		return nil, fmt.Errorf("decode products: %w", err)
+	}
+	if one && len(prods) == 0 {
+		return nil, stock.ErrNotFound
+	}
+
+	out := make([]stock.Reconciliation, 0, len(prods))
+	for _, p := range prods {
+		rec := stock.Reconciliation{
+			ProductID:   p.ID.Hex(),
+			ProductName: p.Name,
+			Stock:       p.Stock,
+		}
+		if i, ok := ledger[p.ID]; ok {
+			rec.Ledger = sums[i].Ledger
+			rec.Movements = sums[i].Movements
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+func mapStockMovementDoc(d stockMovementDoc) stock.Movement {
+	return stock.Movement{
+		ID:        d.ID.Hex(),
+		ProductID: d.ProductID.Hex(),
+		Delta:     d.Delta,
+		Reason:    stock.Reason(d.Reason),
+		ActorID:   d.ActorID,
+		Reference: d.Reference,
+		Note:      d.Note,
+		CreatedAt: d.CreatedAt,
+	}
+}
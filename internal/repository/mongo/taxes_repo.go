@@ -0,0 +1,218 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaxesRepo struct {
+	col *mongo.Collection
+}
+
+func NewTaxesRepo(db *mongo.Database) *TaxesRepo {
+	return &TaxesRepo{col: db.Collection("tax_rules")}
+}
+
+type taxRuleDoc struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty"`
+	Name       string              `bson:"name"`
+	Rate       float64             `bson:"rate"`
+	Inclusive  bool                `bson:"inclusive"`
+	CategoryID *primitive.ObjectID `bson:"categoryId,omitempty"`
+	Country    string              `bson:"country,omitempty"`
+	Region     string              `bson:"region,omitempty"`
+	Active     bool                `bson:"active"`
+	CreatedAt  time.Time           `bson:"createdAt"`
+	UpdatedAt  time.Time           `bson:"updatedAt"`
+}
+
+func (r *TaxesRepo) List(ctx context.Context, f taxes.ListFilter) ([]taxes.Rule, error) {
+	filter := bson.M{}
+	if f.ActiveOnly {
+		filter["active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find tax rules: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []taxRuleDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode tax rules: %w", err)
+	}
+
+	out := make([]taxes.Rule, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, mapTaxRuleDoc(d))
+	}
+	return out, nil
+}
+
+func (r *TaxesRepo) GetByID(ctx context.Context, id string) (taxes.Rule, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return taxes.Rule{}, taxes.ErrInvalidID
+	}
+
+	var d taxRuleDoc
+	if err := r.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return taxes.Rule{}, taxes.ErrNotFound
+		}
+		return taxes.Rule{}, fmt.Errorf("find tax rule: %w", err)
+	}
+	return mapTaxRuleDoc(d), nil
+}
+
+func (r *TaxesRepo) Create(ctx context.Context, rule taxes.Rule) (taxes.Rule, error) {
+	catID, err := optionalObjectID(rule.CategoryID)
+	if err != nil {
+		return taxes.Rule{}, taxes.ErrInvalidCategory
+	}
+
+	doc := taxRuleDoc{
+		ID:         primitive.NewObjectID(),
+		Name:       rule.Name,
+		Rate:       rule.Rate,
+		Inclusive:  rule.Inclusive,
+		CategoryID: catID,
+		Country:    rule.Country,
+		Region:     rule.Region,
+		Active:     rule.Active,
+		CreatedAt:  rule.CreatedAt,
+		UpdatedAt:  rule.UpdatedAt,
+	}
+
+	if _, err := r.col.InsertOne(ctx, doc); err != nil {
+		return taxes.Rule{}, fmt.Errorf("insert tax rule: %w", err)
+	}
+
+	rule.ID = doc.ID.Hex()
+	return rule, nil
+}
+
+func (r *TaxesRepo) Update(ctx context.Context, id string, in taxes.UpdateInput) (taxes.Rule, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return taxes.Rule{}, taxes.ErrInvalidID
+	}
+
+	set := bson.M{
+		"updatedAt": time.Now().UTC(),
+	}
+	// an empty string widens the rule back to everything
+	unset := bson.M{}
+	if in.Name != nil {
+		set["name"] = *in.Name
+	}
+	if in.Rate != nil {
+		set["rate"] = *in.Rate
+	}
+	if in.Inclusive != nil {
+		set["inclusive"] = *in.Inclusive
+	}
+	if in.CategoryID != nil {
+		catID, err := optionalObjectID(*in.CategoryID)
+		if err != nil {
+			return taxes.Rule{}, taxes.ErrInvalidCategory
+		}
+		if catID == nil {
+			unset["categoryId"] = ""
+		} else {
+			set["categoryId"] = *catID
+		}
+	}
+	if in.Country != nil {
+		if *in.Country == "" {
+			unset["country"] = ""
+		} else {
+			set["country"] = *in.Country
+		}
+	}
+	if in.Region != nil {
+		if *in.Region == "" {
+			unset["region"] = ""
+		} else {
+			set["region"] = *in.Region
+		}
+	}
+	if in.Active != nil {
+		set["active"] = *in.Active
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d taxRuleDoc
+	err = r.col.FindOneAndUpdate(ctx, bson.M{"_id": oid}, update, opts).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return taxes.Rule{}, taxes.ErrNotFound
+		}
+		return taxes.Rule{}, fmt.Errorf("update tax rule: %w", err)
+	}
+
+	return mapTaxRuleDoc(d), nil
+}
+
+func (r *TaxesRepo) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return taxes.ErrInvalidID
+	}
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("delete tax rule: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return taxes.ErrNotFound
+	}
+	return nil
+}
+
+func mapTaxRuleDoc(d taxRuleDoc) taxes.Rule {
+	rule := taxes.Rule{
+		ID:        d.ID.Hex(),
+		Name:      d.Name,
+		Rate:      d.Rate,
+		Inclusive: d.Inclusive,
+		Country:   d.Country,
+		Region:    d.Region,
+		Active:    d.Active,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	if d.CategoryID != nil {
+		rule.CategoryID = d.CategoryID.Hex()
+	}
+	return rule
+}
+
+// optionalObjectID parses id, treating an empty string as no id.
+func optionalObjectID(id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return &oid, nil
+}
@@ -0,0 +1,48 @@
+package mongorepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnitOfWork runs callbacks inside a Mongo multi-document transaction.
+// Requires a replica set or sharded cluster (Atlas is fine; a bare standalone mongod is not).
+type UnitOfWork struct {
+	client *mongo.Client
+}
+
+func NewUnitOfWork(client *mongo.Client) *UnitOfWork {
+	return &UnitOfWork{client: client}
+}
+
+var _ uow.UnitOfWork = (*UnitOfWork)(nil)
+
+func (u *UnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// already inside a transaction: join it instead of starting a nested one
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	sess, err := u.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer sess.EndSession(ctx)
+
+	// the session context is passed down, so every collection call in fn joins the transaction.
+	// WithTransaction may retry fn; only the committed attempt's hooks run.
+	var runHooks func(ctx context.Context)
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		hctx, run := uow.WithHooks(sc)
+		runHooks = run
+		return nil, fn(hctx)
+	})
+	if err != nil {
+		return err
+	}
+	runHooks(ctx)
+	return nil
+}
@@ -25,33 +25,91 @@ func RegisterRoutes(r *gin.Engine, c *app.Container) {
 
 	// public products
 	v1.GET("/products", c.Products.List)
+	v1.GET("/products/facets", c.Products.Facets)
 	v1.GET("/products/:id", c.Products.Get)
 
-	v1.POST("/products/:id/reviews", middleware.AuthRequired(c.JWT), c.Products.AddReview)
+	v1.GET("/delivery-methods", c.Delivery.List)
+	v1.GET("/currencies", c.Currencies.List)
+
+	v1.POST("/products/:id/reviews", middleware.AuthRequired(c.JWT), c.Idempotent, c.Products.AddReview)
 	v1.DELETE("/products/:id/reviews/:reviewId", middleware.AuthRequired(c.JWT), c.Products.DeleteReview)
 
 	// orders: auth required (user + admin)
 	ordersGroup := v1.Group("/orders")
-	ordersGroup.Use(middleware.AuthRequired(c.JWT))
+	ordersGroup.Use(middleware.AuthRequired(c.JWT), c.Idempotent)
 	ordersGroup.POST("", c.Orders.Create)
 	ordersGroup.GET("", c.Orders.List)
 	ordersGroup.GET("/:id", c.Orders.Get)
+	ordersGroup.POST("/:id/cancel", c.Orders.Cancel)
+	ordersGroup.POST("/:id/pay", c.Payments.Pay)
+	ordersGroup.GET("/:id/invoice", c.Invoices.Get)
+	ordersGroup.POST("/:id/returns", c.Returns.Create)
+
+	// returns: auth required (user + admin)
+	returnsGroup := v1.Group("/returns")
+	returnsGroup.Use(middleware.AuthRequired(c.JWT))
+	returnsGroup.GET("", c.Returns.List)
+	returnsGroup.GET("/:id", c.Returns.Get)
+
+	// payment provider callbacks: authenticated by signature, not JWT
+	v1.POST("/payments/webhook", c.Payments.Webhook)
+
+	// cart: auth required
+	cartGroup := v1.Group("/cart")
+	cartGroup.Use(middleware.AuthRequired(c.JWT), c.Idempotent)
+	cartGroup.GET("", c.Cart.Get)
+	cartGroup.DELETE("", c.Cart.Clear)
+	cartGroup.POST("/items", c.Cart.AddItem)
+	cartGroup.PUT("/items/:productId", c.Cart.UpdateItem)
+	cartGroup.DELETE("/items/:productId", c.Cart.RemoveItem)
+	cartGroup.POST("/checkout", c.Cart.Checkout)
 
 	// admin products
 	admin := v1.Group("/admin")
-	admin.Use(middleware.AuthRequired(c.JWT), middleware.AdminOnly())
+	admin.Use(middleware.AuthRequired(c.JWT), middleware.AdminOnly(), c.Idempotent)
 
 	admin.POST("/products", c.Products.Create)
 	admin.PUT("/products/:id", c.Products.Update)
 	admin.DELETE("/products/:id", c.Products.Delete)
+	admin.GET("/products/low-stock", c.Products.LowStock)
+	admin.GET("/products/:id/stock-history", c.Stock.History)
+	admin.GET("/stock/reconciliation", c.Stock.Reconcile)
 
 	admin.POST("/categories", c.Categories.Create)
 	admin.PUT("/categories/:id", c.Categories.Update)
 	admin.DELETE("/categories/:id", c.Categories.Delete)
 
 	admin.PUT("/orders/:id/status", c.Orders.UpdateStatus)
+	admin.GET("/orders/export", c.Orders.Export)
 	admin.GET("/orders/:id", c.Orders.Get)
 	admin.POST("/orders/find", c.Orders.FindOrderByID)
+	admin.POST("/orders/:id/refund", c.Payments.Refund)
+	admin.POST("/orders/:id/shipments", c.Orders.AddShipment)
+	admin.POST("/orders/:id/shipments/:shipmentId/deliver", c.Orders.DeliverShipment)
+
+	admin.POST("/returns/:id/approve", c.Returns.Approve)
+	admin.POST("/returns/:id/reject", c.Returns.Reject)
+	admin.POST("/returns/:id/receive", c.Returns.Receive)
+
+	admin.GET("/promotions", c.Promotions.List)
+	admin.GET("/promotions/:id", c.Promotions.Get)
+	admin.POST("/promotions", c.Promotions.Create)
+	admin.PUT("/promotions/:id", c.Promotions.Update)
+	admin.DELETE("/promotions/:id", c.Promotions.Delete)
+
+	admin.GET("/delivery-methods", c.Delivery.AdminList)
+	admin.POST("/delivery-methods", c.Delivery.Create)
+	admin.PUT("/delivery-methods/:id", c.Delivery.Update)
+	admin.DELETE("/delivery-methods/:id", c.Delivery.Delete)
+
+	admin.GET("/tax-rules", c.Taxes.List)
+	admin.GET("/tax-rules/:id", c.Taxes.Get)
+	admin.POST("/tax-rules", c.Taxes.Create)
+	admin.PUT("/tax-rules/:id", c.Taxes.Update)
+	admin.DELETE("/tax-rules/:id", c.Taxes.Delete)
+
+	admin.PUT("/currencies/:code", c.Currencies.Set)
+	admin.DELETE("/currencies/:code", c.Currencies.Delete)
 
 	// admin stats (GET with query: year OR start&end; if year and start both present, use year)
 	admin.GET("/stats/sales", c.Statistics.GetSalesStats)
@@ -68,7 +126,7 @@ func RegisterRoutes(r *gin.Engine, c *app.Container) {
 
 	// wishlist: auth required
 	wishlistGroup := v1.Group("/wishlist")
-	wishlistGroup.Use(middleware.AuthRequired(c.JWT))
+	wishlistGroup.Use(middleware.AuthRequired(c.JWT), c.Idempotent)
 	wishlistGroup.POST("", c.Wishlist.Add)
 	wishlistGroup.GET("", c.Wishlist.List)
 	wishlistGroup.DELETE("/:id", c.Wishlist.Delete)
@@ -0,0 +1,274 @@
+package cartsvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/cart"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+type Service struct {
+	repo         cart.Repo
+	productsRepo products.Repo
+	ordersSvc    orders.Service
+	holds        reservations.Service
+	tx           uow.UnitOfWork
+	base         money.Currency
+	now          func() time.Time
+}
+
+// New builds the cart service. Cart totals are in base, the currency product
+// prices are stored in. Every line holds its quantity through holds until
+// checkout, removal or expiry.
+func New(repo cart.Repo, productsRepo products.Repo, ordersSvc orders.Service, holds reservations.Service, tx uow.UnitOfWork, base money.Currency) *Service {
+	return &Service{
+		repo:         repo,
+		productsRepo: productsRepo,
+		ordersSvc:    ordersSvc,
+		holds:        holds,
+		tx:           tx,
+		base:         base,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ cart.Service = (*Service)(nil)
+
+func (s *Service) Get(ctx context.Context, userID string) (cart.Cart, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return cart.Cart{}, cart.ErrInvalidID
+	}
+
+	c, err := s.repo.Get(ctx, uid)
+	if err != nil {
+		return cart.Cart{}, err
+	}
+	if err := s.fillPrices(ctx, &c); err != nil {
+		return cart.Cart{}, err
+	}
+	return c, nil
+}
+
+func (s *Service) AddItem(ctx context.Context, userID string, in cart.AddItemInput) (cart.Cart, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return cart.Cart{}, cart.ErrInvalidID
+	}
+	pid := strings.TrimSpace(in.ProductID)
+	if pid == "" {
+		return cart.Cart{}, cart.ErrInvalidProduct
+	}
+	if in.Quantity <= 0 {
+		return cart.Cart{}, cart.ErrInvalidQty
+	}
+
+	c, err := s.repo.Get(ctx, uid)
+	if err != nil {
+		return cart.Cart{}, err
+	}
+
+	// adding a product that is already in the cart tops up its quantity
+	item := cart.Item{ProductID: pid, Quantity: in.Quantity, AddedAt: s.now()}
+	for _, it := range c.Items {
+		if it.ProductID == pid {
+			item.Quantity += it.Quantity
+			item.AddedAt = it.AddedAt
+			break
+		}
+	}
+
+	if err := s.setItem(ctx, uid, item); err != nil {
+		return cart.Cart{}, err
+	}
+	return s.Get(ctx, uid)
+}
+
+func (s *Service) UpdateItem(ctx context.Context, userID string, productID string, qty int64) (cart.Cart, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return cart.Cart{}, cart.ErrInvalidID
+	}
+	pid := strings.TrimSpace(productID)
+	if pid == "" {
+		return cart.Cart{}, cart.ErrInvalidProduct
+	}
+	if qty <= 0 {
+		return cart.Cart{}, cart.ErrInvalidQty
+	}
+
+	c, err := s.repo.Get(ctx, uid)
+	if err != nil {
+		return cart.Cart{}, err
+	}
+
+	var (
+		item  cart.Item
+		found bool
+	)
+	for _, it := range c.Items {
+		if it.ProductID == pid {
+			item, found = it, true
+			break
+		}
+	}
+	if !found {
+		return cart.Cart{}, cart.ErrItemNotFound
+	}
+	item.Quantity = qty
+
+	if err := s.setItem(ctx, uid, item); err != nil {
+		return cart.Cart{}, err
+	}
+	return s.Get(ctx, uid)
+}
+
+func (s *Service) RemoveItem(ctx context.Context, userID string, productID string) (cart.Cart, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return cart.Cart{}, cart.ErrInvalidID
+	}
+	pid := strings.TrimSpace(productID)
+	if pid == "" {
+		return cart.Cart{}, cart.ErrInvalidProduct
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RemoveItem(ctx, uid, pid); err != nil {
+			return err
+		}
+		return s.holds.Release(ctx, uid, pid)
+	})
+	if err != nil {
+		return cart.Cart{}, err
+	}
+	return s.Get(ctx, uid)
+}
+
+func (s *Service) Clear(ctx context.Context, userID string) error {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return cart.ErrInvalidID
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Clear(ctx, uid); err != nil {
+			return err
+		}
+		return s.holds.ReleaseAll(ctx, uid)
+	})
+}
+
+// Checkout turns the cart into an order and empties it. Both happen in one unit
+// of work, so a failed order leaves the cart and its holds untouched.
+func (s *Service) Checkout(ctx context.Context, userID string, in cart.CheckoutInput) (orders.Order, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return orders.Order{}, cart.ErrInvalidID
+	}
+
+	var ord orders.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		c, err := s.repo.Get(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if len(c.Items) == 0 {
+			return cart.ErrEmptyCart
+		}
+
+		oin := orders.CreateInput{
+			Items:            make([]orders.Item, 0, len(c.Items)),
+			PromoCode:        in.PromoCode,
+			ShippingAddress:  in.ShippingAddress,
+			DeliveryMethodID: in.DeliveryMethodID,
+			Currency:         in.Currency,
+		}
+		for _, it := range c.Items {
+			oin.Items = append(oin.Items, orders.Item{ProductID: it.ProductID, Quantity: it.Quantity})
+		}
+
+		ord, err = s.ordersSvc.Create(ctx, uid, oin)
+		if err != nil {
+			return err
+		}
+		return s.repo.Clear(ctx, uid)
+	})
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	return ord, nil
+}
+
+// ---- helpers ----
+
+// setItem saves the line and holds its whole quantity, renewing the hold's
+// expiry. Without enough available stock neither is changed.
+func (s *Service) setItem(ctx context.Context, userID string, item cart.Item) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.holds.Hold(ctx, userID, item.ProductID, item.Quantity); err != nil {
+			switch {
+			case errors.Is(err, reservations.ErrInsufficientStock):
+				return cart.ErrInsufficientStock
+			case errors.Is(err, reservations.ErrInvalidProduct):
+				return cart.ErrInvalidProduct
+			}
+			return err
+		}
+		return s.repo.SetItem(ctx, userID, item)
+	})
+}
+
+// fillPrices sets live names, prices, availability and holds on every line.
+// Lines whose product was deleted stay in the cart, marked out of stock.
+func (s *Service) fillPrices(ctx context.Context, c *cart.Cart) error {
+	ids := make([]string, 0, len(c.Items))
+	for _, it := range c.Items {
+		ids = append(ids, it.ProductID)
+	}
+	prods, err := s.productsRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	held, err := s.holds.ListByUser(ctx, c.UserID)
+	if err != nil {
+		return err
+	}
+	holds := make(map[string]reservations.Reservation, len(held))
+	for _, r := range held {
+		holds[r.ProductID] = r
+	}
+
+	total := money.Zero(s.base)
+	for i := range c.Items {
+		p, ok := prods[c.Items[i].ProductID]
+		if !ok {
+			continue
+		}
+
+		if !p.Price.IsIn(s.base) {
+			return cart.ErrCurrencyMismatch
+		}
+
+		c.Items[i].ProductName = p.Name
+		c.Items[i].UnitPrice = p.Price
+		c.Items[i].LineTotal = p.Price.Mul(c.Items[i].Quantity)
+		// the user's own hold counts towards what they can still order
+		h, ok := holds[c.Items[i].ProductID]
+		c.Items[i].InStock = p.Available()+h.Quantity >= c.Items[i].Quantity
+		if ok {
+			until := h.ExpiresAt
+			c.Items[i].ReservedUntil = &until
+		}
+		total = total.Add(c.Items[i].LineTotal)
+	}
+	c.TotalPrice = total
+	return nil
+}
@@ -0,0 +1,355 @@
+package cartsvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/cart"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	cartsvc "github.com/bnursik/aitu-ad-final-back/internal/services/cart"
+)
+
+// The fakes embed their interface so only the methods the cart calls need
+// writing; anything else panics on the nil embedded value.
+
+type fakeCarts struct {
+	carts map[string]cart.Cart
+}
+
+func (r *fakeCarts) Get(ctx context.Context, userID string) (cart.Cart, error) {
+	c := r.carts[userID]
+	c.UserID = userID
+	c.Items = append([]cart.Item(nil), c.Items...)
+	return c, nil
+}
+
+func (r *fakeCarts) SetItem(ctx context.Context, userID string, item cart.Item) error {
+	c := r.carts[userID]
+	for i := range c.Items {
+		if c.Items[i].ProductID == item.ProductID {
+			c.Items[i] = item
+			r.carts[userID] = c
+			return nil
+		}
+	}
+	c.Items = append(c.Items, item)
+	r.carts[userID] = c
+	return nil
+}
+
+func (r *fakeCarts) RemoveItem(ctx context.Context, userID string, productID string) error {
+	c := r.carts[userID]
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			break
+		}
+	}
+	r.carts[userID] = c
+	return nil
+}
+
+func (r *fakeCarts) Clear(ctx context.Context, userID string) error {
+	delete(r.carts, userID)
+	return nil
+}
+
+type fakeProducts struct {
+	products.Repo
+	byID map[string]products.Product
+}
+
+func (r *fakeProducts) GetByID(ctx context.Context, id string) (products.Product, error) {
+	p, ok := r.byID[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *fakeProducts) GetByIDs(ctx context.Context, ids []string) (map[string]products.Product, error) {
+	out := make(map[string]products.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := r.byID[id]; ok {
+			out[id] = p
+		}
+	}
+	return out, nil
+}
+
+// fakeHolds keeps holds per user and product and counts them in the
+// products' Reserved stock, as the reservations service does.
+type fakeHolds struct {
+	reservations.Service
+	products *fakeProducts
+	held     map[[2]string]int64
+}
+
+func (h *fakeHolds) Hold(ctx context.Context, userID, productID string, qty int64) (reservations.Reservation, error) {
+	p, ok := h.products.byID[productID]
+	if !ok {
+		return reservations.Reservation{}, reservations.ErrInvalidProduct
+	}
+	key := [2]string{userID, productID}
+	if p.Available()+h.held[key] < qty {
+		return reservations.Reservation{}, reservations.ErrInsufficientStock
+	}
+	p.Reserved += qty - h.held[key]
+	h.products.byID[productID] = p
+	h.held[key] = qty
+	return reservations.Reservation{UserID: userID, ProductID: productID, Quantity: qty}, nil
+}
+
+func (h *fakeHolds) Release(ctx context.Context, userID, productID string) error {
+	key := [2]string{userID, productID}
+	if p, ok := h.products.byID[productID]; ok {
+		p.Reserved -= h.held[key]
+		h.products.byID[productID] = p
+	}
+	delete(h.held, key)
+	return nil
+}
+
+func (h *fakeHolds) ReleaseAll(ctx context.Context, userID string) error {
+	for key := range h.held {
+		if key[0] == userID {
+			_ = h.Release(ctx, userID, key[1])
+		}
+	}
+	return nil
+}
+
+func (h *fakeHolds) ListByUser(ctx context.Context, userID string) ([]reservations.Reservation, error) {
+	var out []reservations.Reservation
+	for key, qty := range h.held {
+		if key[0] == userID {
+			out = append(out, reservations.Reservation{UserID: userID, ProductID: key[1], Quantity: qty})
+		}
+	}
+	return out, nil
+}
+
+type fakeOrders struct {
+	orders.Service
+	created []orders.CreateInput
+	err     error
+}
+
+func (s *fakeOrders) Create(ctx context.Context, userID string, in orders.CreateInput) (orders.Order, error) {
+	if s.err != nil {
+		return orders.Order{}, s.err
+	}
+	s.created = append(s.created, in)
+	return orders.Order{ID: "order-1", UserID: userID, Items: in.Items}, nil
+}
+
+const uid = "user-1"
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func newService(t *testing.T) (*cartsvc.Service, *fakeCarts, *fakeOrders) {
+	svc, carts, ords, _ := newServiceWithHolds(t)
+	return svc, carts, ords
+}
+
+func newServiceWithHolds(t *testing.T) (*cartsvc.Service, *fakeCarts, *fakeOrders, *fakeHolds) {
+	t.Helper()
+	carts := &fakeCarts{carts: map[string]cart.Cart{}}
+	prods := &fakeProducts{byID: map[string]products.Product{
+		"mouse":        {ID: "mouse", Name: "Mouse", Price: kzt(2500), Stock: 10},
+		"keyboard":     {ID: "keyboard", Name: "Keyboard", Price: kzt(8000), Stock: 2},
+		"usd-keyboard": {ID: "usd-keyboard", Name: "Keyboard", Price: money.New(2000, "USD"), Stock: 2},
+	}}
+	ords := &fakeOrders{}
+	holds := &fakeHolds{products: prods, held: map[[2]string]int64{}}
+	return cartsvc.New(carts, prods, ords, holds, memrepo.NewUnitOfWork(), money.Default), carts, ords, holds
+}
+
+func TestAddItem(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newService(t)
+
+	if _, err := svc.AddItem(ctx, uid, cart.AddItemInput{ProductID: "mouse", Quantity: 2}); err != nil {
+		t.Fatal(err)
+	}
+	// adding the same product again tops up the line
+	c, err := svc.AddItem(ctx, uid, cart.AddItemInput{ProductID: "mouse", Quantity: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Items) != 1 || c.Items[0].Quantity != 5 {
+		t.Fatalf("items = %+v, want one line of 5", c.Items)
+	}
+	if it := c.Items[0]; it.UnitPrice != kzt(2500) || it.LineTotal != kzt(12500) || !it.InStock {
+		t.Errorf("line = %+v, want 5 x 25.00 = 125.00 in stock", it)
+	}
+	if c.TotalPrice != kzt(12500) {
+		t.Errorf("total = %v, want 125", c.TotalPrice)
+	}
+}
+
+func TestAddItemRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		in   cart.AddItemInput
+		want error
+	}{
+		{"zero quantity", cart.AddItemInput{ProductID: "mouse"}, cart.ErrInvalidQty},
+		{"blank product", cart.AddItemInput{ProductID: " ", Quantity: 1}, cart.ErrInvalidProduct},
+		{"unknown product", cart.AddItemInput{ProductID: "monitor", Quantity: 1}, cart.ErrInvalidProduct},
+		{"more than in stock", cart.AddItemInput{ProductID: "keyboard", Quantity: 3}, cart.ErrInsufficientStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newService(t)
+			if _, err := svc.AddItem(context.Background(), uid, tt.in); !errors.Is(err, tt.want) {
+				t.Errorf("AddItem = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateItem(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newService(t)
+
+	if _, err := svc.UpdateItem(ctx, uid, "mouse", 1); !errors.Is(err, cart.ErrItemNotFound) {
+		t.Fatalf("UpdateItem on an empty cart = %v, want %v", err, cart.ErrItemNotFound)
+	}
+	if _, err := svc.AddItem(ctx, uid, cart.AddItemInput{ProductID: "keyboard", Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UpdateItem(ctx, uid, "keyboard", 3); !errors.Is(err, cart.ErrInsufficientStock) {
+		t.Fatalf("UpdateItem over stock = %v, want %v", err, cart.ErrInsufficientStock)
+	}
+	c, err := svc.UpdateItem(ctx, uid, "keyboard", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TotalPrice != kzt(16000) {
+		t.Errorf("total = %v, want 160", c.TotalPrice)
+	}
+}
+
+func TestCartHoldsStock(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, holds := newServiceWithHolds(t)
+
+	if _, err := svc.AddItem(ctx, "user-2", cart.AddItemInput{ProductID: "keyboard", Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// one of the two keyboards is held for user-2
+	if _, err := svc.AddItem(ctx, uid, cart.AddItemInput{ProductID: "keyboard", Quantity: 2}); !errors.Is(err, cart.ErrInsufficientStock) {
+		t.Fatalf("AddItem past another cart's hold = %v, want %v", err, cart.ErrInsufficientStock)
+	}
+	c, err := svc.AddItem(ctx, uid, cart.AddItemInput{ProductID: "keyboard", Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the user's own hold counts as available to them
+	if !c.Items[0].InStock {
+		t.Error("held line reported out of stock")
+	}
+
+	if _, err := svc.RemoveItem(ctx, "user-2", "keyboard"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := holds.held[[2]string{"user-2", "keyboard"}]; ok {
+		t.Error("hold kept after the line was removed")
+	}
+	if _, err := svc.UpdateItem(ctx, uid, "keyboard", 2); err != nil {
+		t.Fatalf("UpdateItem after the other hold was released: %v", err)
+	}
+
+	if err := svc.Clear(ctx, uid); err != nil {
+		t.Fatal(err)
+	}
+	if len(holds.held) != 0 {
+		t.Errorf("holds left after Clear: %v", holds.held)
+	}
+}
+
+func TestGetMarksDeletedProducts(t *testing.T) {
+	ctx := context.Background()
+	svc, carts, _ := newService(t)
+	carts.carts[uid] = cart.Cart{Items: []cart.Item{
+		{ProductID: "mouse", Quantity: 1},
+		{ProductID: "deleted", Quantity: 4},
+	}}
+
+	c, err := svc.Get(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Items) != 2 || c.Items[1].InStock {
+		t.Fatalf("items = %+v, want the deleted product kept and out of stock", c.Items)
+	}
+	if c.TotalPrice != kzt(2500) {
+		t.Errorf("total = %v, want 25", c.TotalPrice)
+	}
+}
+
+func TestGetRejectsForeignPrices(t *testing.T) {
+	ctx := context.Background()
+	svc, carts, _ := newService(t)
+	carts.carts[uid] = cart.Cart{Items: []cart.Item{{ProductID: "mouse", Quantity: 1}}}
+
+	if _, err := svc.Get(ctx, uid); err != nil {
+		t.Fatal(err)
+	}
+	// this keyboard was priced before BASE_CURRENCY changed
+	carts.carts[uid] = cart.Cart{Items: []cart.Item{{ProductID: "mouse", Quantity: 1}, {ProductID: "usd-keyboard", Quantity: 1}}}
+	if _, err := svc.Get(ctx, uid); !errors.Is(err, cart.ErrCurrencyMismatch) {
+		t.Errorf("Get = %v, want %v", err, cart.ErrCurrencyMismatch)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	ctx := context.Background()
+	svc, carts, ords := newService(t)
+
+	in := cart.CheckoutInput{PromoCode: "SUMMER10", DeliveryMethodID: "courier"}
+	if _, err := svc.Checkout(ctx, uid, in); !errors.Is(err, cart.ErrEmptyCart) {
+		t.Fatalf("Checkout of an empty cart = %v, want %v", err, cart.ErrEmptyCart)
+	}
+
+	for _, in := range []cart.AddItemInput{{ProductID: "mouse", Quantity: 2}, {ProductID: "keyboard", Quantity: 1}} {
+		if _, err := svc.AddItem(ctx, uid, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a failed order leaves the cart as it was
+	ords.err = orders.ErrInsufficientStock
+	if _, err := svc.Checkout(ctx, uid, in); !errors.Is(err, orders.ErrInsufficientStock) {
+		t.Fatalf("Checkout = %v, want %v", err, orders.ErrInsufficientStock)
+	}
+	if got := len(carts.carts[uid].Items); got != 2 {
+		t.Fatalf("cart has %d lines after a failed checkout, want 2", got)
+	}
+
+	ords.err = nil
+	o, err := svc.Checkout(ctx, uid, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ords.created) != 1 || len(o.Items) != 2 {
+		t.Fatalf("created %+v, want one order of two lines", ords.created)
+	}
+	if got := ords.created[0]; got.PromoCode != in.PromoCode || got.DeliveryMethodID != in.DeliveryMethodID {
+		t.Errorf("order options = %q, %q; want the checkout's", got.PromoCode, got.DeliveryMethodID)
+	}
+	for _, it := range o.Items {
+		if want := map[string]int64{"mouse": 2, "keyboard": 1}[it.ProductID]; it.Quantity != want {
+			t.Errorf("%s quantity = %d, want %d", it.ProductID, it.Quantity, want)
+		}
+	}
+	if _, ok := carts.carts[uid]; ok {
+		t.Error("cart not emptied by checkout")
+	}
+}
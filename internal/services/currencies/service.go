@@ -0,0 +1,101 @@
+package currenciessvc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+)
+
+// maxRate guards against typos such as a rate entered in minor units.
+const maxRate = 1e9
+
+type Service struct {
+	repo currencies.Repo
+	base money.Currency
+	now  func() time.Time
+}
+
+// New builds the currencies service. Rates are kept against base; changing
+// the base later does not convert amounts already stored in it.
+func New(repo currencies.Repo, base money.Currency) *Service {
+	return &Service{
+		repo: repo,
+		base: base,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ currencies.Service = (*Service)(nil)
+
+func (s *Service) Base() money.Currency {
+	return s.base
+}
+
+func (s *Service) List(ctx context.Context) ([]currencies.Rate, error) {
+	return s.repo.List(ctx, s.base)
+}
+
+func (s *Service) Set(ctx context.Context, in currencies.SetInput) (currencies.Rate, error) {
+	cur, err := s.parse(in.Currency)
+	if err != nil {
+		return currencies.Rate{}, err
+	}
+	if math.IsNaN(in.Rate) || in.Rate <= 0 || in.Rate > maxRate {
+		return currencies.Rate{}, currencies.ErrInvalidRate
+	}
+
+	return s.repo.Upsert(ctx, s.base, currencies.Rate{
+		Currency:  cur,
+		Rate:      in.Rate,
+		UpdatedBy: strings.TrimSpace(in.UpdatedBy),
+		UpdatedAt: s.now(),
+	})
+}
+
+func (s *Service) Delete(ctx context.Context, c money.Currency) error {
+	cur, err := s.parse(c)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, s.base, cur)
+}
+
+func (s *Service) Quote(ctx context.Context, c money.Currency) (currencies.Quote, error) {
+	if c == "" {
+		return currencies.Quote{Currency: s.base, Rate: 1}, nil
+	}
+	cur, err := money.ParseCurrency(string(c))
+	if err != nil {
+		return currencies.Quote{}, currencies.ErrInvalidCurrency
+	}
+	if cur == s.base {
+		return currencies.Quote{Currency: s.base, Rate: 1}, nil
+	}
+
+	r, err := s.repo.Get(ctx, s.base, cur)
+	if err != nil {
+		if errors.Is(err, currencies.ErrNotFound) {
+			return currencies.Quote{}, currencies.ErrUnsupported
+		}
+		return currencies.Quote{}, err
+	}
+	return currencies.Quote{Currency: cur, Rate: r.Rate}, nil
+}
+
+// parse validates a currency whose rate is being managed; the base currency
+// has a fixed rate of 1 and can't be set.
+func (s *Service) parse(c money.Currency) (money.Currency, error) {
+	cur, err := money.ParseCurrency(string(c))
+	if err != nil {
+		return "", currencies.ErrInvalidCurrency
+	}
+	if cur == s.base {
+		return "", currencies.ErrBaseCurrency
+	}
+	return cur, nil
+}
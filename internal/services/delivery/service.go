@@ -0,0 +1,82 @@
+package deliverysvc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/delivery"
+)
+
+type Service struct {
+	repo delivery.Repo
+	now  func() time.Time
+}
+
+func New(repo delivery.Repo) *Service {
+	return &Service{
+		repo: repo,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ delivery.Service = (*Service)(nil)
+
+func (s *Service) List(ctx context.Context, f delivery.ListFilter) ([]delivery.Method, error) {
+	return s.repo.List(ctx, f)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (delivery.Method, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) Create(ctx context.Context, in delivery.CreateInput) (delivery.Method, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return delivery.Method{}, delivery.ErrInvalidName
+	}
+	if in.Fee.IsNegative() {
+		return delivery.Method{}, delivery.ErrInvalidFee
+	}
+	if in.EstimatedDays < 0 {
+		return delivery.Method{}, delivery.ErrInvalidDays
+	}
+
+	now := s.now()
+	m := delivery.Method{
+		Name:          name,
+		Description:   strings.TrimSpace(in.Description),
+		Fee:           in.Fee,
+		EstimatedDays: in.EstimatedDays,
+		Active:        in.Active,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	return s.repo.Create(ctx, m)
+}
+
+func (s *Service) Update(ctx context.Context, id string, in delivery.UpdateInput) (delivery.Method, error) {
+	if in.Name != nil {
+		n := strings.TrimSpace(*in.Name)
+		if n == "" {
+			return delivery.Method{}, delivery.ErrInvalidName
+		}
+		in.Name = &n
+	}
+	if in.Description != nil {
+		d := strings.TrimSpace(*in.Description)
+		in.Description = &d
+	}
+	if in.Fee != nil && in.Fee.IsNegative() {
+		return delivery.Method{}, delivery.ErrInvalidFee
+	}
+	if in.EstimatedDays != nil && *in.EstimatedDays < 0 {
+		return delivery.Method{}, delivery.ErrInvalidDays
+	}
+
+	return s.repo.Update(ctx, id, in)
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
@@ -0,0 +1,80 @@
+package invoicessvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+type Service struct {
+	repo      invoices.Repo
+	ordersSvc orders.Service
+	tx        uow.UnitOfWork
+	now       func() time.Time
+}
+
+func New(repo invoices.Repo, ordersSvc orders.Service, tx uow.UnitOfWork) *Service {
+	return &Service{
+		repo:      repo,
+		ordersSvc: ordersSvc,
+		tx:        tx,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ invoices.Service = (*Service)(nil)
+
+// Issue looks the invoice up first, so an order cancelled after it was
+// invoiced still gets its original invoice back. New invoices are only issued
+// for orders that weren't cancelled.
+func (s *Service) Issue(ctx context.Context, orderID string, userID string, isAdmin bool) (invoices.Document, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" && !isAdmin {
+		return invoices.Document{}, orders.ErrForbidden
+	}
+
+	o, err := s.ordersSvc.Get(ctx, orderID, uid, isAdmin)
+	if err != nil {
+		return invoices.Document{}, err
+	}
+
+	inv, err := s.repo.GetByOrderID(ctx, o.ID)
+	if err == nil {
+		return invoices.Document{Invoice: inv, Order: o}, nil
+	}
+	if !errors.Is(err, invoices.ErrNotFound) {
+		return invoices.Document{}, err
+	}
+
+	if o.Status == orders.StatusCancelled {
+		return invoices.Document{}, invoices.ErrNotInvoiceable
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		n, err := s.repo.NextNumber(ctx)
+		if err != nil {
+			return err
+		}
+		inv, err = s.repo.Create(ctx, invoices.Invoice{
+			OrderID:  o.ID,
+			UserID:   o.UserID,
+			Number:   n,
+			IssuedAt: s.now(),
+		})
+		return err
+	})
+	// a concurrent request issued it first; its number rolled ours back
+	if errors.Is(err, invoices.ErrExists) {
+		inv, err = s.repo.GetByOrderID(ctx, o.ID)
+	}
+	if err != nil {
+		return invoices.Document{}, err
+	}
+
+	return invoices.Document{Invoice: inv, Order: o}, nil
+}
@@ -0,0 +1,151 @@
+package invoicessvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/invoices"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	invoicessvc "github.com/bnursik/aitu-ad-final-back/internal/services/invoices"
+)
+
+// fakeInvoices numbers invoices like the counter collection does. raced, when
+// set, is an invoice another request stored just before ours.
+type fakeInvoices struct {
+	byOrder map[string]invoices.Invoice
+	last    int64
+	raced   *invoices.Invoice
+}
+
+func (r *fakeInvoices) GetByOrderID(ctx context.Context, orderID string) (invoices.Invoice, error) {
+	inv, ok := r.byOrder[orderID]
+	if !ok {
+		return invoices.Invoice{}, invoices.ErrNotFound
+	}
+	return inv, nil
+}
+
+func (r *fakeInvoices) NextNumber(ctx context.Context) (int64, error) {
+	r.last++
+	return r.last, nil
+}
+
+func (r *fakeInvoices) Create(ctx context.Context, inv invoices.Invoice) (invoices.Invoice, error) {
+	if r.raced != nil {
+		r.byOrder[inv.OrderID], r.raced = *r.raced, nil
+	}
+	if _, ok := r.byOrder[inv.OrderID]; ok {
+		return invoices.Invoice{}, invoices.ErrExists
+	}
+	inv.ID = inv.OrderID + "-invoice"
+	r.byOrder[inv.OrderID] = inv
+	return inv, nil
+}
+
+// fakeOrders embeds the interface so only Get needs writing.
+type fakeOrders struct {
+	orders.Service
+	byID map[string]orders.Order
+}
+
+func (s *fakeOrders) Get(ctx context.Context, id string, userID string, isAdmin bool) (orders.Order, error) {
+	o, ok := s.byID[id]
+	if !ok || (!isAdmin && o.UserID != userID) {
+		return orders.Order{}, orders.ErrNotFound
+	}
+	return o, nil
+}
+
+const uid = "user-1"
+
+func newService(t *testing.T) (*invoicessvc.Service, *fakeInvoices, *fakeOrders) {
+	t.Helper()
+	repo := &fakeInvoices{byOrder: map[string]invoices.Invoice{}}
+	ords := &fakeOrders{byID: map[string]orders.Order{
+		"order-1":   {ID: "order-1", UserID: uid, Status: orders.StatusDelivered},
+		"order-2":   {ID: "order-2", UserID: uid, Status: orders.StatusPending},
+		"cancelled": {ID: "cancelled", UserID: uid, Status: orders.StatusCancelled},
+	}}
+	return invoicessvc.New(repo, ords, memrepo.NewUnitOfWork()), repo, ords
+}
+
+func TestIssueNumbersSequentially(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newService(t)
+
+	first, err := svc.Issue(ctx, "order-1", uid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Invoice.Number != 1 || first.Invoice.Code() != "INV-000001" || first.Order.ID != "order-1" {
+		t.Fatalf("first invoice = %+v", first.Invoice)
+	}
+
+	// asking again returns the same invoice rather than a new number
+	again, err := svc.Issue(ctx, "order-1", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Invoice != first.Invoice {
+		t.Errorf("reissued %+v, want %+v", again.Invoice, first.Invoice)
+	}
+
+	second, err := svc.Issue(ctx, "order-2", uid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Invoice.Number != 2 {
+		t.Errorf("second order numbered %d, want 2", second.Invoice.Number)
+	}
+}
+
+func TestIssueCancelled(t *testing.T) {
+	ctx := context.Background()
+	svc, _, ords := newService(t)
+
+	if _, err := svc.Issue(ctx, "cancelled", uid, false); !errors.Is(err, invoices.ErrNotInvoiceable) {
+		t.Fatalf("Issue for a cancelled order = %v, want %v", err, invoices.ErrNotInvoiceable)
+	}
+
+	// an order cancelled after it was invoiced keeps its invoice
+	inv, err := svc.Issue(ctx, "order-2", uid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := ords.byID["order-2"]
+	o.Status = orders.StatusCancelled
+	ords.byID["order-2"] = o
+	got, err := svc.Issue(ctx, "order-2", uid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Invoice != inv.Invoice {
+		t.Errorf("invoice after cancelling = %+v, want %+v", got.Invoice, inv.Invoice)
+	}
+}
+
+func TestIssueOthersOrder(t *testing.T) {
+	svc, _, _ := newService(t)
+	if _, err := svc.Issue(context.Background(), "order-1", "someone-else", false); !errors.Is(err, orders.ErrNotFound) {
+		t.Errorf("Issue = %v, want %v", err, orders.ErrNotFound)
+	}
+	if _, err := svc.Issue(context.Background(), "order-1", "", false); !errors.Is(err, orders.ErrForbidden) {
+		t.Errorf("Issue without a user = %v, want %v", err, orders.ErrForbidden)
+	}
+}
+
+func TestIssueConcurrentRequestWins(t *testing.T) {
+	svc, repo, _ := newService(t)
+	winner := invoices.Invoice{ID: "winner", OrderID: "order-1", UserID: uid, Number: 1}
+	repo.raced = &winner
+
+	got, err := svc.Issue(context.Background(), "order-1", uid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Invoice != winner {
+		t.Errorf("invoice = %+v, want the concurrent request's %+v", got.Invoice, winner)
+	}
+}
@@ -3,63 +3,189 @@ package orderssvc
 import (
 	"context"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/delivery"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/users"
 )
 
 type Service struct {
-	repo         orders.Repo
-	productsRepo products.Repo
-	now          func() time.Time
+	repo           orders.Repo
+	productsRepo   products.Repo
+	stockRepo      stock.Repo
+	promotionsRepo promotions.Repo
+	usersRepo      users.Repo
+	deliveryRepo   delivery.Repo
+	taxesRepo      taxes.Repo
+	rates          currencies.Service
+	holds          reservations.Service
+	notifier       products.Notifier
+	tx             uow.UnitOfWork
+	cancelWindow   time.Duration
+	now            func() time.Time
 }
 
-func New(repo orders.Repo, productsRepo products.Repo) *Service {
+// Deps are the repositories and services the orders service works with.
+// Fields are named rather than positional so that collaborators of the same
+// shape can't be passed in the wrong order; all are required.
+type Deps struct {
+	Orders     orders.Repo
+	Products   products.Repo
+	Stock      stock.Repo
+	Promotions promotions.Repo
+	Users      users.Repo
+	Delivery   delivery.Repo
+	Taxes      taxes.Repo
+	Rates      currencies.Service
+	Holds      reservations.Service
+	// Notifier receives low-stock alerts once the outermost transaction holding
+	// the order that raised them commits.
+	Notifier products.Notifier
+	Tx       uow.UnitOfWork
+}
+
+// New builds the orders service. cancelWindow limits how long after creation a
+// customer may cancel their own order; 0 disables the limit.
+func New(d Deps, cancelWindow time.Duration) *Service {
 	return &Service{
-		repo:         repo,
-		productsRepo: productsRepo,
-		now:          func() time.Time { return time.Now().UTC() },
+		repo:           d.Orders,
+		productsRepo:   d.Products,
+		stockRepo:      d.Stock,
+		promotionsRepo: d.Promotions,
+		usersRepo:      d.Users,
+		deliveryRepo:   d.Delivery,
+		taxesRepo:      d.Taxes,
+		rates:          d.Rates,
+		holds:          d.Holds,
+		notifier:       d.Notifier,
+		tx:             d.Tx,
+		cancelWindow:   cancelWindow,
+		now:            func() time.Time { return time.Now().UTC() },
 	}
 }
 
 var _ orders.Service = (*Service)(nil)
 
+// paymentsActor is recorded as ChangedBy for status changes driven by payments.
+const paymentsActor = "system:payments"
+
+// List returns a page of orders and the total matching f. Customers only ever
+// see their own orders; the user and email filters apply to admins only.
 func (s *Service) List(ctx context.Context, userID string, isAdmin bool, f orders.ListFilter) ([]orders.Order, int64, error) {
-	var (
-		list  []orders.Order
-		total int64
-		err   error
-	)
+	scope, f, none, err := s.listScope(ctx, userID, isAdmin, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	if none {
+		return []orders.Order{}, 0, nil
+	}
+
+	list, err := s.repo.List(ctx, scope, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(ctx, scope, f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+// listScope validates f and pins it to what the caller may see: customers
+// only their own orders, admins anything, with f.Email resolved to a user ID.
+// none reports that the filter can't match any order.
+func (s *Service) listScope(ctx context.Context, userID string, isAdmin bool, f orders.ListFilter) (scope *string, _ orders.ListFilter, none bool, err error) {
+	if err := validateListFilter(f); err != nil {
+		return nil, f, false, err
+	}
 
 	if isAdmin {
-		list, err = s.repo.List(ctx, nil, f)
-		if err != nil {
-			return nil, 0, err
-		}
-		total, err = s.repo.Count(ctx, nil)
-		if err != nil {
-			return nil, 0, err
+		if email := strings.TrimSpace(strings.ToLower(f.Email)); email != "" {
+			u, err := s.usersRepo.FindByEmail(ctx, email)
+			if err != nil {
+				if errors.Is(err, users.ErrUserNotFound) {
+					return nil, f, true, nil
+				}
+				return nil, f, false, err
+			}
+			// userId and email given together must name the same user
+			if f.UserID != nil && strings.TrimSpace(*f.UserID) != u.ID {
+				return nil, f, true, nil
+			}
+			f.UserID = &u.ID
+		} else if f.UserID != nil {
+			uid := strings.TrimSpace(*f.UserID)
+			f.UserID = &uid
 		}
 	} else {
 		uid := strings.TrimSpace(userID)
-		list, err = s.repo.List(ctx, &uid, f)
-		if err != nil {
-			return nil, 0, err
-		}
-		total, err = s.repo.Count(ctx, &uid)
-		if err != nil {
-			return nil, 0, err
-		}
+		scope = &uid
+		f.UserID = nil
 	}
+	f.Email = ""
 
-	// ✅ keep your computed totals (no DB change)
-	if err := s.fillTotals(ctx, list); err != nil {
-		return nil, 0, err
+	return scope, f, false, nil
+}
+
+// Export walks matching orders from a cursor rather than loading them, so
+// memory stays flat however many rows are exported. Emails are looked up once
+// per customer.
+func (s *Service) Export(ctx context.Context, f orders.ListFilter, fn func(orders.ExportLine) error) error {
+	f.Offset, f.Limit = 0, 0
+
+	scope, f, none, err := s.listScope(ctx, "", true, f)
+	if err != nil || none {
+		return err
 	}
 
-	return list, total, nil
+	emails := make(map[string]string)
+	return s.repo.Each(ctx, scope, f, func(o orders.Order) error {
+		email, ok := emails[o.UserID]
+		if !ok {
+			u, err := s.usersRepo.FindByID(ctx, o.UserID)
+			switch {
+			case err == nil:
+				email = u.Email
+			case !errors.Is(err, users.ErrUserNotFound):
+				return err
+			}
+			emails[o.UserID] = email
+		}
+
+		for _, it := range o.Items {
+			err := fn(orders.ExportLine{
+				OrderID:       o.ID,
+				UserID:        o.UserID,
+				Email:         email,
+				Status:        o.Status,
+				PaymentStatus: o.PaymentStatus,
+				ProductID:     it.ProductID,
+				ProductName:   it.ProductName,
+				Quantity:      it.Quantity,
+				UnitPrice:     it.UnitPrice,
+				LineTotal:     it.LineTotal,
+				OrderTotal:    o.TotalPrice,
+				CreatedAt:     o.CreatedAt,
+				UpdatedAt:     o.UpdatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *Service) Get(ctx context.Context, id string, userID string, isAdmin bool) (orders.Order, error) {
@@ -78,18 +204,6 @@ func (s *Service) Get(ctx context.Context, id string, userID string, isAdmin boo
 		return orders.Order{}, err
 	}
 
-	// compute total for this single order
-	if err := s.fillTotals(ctx, []orders.Order{o}); err != nil {
-		return orders.Order{}, err
-	}
-
-	// fillTotals works on slice elements; for single order easiest is recompute directly:
-	total, err := s.computeOne(ctx, &o)
-	if err != nil {
-		return orders.Order{}, err
-	}
-	o.TotalPrice = total
-
 	return o, nil
 }
 
@@ -115,110 +229,722 @@ func (s *Service) Create(ctx context.Context, userID string, in orders.CreateInp
 		items = append(items, orders.Item{ProductID: p, Quantity: it.Quantity})
 	}
 
-	// Validate stock for all products before creating order
-	for _, it := range items {
-		prod, err := s.productsRepo.GetByID(ctx, it.ProductID)
+	quote, err := s.rates.Quote(ctx, in.Currency)
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	subtotal, lines, err := s.priceItems(ctx, items)
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	addr, err := s.shippingAddress(ctx, uid, in.ShippingAddress)
+	if err != nil {
+		return orders.Order{}, err
+	}
+	method, err := s.deliveryMethod(ctx, in.DeliveryMethodID)
+	if err != nil {
+		return orders.Order{}, err
+	}
+	fee := money.Zero(subtotal.Currency)
+	if method != nil {
+		fee = method.Fee
+	}
+
+	now := s.now()
+
+	var (
+		promo     *promotions.Promotion
+		discounts []orders.Discount
+		discount  = money.Zero(subtotal.Currency)
+	)
+	if code := promotions.NormalizeCode(in.PromoCode); code != "" {
+		p, err := s.promotionsRepo.GetByCode(ctx, code)
 		if err != nil {
-			if errors.Is(err, products.ErrNotFound) {
-				return orders.Order{}, orders.ErrInvalidProduct
+			if errors.Is(err, promotions.ErrNotFound) {
+				return orders.Order{}, promotions.ErrInvalidCode
 			}
 			return orders.Order{}, err
 		}
-		if prod.Stock < it.Quantity {
-			return orders.Order{}, orders.ErrInsufficientStock
+		if !p.ActiveAt(now) {
+			return orders.Order{}, promotions.ErrNotActive
+		}
+		if !p.MinOrderTotal.IsIn(subtotal.Currency) {
+			return orders.Order{}, orders.ErrCurrencyMismatch
 		}
+		discount, err = p.Discount(lines)
+		if err != nil {
+			return orders.Order{}, err
+		}
+		promo = &p
+		discounts = []orders.Discount{{PromotionID: p.ID, Code: p.Code, Amount: discount}}
+	}
+
+	taxLines, err := s.taxLines(ctx, addr, lines, promo, discount)
+	if err != nil {
+		return orders.Order{}, err
 	}
 
-	now := s.now()
 	o := orders.Order{
-		UserID:    uid,
-		Items:     items,
-		Status:    orders.StatusPending,
+		UserID:          uid,
+		Items:           items,
+		Status:          orders.StatusPending,
+		PaymentStatus:   orders.PaymentUnpaid,
+		ShippingAddress: addr,
+		Delivery:        method,
+		Subtotal:        subtotal,
+		Discounts:       discounts,
+		Taxes:           taxLines,
+		StatusHistory: []orders.StatusChange{
+			{To: orders.StatusPending, ChangedBy: uid, ChangedAt: now},
+		},
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	ord, err := s.repo.Create(ctx, o)
-	if err != nil {
-		return orders.Order{}, err
+	o.TotalPrice = subtotal.Sub(discount).Add(fee).Add(o.AddedTax())
+	// statistics use the base amounts; the customer sees and pays the display ones
+	if quote.Currency != s.rates.Base() {
+		o.Display = &orders.Display{
+			Currency:   quote.Currency,
+			Rate:       quote.Rate,
+			Subtotal:   quote.FromBase(o.Subtotal),
+			TotalPrice: quote.FromBase(o.TotalPrice),
+		}
 	}
 
-	// Decrement stock for each product in the order
-	for _, it := range ord.Items {
-		if err := s.productsRepo.DecrementStock(ctx, it.ProductID, it.Quantity); err != nil {
-			if errors.Is(err, products.ErrInsufficientStock) {
-				return orders.Order{}, orders.ErrInsufficientStock
+	// Insert the order and decrement stock atomically: if any decrement fails
+	// (e.g. a concurrent order took the last unit) nothing is persisted. The
+	// customer's own holds are released first so the stock they kept in their
+	// cart is what the order takes.
+	var ord orders.Order
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		created, err := s.repo.Create(ctx, o)
+		if err != nil {
+			return err
+		}
+
+		moves := make([]stock.Movement, 0, len(created.Items))
+		for _, it := range created.Items {
+			if err := s.holds.Release(ctx, uid, it.ProductID); err != nil {
+				return err
 			}
-			return orders.Order{}, err
+			prod, err := s.productsRepo.DecrementStock(ctx, it.ProductID, it.Quantity)
+			if err != nil {
+				if errors.Is(err, products.ErrInsufficientStock) {
+					return orders.ErrInsufficientStock
+				}
+				return err
+			}
+			if err := s.productsRepo.AddSold(ctx, it.ProductID, it.Quantity); err != nil {
+				return err
+			}
+			// only the order that crosses the threshold alerts, not every one after it
+			if prod.LowStock() && prod.Stock+it.Quantity > prod.ReorderThreshold {
+				s.alertAfterCommit(ctx, products.StockAlert{
+					ProductID:   prod.ID,
+					ProductName: prod.Name,
+					Stock:       prod.Stock,
+					Threshold:   prod.ReorderThreshold,
+					OrderID:     created.ID,
+					At:          now,
+				})
+			}
+			moves = append(moves, stock.Movement{
+				ProductID: it.ProductID,
+				Delta:     -it.Quantity,
+				Reason:    stock.ReasonOrder,
+				ActorID:   uid,
+				Reference: created.ID,
+				CreatedAt: now,
+			})
+		}
+		if err := s.stockRepo.Append(ctx, moves...); err != nil {
+			return err
 		}
-	}
 
+		if promo != nil {
+			if err := s.redeem(ctx, *promo, created); err != nil {
+				return err
+			}
+		}
+
+		ord = created
+		return nil
+	})
+	if err != nil {
+		return orders.Order{}, err
+	}
 	return ord, nil
 }
 
-func (s *Service) UpdateStatus(ctx context.Context, id string, status orders.Status) (orders.Order, error) {
-	switch status {
-	case orders.StatusPending, orders.StatusShipped, orders.StatusDelivered, orders.StatusCancelled:
-	default:
+// alertAfterCommit sends a once the outermost unit of work commits, so an
+// order rolled back by a caller's transaction (e.g. cart checkout) never
+// alerts. A failed alert doesn't undo the order.
+func (s *Service) alertAfterCommit(ctx context.Context, a products.StockAlert) {
+	uow.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.notifier.NotifyLowStock(ctx, a); err != nil {
+			log.Printf("low-stock alert for product %s: %v", a.ProductID, err)
+		}
+	})
+}
+
+func (s *Service) UpdateStatus(ctx context.Context, id string, actorID string, in orders.UpdateStatusInput) (orders.Order, error) {
+	if !in.Status.Valid() {
 		return orders.Order{}, orders.ErrInvalidStatus
 	}
-	return s.repo.UpdateStatus(ctx, id, status)
+	// paid only follows a confirmed payment (RecordPayment); the fulfilment
+	// statuses are derived from shipments (AddShipment, DeliverShipment), so
+	// an order can't be marked shipped with items still in the warehouse
+	switch in.Status {
+	case orders.StatusPaid, orders.StatusPartiallyShipped, orders.StatusShipped, orders.StatusDelivered:
+		return orders.Order{}, orders.ErrInvalidTransition
+	}
+
+	return s.transition(ctx, id, nil, actorID, in, func(o orders.Order) error {
+		// the order service can't return money; the payment is refunded
+		// through payments first, which leaves the order cancellable
+		if in.Status == orders.StatusCancelled && o.PaymentStatus == orders.PaymentPaid {
+			return orders.ErrAlreadyPaid
+		}
+		return nil
+	})
+}
+
+func (s *Service) Cancel(ctx context.Context, id string, userID string, in orders.CancelInput) (orders.Order, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return orders.Order{}, orders.ErrForbidden
+	}
+
+	return s.transition(ctx, id, &uid, uid, orders.UpdateStatusInput{
+		Status: orders.StatusCancelled,
+		Note:   in.Reason,
+	}, func(o orders.Order) error {
+		// customers may only withdraw an order nobody has started on yet
+		if o.Status != orders.StatusPending {
+			return orders.ErrInvalidTransition
+		}
+		// paid orders need a refund, which only an admin can issue
+		if o.PaymentStatus == orders.PaymentPaid {
+			return orders.ErrAlreadyPaid
+		}
+		if s.cancelWindow > 0 && s.now().Sub(o.CreatedAt) > s.cancelWindow {
+			return orders.ErrCancelWindow
+		}
+		return nil
+	})
+}
+
+func (s *Service) RecordPayment(ctx context.Context, id string, ps orders.PaymentStatus) (orders.Order, error) {
+	if !ps.Valid() {
+		return orders.Order{}, orders.ErrInvalidPayment
+	}
+
+	var updated orders.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = s.repo.SetPaymentStatus(ctx, id, ps, s.now())
+		if err != nil {
+			return err
+		}
+		// rolls the payment status back; nobody should be charged for it
+		if ps == orders.PaymentPaid && updated.Status == orders.StatusCancelled {
+			return orders.ErrPaidAfterCancel
+		}
+
+		if ps == orders.PaymentPaid && updated.Status == orders.StatusPending {
+			updated, err = s.repo.UpdateStatus(ctx, id, orders.StatusChange{
+				From:      orders.StatusPending,
+				To:        orders.StatusPaid,
+				ChangedBy: paymentsActor,
+				Note:      "payment received",
+				ChangedAt: s.now(),
+			})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	return updated, nil
+}
+
+func (s *Service) RecordRefund(ctx context.Context, id string, r orders.Refund) (orders.Order, error) {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = s.now()
+	}
+	return s.repo.AddRefund(ctx, id, r)
 }
 
-// ---- helpers ----
+func (s *Service) AddShipment(ctx context.Context, id string, actorID string, in orders.ShipmentInput) (orders.Order, error) {
+	carrier := strings.TrimSpace(in.Carrier)
+	tracking := strings.TrimSpace(in.TrackingNumber)
+	if carrier == "" || tracking == "" {
+		return orders.Order{}, orders.ErrInvalidShipment
+	}
+
+	requested := make(map[string]int64, len(in.Items))
+	for _, it := range in.Items {
+		pid := strings.TrimSpace(it.ProductID)
+		if pid == "" {
+			return orders.Order{}, orders.ErrInvalidProduct
+		}
+		if it.Quantity <= 0 {
+			return orders.Order{}, orders.ErrInvalidQty
+		}
+		requested[pid] += it.Quantity
+	}
+
+	var updated orders.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := s.repo.GetByID(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+		switch cur.Status {
+		case orders.StatusPending, orders.StatusPaid, orders.StatusPartiallyShipped:
+		default:
+			return orders.ErrNotShippable
+		}
 
-// fillTotals computes unitPrice/lineTotal/totalPrice for all orders in list.
-// IMPORTANT: it does NOT write to DB.
-func (s *Service) fillTotals(ctx context.Context, list []orders.Order) error {
-	// cache product prices within the request
-	priceCache := make(map[string]float64, 128)
+		left := cur.Unshipped()
+		if len(requested) == 0 {
+			for pid, q := range left {
+				requested[pid] = q
+			}
+		}
+		if len(requested) == 0 {
+			return orders.ErrOverShipment
+		}
 
-	for i := range list {
-		var total float64
+		// keep the order's line order so shipments read like the order
+		items := make([]orders.ShipmentItem, 0, len(requested))
+		seen := make(map[string]bool, len(requested))
+		for _, it := range cur.Items {
+			q, ok := requested[it.ProductID]
+			if !ok || seen[it.ProductID] {
+				continue
+			}
+			seen[it.ProductID] = true
+			if q > left[it.ProductID] {
+				return orders.ErrOverShipment
+			}
+			left[it.ProductID] -= q
+			items = append(items, orders.ShipmentItem{ProductID: it.ProductID, Quantity: q})
+		}
+		if len(items) != len(requested) {
+			return orders.ErrInvalidProduct
+		}
 
-		for j := range list[i].Items {
-			pid := list[i].Items[j].ProductID
+		next := orders.StatusShipped
+		for _, q := range left {
+			if q > 0 {
+				next = orders.StatusPartiallyShipped
+				break
+			}
+		}
 
-			price, ok := priceCache[pid]
-			if !ok {
-				p, err := s.productsRepo.GetByID(ctx, pid)
-				if err != nil {
-					return orders.ErrInvalidProduct
+		now := s.now()
+		actor := strings.TrimSpace(actorID)
+		var ch *orders.StatusChange
+		if next != cur.Status {
+			ch = &orders.StatusChange{
+				From:      cur.Status,
+				To:        next,
+				ChangedBy: actor,
+				Note:      carrier + " " + tracking,
+				ChangedAt: now,
+			}
+		}
+
+		updated, err = s.repo.AddShipment(ctx, id, cur.Status, orders.Shipment{
+			Carrier:        carrier,
+			TrackingNumber: tracking,
+			Items:          items,
+			ShippedAt:      now,
+			CreatedBy:      actor,
+		}, ch)
+		return err
+	})
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	return updated, nil
+}
+
+func (s *Service) DeliverShipment(ctx context.Context, id string, shipmentID string, actorID string) (orders.Order, error) {
+	var updated orders.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := s.repo.GetByID(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+
+		var (
+			found        bool
+			allDelivered = true
+		)
+		for _, sh := range cur.Shipments {
+			if sh.ID == shipmentID {
+				found = true
+				// delivering twice is a no-op
+				if sh.DeliveredAt != nil {
+					updated = cur
+					return nil
 				}
-				price = p.Price
-				priceCache[pid] = price
+				continue
+			}
+			if sh.DeliveredAt == nil {
+				allDelivered = false
 			}
+		}
+		if !found {
+			return orders.ErrShipmentNotFound
+		}
 
-			list[i].Items[j].UnitPrice = price
-			list[i].Items[j].LineTotal = price * float64(list[i].Items[j].Quantity)
-			total += list[i].Items[j].LineTotal
+		now := s.now()
+		var ch *orders.StatusChange
+		if allDelivered && cur.Status == orders.StatusShipped {
+			ch = &orders.StatusChange{
+				From:      cur.Status,
+				To:        orders.StatusDelivered,
+				ChangedBy: strings.TrimSpace(actorID),
+				Note:      "all shipments delivered",
+				ChangedAt: now,
+			}
 		}
 
-		list[i].TotalPrice = total
+		updated, err = s.repo.SetShipmentDelivered(ctx, id, shipmentID, cur.Status, now, ch)
+		return err
+	})
+	if err != nil {
+		return orders.Order{}, err
 	}
 
-	return nil
+	return updated, nil
+}
+
+// transition moves an order to in.Status inside a unit of work, restocking
+// and releasing promo codes on cancellation. userID scopes the lookup to the owner (nil for admins) and
+// check, if set, can veto the change after the transition table allows it.
+func (s *Service) transition(ctx context.Context, id string, userID *string, actorID string, in orders.UpdateStatusInput, check func(orders.Order) error) (orders.Order, error) {
+	var updated orders.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := s.repo.GetByID(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		if !cur.Status.CanTransitionTo(in.Status) {
+			return orders.ErrInvalidTransition
+		}
+		if check != nil {
+			if err := check(cur); err != nil {
+				return err
+			}
+		}
+
+		updated, err = s.repo.UpdateStatus(ctx, id, orders.StatusChange{
+			From:      cur.Status,
+			To:        in.Status,
+			ChangedBy: strings.TrimSpace(actorID),
+			Note:      strings.TrimSpace(in.Note),
+			ChangedAt: s.now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if in.Status == orders.StatusCancelled {
+			if err := s.restock(ctx, updated, strings.TrimSpace(actorID)); err != nil {
+				return err
+			}
+			return s.releasePromotions(ctx, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return orders.Order{}, err
+	}
+
+	return updated, nil
 }
 
-func (s *Service) computeOne(ctx context.Context, o *orders.Order) (float64, error) {
-	priceCache := make(map[string]float64, 32)
-	var total float64
+// priceItems checks stock and snapshots name and price onto each item. It is
+// the only place order totals are computed; reads use the stored snapshot.
+// All products are loaded in a single query.
+func (s *Service) priceItems(ctx context.Context, items []orders.Item) (money.Money, []promotions.Line, error) {
+	ids := make([]string, 0, len(items))
+	wanted := make(map[string]int64, len(items))
+	for _, it := range items {
+		if _, seen := wanted[it.ProductID]; !seen {
+			ids = append(ids, it.ProductID)
+		}
+		wanted[it.ProductID] += it.Quantity
+	}
 
-	for i := range o.Items {
-		pid := o.Items[i].ProductID
+	prods, err := s.productsRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		if errors.Is(err, products.ErrInvalidID) {
+			return money.Money{}, nil, orders.ErrInvalidProduct
+		}
+		return money.Money{}, nil, err
+	}
 
-		price, ok := priceCache[pid]
+	// a product listed on several lines needs stock for all of them; this is
+	// on-hand stock, as holds (including the customer's own) are only settled
+	// when the stock is decremented
+	for id, qty := range wanted {
+		prod, ok := prods[id]
 		if !ok {
-			p, err := s.productsRepo.GetByID(ctx, pid)
-			if err != nil {
-				return 0, orders.ErrInvalidProduct
+			return money.Money{}, nil, orders.ErrInvalidProduct
+		}
+		if prod.Stock < qty {
+			return money.Money{}, nil, orders.ErrInsufficientStock
+		}
+		// adding it to the base subtotal would panic
+		if !prod.Price.IsIn(s.rates.Base()) {
+			return money.Money{}, nil, orders.ErrCurrencyMismatch
+		}
+	}
+
+	subtotal := money.Zero(s.rates.Base())
+	lines := make([]promotions.Line, 0, len(items))
+	for i := range items {
+		prod := prods[items[i].ProductID]
+
+		items[i].ProductName = prod.Name
+		items[i].UnitPrice = prod.Price
+		items[i].LineTotal = prod.Price.Mul(items[i].Quantity)
+		subtotal = subtotal.Add(items[i].LineTotal)
+		lines = append(lines, promotions.Line{
+			ProductID:  prod.ID,
+			CategoryID: prod.CategoryID,
+			LineTotal:  items[i].LineTotal,
+		})
+	}
+	return subtotal, lines, nil
+}
+
+// taxLines applies the active tax rules to the order lines, each taxed on its
+// total less its share of the discount. Delivery is not taxed.
+func (s *Service) taxLines(ctx context.Context, addr *orders.ShippingAddress, lines []promotions.Line, promo *promotions.Promotion, discount money.Money) ([]orders.TaxLine, error) {
+	rules, err := s.taxesRepo.List(ctx, taxes.ListFilter{ActiveOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	shares := allocateDiscount(lines, promo, discount)
+	taxable := make([]taxes.Line, 0, len(lines))
+	for i, l := range lines {
+		taxable = append(taxable, taxes.Line{CategoryID: l.CategoryID, Amount: l.LineTotal.Sub(shares[i])})
+	}
+
+	var dest taxes.Destination
+	if addr != nil {
+		dest = taxes.Destination{Country: addr.Country, Region: addr.Region}
+	}
+
+	var out []orders.TaxLine
+	for _, c := range taxes.Calculate(rules, dest, taxable) {
+		out = append(out, orders.TaxLine{
+			RuleID:    c.Rule.ID,
+			Name:      c.Rule.Name,
+			Rate:      c.Rule.Rate,
+			Inclusive: c.Rule.Inclusive,
+			Base:      c.Base,
+			Amount:    c.Amount,
+		})
+	}
+	return out, nil
+}
+
+// allocateDiscount splits discount over the lines promo applies to in
+// proportion to their totals. The last of those lines takes the rounding
+// remainder, so the shares add up to discount exactly.
+func allocateDiscount(lines []promotions.Line, promo *promotions.Promotion, discount money.Money) []money.Money {
+	shares := make([]money.Money, len(lines))
+	if promo == nil || !discount.IsPositive() {
+		return shares
+	}
+
+	var eligible money.Money
+	last := -1
+	for i, l := range lines {
+		if promo.InScope(l) {
+			eligible = eligible.Add(l.LineTotal)
+			last = i
+		}
+	}
+	if !eligible.IsPositive() {
+		return shares
+	}
+
+	left := discount
+	for i, l := range lines {
+		if !promo.InScope(l) {
+			continue
+		}
+		if i == last {
+			shares[i] = left
+			break
+		}
+		shares[i] = discount.MulRatio(l.LineTotal.Amount, eligible.Amount)
+		left = left.Sub(shares[i])
+	}
+	return shares
+}
+
+func validateListFilter(f orders.ListFilter) error {
+	if f.Status != nil && !f.Status.Valid() {
+		return orders.ErrInvalidFilter
+	}
+	if f.SortBy != "" && !f.SortBy.Valid() {
+		return orders.ErrInvalidFilter
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return orders.ErrInvalidFilter
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && f.MinTotal.Cmp(*f.MaxTotal) > 0 {
+		return orders.ErrInvalidFilter
+	}
+	return nil
+}
+
+// shippingAddress validates the address given at checkout, or builds one from
+// the user's profile when none was given.
+func (s *Service) shippingAddress(ctx context.Context, userID string, in *orders.ShippingAddress) (*orders.ShippingAddress, error) {
+	var addr orders.ShippingAddress
+	if in != nil {
+		addr = orders.ShippingAddress{
+			FullName:   strings.TrimSpace(in.FullName),
+			Phone:      strings.TrimSpace(in.Phone),
+			Line1:      strings.TrimSpace(in.Line1),
+			Line2:      strings.TrimSpace(in.Line2),
+			City:       strings.TrimSpace(in.City),
+			Region:     strings.TrimSpace(in.Region),
+			PostalCode: strings.TrimSpace(in.PostalCode),
+			Country:    strings.TrimSpace(in.Country),
+		}
+	} else {
+		u, err := s.usersRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, users.ErrUserNotFound) {
+				return nil, orders.ErrInvalidAddress
 			}
-			price = p.Price
-			priceCache[pid] = price
+			return nil, err
+		}
+		// the profile address is free-form, so it all goes into the first line
+		addr = orders.ShippingAddress{
+			FullName: strings.TrimSpace(u.Name),
+			Phone:    strings.TrimSpace(u.Phone),
+			Line1:    strings.TrimSpace(u.Address),
 		}
+	}
 
-		o.Items[i].UnitPrice = price
-		o.Items[i].LineTotal = price * float64(o.Items[i].Quantity)
-		total += o.Items[i].LineTotal
+	if addr.FullName == "" || addr.Line1 == "" {
+		return nil, orders.ErrInvalidAddress
+	}
+	return &addr, nil
+}
+
+// deliveryMethod resolves the chosen delivery method, falling back to the
+// cheapest active one. Returns nil when no methods are configured at all.
+func (s *Service) deliveryMethod(ctx context.Context, methodID string) (*orders.DeliveryMethod, error) {
+	var m delivery.Method
+
+	if id := strings.TrimSpace(methodID); id != "" {
+		var err error
+		m, err = s.deliveryRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, delivery.ErrNotFound) || errors.Is(err, delivery.ErrInvalidID) {
+				return nil, orders.ErrInvalidDelivery
+			}
+			return nil, err
+		}
+		if !m.Active {
+			return nil, orders.ErrInvalidDelivery
+		}
+	} else {
+		methods, err := s.deliveryRepo.List(ctx, delivery.ListFilter{ActiveOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		if len(methods) == 0 {
+			return nil, nil
+		}
+		m = methods[0]
+	}
+
+	if !m.Fee.IsIn(s.rates.Base()) {
+		return nil, orders.ErrCurrencyMismatch
+	}
+	return &orders.DeliveryMethod{MethodID: m.ID, Name: m.Name, Fee: m.Fee}, nil
+}
+
+// redeem counts a promo code use against its per-user and global limits.
+func (s *Service) redeem(ctx context.Context, p promotions.Promotion, o orders.Order) error {
+	if p.MaxUsesPerUser > 0 {
+		used, err := s.promotionsRepo.CountRedemptions(ctx, p.ID, o.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= p.MaxUsesPerUser {
+			return promotions.ErrUsageLimit
+		}
+	}
+
+	return s.promotionsRepo.Redeem(ctx, promotions.Redemption{
+		PromotionID: p.ID,
+		UserID:      o.UserID,
+		OrderID:     o.ID,
+		CreatedAt:   o.CreatedAt,
+	})
+}
+
+// releasePromotions gives the promo codes used on a cancelled order back to
+// their usage limits.
+func (s *Service) releasePromotions(ctx context.Context, o orders.Order) error {
+	for _, d := range o.Discounts {
+		if d.PromotionID == "" {
+			continue
+		}
+		if err := s.promotionsRepo.Release(ctx, d.PromotionID, o.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restock returns the quantities of a cancelled order to inventory.
+// Products deleted since the order was placed are skipped.
+func (s *Service) restock(ctx context.Context, o orders.Order, actorID string) error {
+	now := s.now()
+	moves := make([]stock.Movement, 0, len(o.Items))
+	for _, it := range o.Items {
+		if err := s.productsRepo.IncrementStock(ctx, it.ProductID, it.Quantity); err != nil {
+			if errors.Is(err, products.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if err := s.productsRepo.AddSold(ctx, it.ProductID, -it.Quantity); err != nil {
+			return err
+		}
+		moves = append(moves, stock.Movement{
+			ProductID: it.ProductID,
+			Delta:     it.Quantity,
+			Reason:    stock.ReasonCancellation,
+			ActorID:   actorID,
+			Reference: o.ID,
+			CreatedAt: now,
+		})
 	}
-	return total, nil
+	return s.stockRepo.Append(ctx, moves...)
 }
@@ -0,0 +1,695 @@
+package orderssvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/currencies"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/delivery"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	orderssvc "github.com/bnursik/aitu-ad-final-back/internal/services/orders"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The fakes embed their interface so only the methods Create calls need
+// writing; anything else panics on the nil embedded value.
+
+// countingProducts is a products repo that counts lookups.
+type countingProducts struct {
+	products.Repo
+	byID              map[string]products.Product
+	getByID, getByIDs int
+}
+
+func (r *countingProducts) GetByID(ctx context.Context, id string) (products.Product, error) {
+	r.getByID++
+	p, ok := r.byID[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *countingProducts) GetByIDs(ctx context.Context, ids []string) (map[string]products.Product, error) {
+	r.getByIDs++
+	out := make(map[string]products.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := r.byID[id]; ok {
+			out[id] = p
+		}
+	}
+	return out, nil
+}
+
+func (r *countingProducts) DecrementStock(ctx context.Context, productID string, qty int64) (products.Product, error) {
+	p, ok := r.byID[productID]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	if p.Available() < qty {
+		return products.Product{}, products.ErrInsufficientStock
+	}
+	p.Stock -= qty
+	r.byID[productID] = p
+	return p, nil
+}
+
+func (r *countingProducts) IncrementStock(ctx context.Context, productID string, qty int64) error {
+	p, ok := r.byID[productID]
+	if !ok {
+		return products.ErrNotFound
+	}
+	p.Stock += qty
+	r.byID[productID] = p
+	return nil
+}
+
+func (r *countingProducts) AddSold(ctx context.Context, productID string, qty int64) error {
+	p := r.byID[productID]
+	p.Sold += qty
+	r.byID[productID] = p
+	return nil
+}
+
+type fakeOrders struct{ orders.Repo }
+
+func (fakeOrders) Create(ctx context.Context, o orders.Order) (orders.Order, error) {
+	o.ID = "order-1"
+	return o, nil
+}
+
+// storedOrders keeps orders by id for the status-changing methods.
+type storedOrders struct {
+	orders.Repo
+	byID map[string]orders.Order
+}
+
+func (r *storedOrders) GetByID(ctx context.Context, id string, userID *string) (orders.Order, error) {
+	o, ok := r.byID[id]
+	if !ok || (userID != nil && o.UserID != *userID) {
+		return orders.Order{}, orders.ErrNotFound
+	}
+	return o, nil
+}
+
+func (r *storedOrders) UpdateStatus(ctx context.Context, id string, ch orders.StatusChange) (orders.Order, error) {
+	o := r.byID[id]
+	if o.Status != ch.From {
+		return orders.Order{}, orders.ErrInvalidTransition
+	}
+	o.Status = ch.To
+	o.StatusHistory = append(o.StatusHistory, ch)
+	r.byID[id] = o
+	return o, nil
+}
+
+type fakeDelivery struct{ delivery.Repo }
+
+func (fakeDelivery) List(ctx context.Context, f delivery.ListFilter) ([]delivery.Method, error) {
+	return nil, nil
+}
+
+type fakePromotions struct {
+	promotions.Repo
+	byCode   map[string]promotions.Promotion
+	redeemed []promotions.Redemption
+	released [][2]string // promotion and order ids
+}
+
+func (r *fakePromotions) GetByCode(ctx context.Context, code string) (promotions.Promotion, error) {
+	p, ok := r.byCode[code]
+	if !ok {
+		return promotions.Promotion{}, promotions.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *fakePromotions) Redeem(ctx context.Context, rd promotions.Redemption) error {
+	r.redeemed = append(r.redeemed, rd)
+	return nil
+}
+
+func (r *fakePromotions) Release(ctx context.Context, promotionID string, orderID string) error {
+	r.released = append(r.released, [2]string{promotionID, orderID})
+	return nil
+}
+
+type fakeTaxes struct {
+	taxes.Repo
+	rules []taxes.Rule
+}
+
+func (r fakeTaxes) List(ctx context.Context, f taxes.ListFilter) ([]taxes.Rule, error) {
+	return r.rules, nil
+}
+
+type fakeStock struct {
+	stock.Repo
+	moves []stock.Movement
+}
+
+func (r *fakeStock) Append(ctx context.Context, ms ...stock.Movement) error {
+	r.moves = append(r.moves, ms...)
+	return nil
+}
+
+type fakeNotifier struct {
+	alerts []products.StockAlert
+}
+
+func (n *fakeNotifier) NotifyLowStock(ctx context.Context, a products.StockAlert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+// fakeHolds gives a customer's holds back to the products as they are released.
+type fakeHolds struct {
+	reservations.Service
+	products *countingProducts
+	held     map[string]int64
+}
+
+func (h *fakeHolds) Release(ctx context.Context, userID, productID string) error {
+	if _, ok := h.held[productID]; !ok {
+		return nil
+	}
+	p := h.products.byID[productID]
+	p.Reserved -= h.held[productID]
+	h.products.byID[productID] = p
+	delete(h.held, productID)
+	return nil
+}
+
+// fakeRates quotes every order in the default currency.
+type fakeRates struct{ currencies.Service }
+
+func (fakeRates) Base() money.Currency { return money.Default }
+
+func (fakeRates) Quote(ctx context.Context, cur money.Currency) (currencies.Quote, error) {
+	return currencies.Quote{Currency: money.Default, Rate: 1}, nil
+}
+
+type createFixture struct {
+	svc      *orderssvc.Service
+	products *countingProducts
+	holds    *fakeHolds
+	stock    *fakeStock
+	notifier *fakeNotifier
+	in       orders.CreateInput
+}
+
+// newCreateFixture stocks n products and returns a service over them with
+// an order input that buys one of each.
+func newCreateFixture(tb testing.TB, n int) *createFixture {
+	tb.Helper()
+
+	f := &createFixture{
+		products: &countingProducts{byID: make(map[string]products.Product, n)},
+		stock:    &fakeStock{},
+		notifier: &fakeNotifier{},
+		in: orders.CreateInput{
+			ShippingAddress: &orders.ShippingAddress{FullName: "Test Customer", Line1: "1 Test St"},
+		},
+	}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("product-%d", i)
+		f.products.byID[id] = products.Product{ID: id, Name: "product " + id, Price: money.New(1000, money.Default), Stock: 1 << 40}
+		f.in.Items = append(f.in.Items, orders.Item{ProductID: id, Quantity: 1})
+	}
+
+	f.holds = &fakeHolds{products: f.products, held: map[string]int64{}}
+	f.svc = orderssvc.New(orderssvc.Deps{
+		Orders:   fakeOrders{},
+		Products: f.products,
+		Stock:    f.stock,
+		Delivery: fakeDelivery{},
+		Taxes:    fakeTaxes{},
+		Rates:    fakeRates{},
+		Holds:    f.holds,
+		Notifier: f.notifier,
+		Tx:       memrepo.NewUnitOfWork(),
+	}, 0)
+	return f
+}
+
+// TestCreateAlertsAfterOuterCommit creates an order inside a caller's unit of
+// work, the way cart checkout does, and checks the low-stock alert waits for
+// that unit of work and is dropped when it rolls back.
+func TestCreateAlertsAfterOuterCommit(t *testing.T) {
+	ctx := context.Background()
+	prods := memrepo.NewProductsRepo()
+	p, err := prods.Create(ctx, products.Product{
+		CategoryID:       primitive.NewObjectID().Hex(),
+		Name:             "last one",
+		Price:            money.New(1000, money.Default),
+		Stock:            2,
+		ReorderThreshold: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := memrepo.NewUnitOfWork(prods)
+	notifier := &fakeNotifier{}
+	svc := orderssvc.New(orderssvc.Deps{
+		Orders:   fakeOrders{},
+		Products: prods,
+		Stock:    &fakeStock{},
+		Delivery: fakeDelivery{},
+		Taxes:    fakeTaxes{},
+		Rates:    fakeRates{},
+		Holds:    &fakeHolds{},
+		Notifier: notifier,
+		Tx:       tx,
+	}, 0)
+	in := orders.CreateInput{
+		Items:           []orders.Item{{ProductID: p.ID, Quantity: 1}},
+		ShippingAddress: &orders.ShippingAddress{FullName: "Test Customer", Line1: "1 Test St"},
+	}
+	uid := primitive.NewObjectID().Hex()
+
+	rollback := errors.New("rollback")
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.Create(ctx, uid, in); err != nil {
+			return err
+		}
+		if len(notifier.alerts) != 0 {
+			t.Error("alert sent before the outer unit of work committed")
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("WithinTx = %v, want %v", err, rollback)
+	}
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("rolled-back order alerted: %+v", notifier.alerts)
+	}
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := svc.Create(ctx, uid, in)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.alerts) != 1 || notifier.alerts[0].ProductID != p.ID {
+		t.Fatalf("alerts = %+v, want one for %s", notifier.alerts, p.ID)
+	}
+}
+
+func TestCreateLoadsProductsOnce(t *testing.T) {
+	f := newCreateFixture(t, 10)
+
+	o, err := f.svc.Create(context.Background(), "user-1", f.in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := money.New(10*1000, money.Default); o.Subtotal != want {
+		t.Errorf("subtotal = %v, want %v", o.Subtotal, want)
+	}
+	if f.products.getByIDs != 1 || f.products.getByID != 0 {
+		t.Errorf("lookups: GetByIDs %d, GetByID %d; want 1 and 0", f.products.getByIDs, f.products.getByID)
+	}
+}
+
+func TestCreateRecordsMovements(t *testing.T) {
+	f := newCreateFixture(t, 2)
+	f.in.Items[1].Quantity = 3
+
+	o, err := f.svc.Create(context.Background(), "user-1", f.in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"product-0": -1, "product-1": -3}
+	if len(f.stock.moves) != len(want) {
+		t.Fatalf("movements = %+v, want one per line", f.stock.moves)
+	}
+	for _, m := range f.stock.moves {
+		if m.Delta != want[m.ProductID] || m.Reason != stock.ReasonOrder || m.Reference != o.ID || m.ActorID != "user-1" {
+			t.Errorf("movement = %+v, want %d for order %s", m, want[m.ProductID], o.ID)
+		}
+		if sold := f.products.byID[m.ProductID].Sold; sold != -want[m.ProductID] {
+			t.Errorf("%s sold = %d, want %d", m.ProductID, sold, -want[m.ProductID])
+		}
+	}
+}
+
+func TestCreateAlertsOnceBelowThreshold(t *testing.T) {
+	ctx := context.Background()
+	f := newCreateFixture(t, 2)
+	for id, stock := range map[string]int64{"product-0": 4, "product-1": 100} {
+		p := f.products.byID[id]
+		p.Stock, p.ReorderThreshold = stock, 3
+		f.products.byID[id] = p
+	}
+
+	if _, err := f.svc.Create(ctx, "user-1", f.in); err != nil {
+		t.Fatal(err)
+	}
+	// product-0 went 4 -> 3 and crossed; product-1 is nowhere near
+	if a := f.notifier.alerts; len(a) != 1 || a[0].ProductID != "product-0" || a[0].Stock != 3 || a[0].Threshold != 3 || a[0].OrderID != "order-1" {
+		t.Fatalf("alerts = %+v, want one for product-0 at 3", a)
+	}
+
+	// already low: no second alert
+	if _, err := f.svc.Create(ctx, "user-1", f.in); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.notifier.alerts) != 1 {
+		t.Errorf("alerts = %+v, want still one", f.notifier.alerts)
+	}
+
+	// an order that fails on a later line raises nothing, even for a
+	// product it took below the threshold first: product-1 is all held by
+	// other carts
+	p := f.products.byID["product-0"]
+	p.Stock = 4
+	f.products.byID["product-0"] = p
+	p = f.products.byID["product-1"]
+	p.Reserved = p.Stock
+	f.products.byID["product-1"] = p
+	if _, err := f.svc.Create(ctx, "user-1", f.in); !errors.Is(err, orders.ErrInsufficientStock) {
+		t.Fatalf("Create = %v, want %v", err, orders.ErrInsufficientStock)
+	}
+	if len(f.notifier.alerts) != 1 {
+		t.Errorf("alerts = %+v after a failed order", f.notifier.alerts)
+	}
+}
+
+func TestCreateTakesHeldStock(t *testing.T) {
+	f := newCreateFixture(t, 1)
+	p := f.products.byID["product-0"]
+	p.Stock, p.Reserved = 1, 1
+	f.products.byID["product-0"] = p
+
+	// the only unit is held for someone else
+	if _, err := f.svc.Create(context.Background(), "user-1", f.in); !errors.Is(err, orders.ErrInsufficientStock) {
+		t.Fatalf("Create past another cart's hold = %v, want %v", err, orders.ErrInsufficientStock)
+	}
+
+	// held in the customer's own cart, it is released to the order
+	f.holds.held["product-0"] = 1
+	if _, err := f.svc.Create(context.Background(), "user-1", f.in); err != nil {
+		t.Fatal(err)
+	}
+	if p := f.products.byID["product-0"]; p.Stock != 0 || p.Reserved != 0 || len(f.holds.held) != 0 {
+		t.Errorf("stock %d reserved %d holds %v, want all settled", p.Stock, p.Reserved, f.holds.held)
+	}
+}
+
+func TestCreateRejectsForeignPrices(t *testing.T) {
+	f := newCreateFixture(t, 2)
+	// priced before BASE_CURRENCY changed, never converted
+	p := f.products.byID["product-1"]
+	p.Price = money.New(1000, "USD")
+	f.products.byID["product-1"] = p
+
+	if _, err := f.svc.Create(context.Background(), "user-1", f.in); !errors.Is(err, orders.ErrCurrencyMismatch) {
+		t.Fatalf("Create = %v, want %v", err, orders.ErrCurrencyMismatch)
+	}
+	if len(f.stock.moves) != 0 {
+		t.Errorf("movements = %+v for a rejected order", f.stock.moves)
+	}
+}
+
+func TestUpdateStatusRefusesDerivedStatuses(t *testing.T) {
+	f := newCreateFixture(t, 1)
+	// each of these follows a payment or shipments, never an admin's say-so
+	for _, st := range []orders.Status{orders.StatusPaid, orders.StatusPartiallyShipped, orders.StatusShipped, orders.StatusDelivered} {
+		_, err := f.svc.UpdateStatus(context.Background(), "order-1", "admin-1", orders.UpdateStatusInput{Status: st})
+		if !errors.Is(err, orders.ErrInvalidTransition) {
+			t.Errorf("UpdateStatus to %s = %v, want %v", st, err, orders.ErrInvalidTransition)
+		}
+	}
+	if _, err := f.svc.UpdateStatus(context.Background(), "order-1", "admin-1", orders.UpdateStatusInput{Status: "lost"}); !errors.Is(err, orders.ErrInvalidStatus) {
+		t.Errorf("UpdateStatus to an unknown status = %v, want %v", err, orders.ErrInvalidStatus)
+	}
+}
+
+type cancelFixture struct {
+	svc        *orderssvc.Service
+	orders     *storedOrders
+	products   *countingProducts
+	stock      *fakeStock
+	promotions *fakePromotions
+}
+
+// newCancelFixture stores o as order-1 for user-1, buying two of a mouse
+// that has 5 left and 2 sold, behind a service with a one-day cancel window.
+func newCancelFixture(o orders.Order) *cancelFixture {
+	o.ID, o.UserID = "order-1", "user-1"
+	o.Items = []orders.Item{{ProductID: "mouse", Quantity: 2, UnitPrice: kzt(1000)}}
+	f := &cancelFixture{
+		orders: &storedOrders{byID: map[string]orders.Order{o.ID: o}},
+		products: &countingProducts{byID: map[string]products.Product{
+			"mouse": {ID: "mouse", Price: kzt(1000), Stock: 5, Sold: 2},
+		}},
+		stock:      &fakeStock{},
+		promotions: &fakePromotions{},
+	}
+	f.svc = orderssvc.New(orderssvc.Deps{
+		Orders:     f.orders,
+		Products:   f.products,
+		Stock:      f.stock,
+		Promotions: f.promotions,
+		Tx:         memrepo.NewUnitOfWork(),
+	}, 24*time.Hour)
+	return f
+}
+
+func TestCancel(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		order   orders.Order
+		userID  string
+		wantErr error
+	}{
+		{"pending", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, "user-1", nil},
+		{"paid", orders.Order{Status: orders.StatusPaid, PaymentStatus: orders.PaymentPaid, CreatedAt: now}, "user-1", orders.ErrInvalidTransition},
+		{"paid and refunded", orders.Order{Status: orders.StatusPaid, PaymentStatus: orders.PaymentRefunded, CreatedAt: now}, "user-1", orders.ErrInvalidTransition},
+		{"partially shipped", orders.Order{Status: orders.StatusPartiallyShipped, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, "user-1", orders.ErrInvalidTransition},
+		{"shipped", orders.Order{Status: orders.StatusShipped, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, "user-1", orders.ErrInvalidTransition},
+		{"already cancelled", orders.Order{Status: orders.StatusCancelled, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, "user-1", orders.ErrInvalidTransition},
+		{"outside the window", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now.Add(-25 * time.Hour)}, "user-1", orders.ErrCancelWindow},
+		{"someone else's", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, "user-2", orders.ErrNotFound},
+		{"no user", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now}, " ", orders.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCancelFixture(tt.order)
+
+			got, err := f.svc.Cancel(context.Background(), "order-1", tt.userID, orders.CancelInput{Reason: " changed my mind "})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cancel = %v, want %v", err, tt.wantErr)
+			}
+			mouse := f.products.byID["mouse"]
+			if tt.wantErr != nil {
+				if f.orders.byID["order-1"].Status != tt.order.Status || mouse.Stock != 5 || mouse.Sold != 2 || len(f.stock.moves) != 0 {
+					t.Errorf("refused cancel changed state: order %s, stock %d, sold %d, %d movements", f.orders.byID["order-1"].Status, mouse.Stock, mouse.Sold, len(f.stock.moves))
+				}
+				return
+			}
+
+			if got.Status != orders.StatusCancelled {
+				t.Errorf("status = %s, want %s", got.Status, orders.StatusCancelled)
+			}
+			if h := got.StatusHistory; len(h) != 1 || h[0].From != orders.StatusPending || h[0].ChangedBy != "user-1" || h[0].Note != "changed my mind" {
+				t.Errorf("history = %+v", h)
+			}
+			if mouse.Stock != 7 || mouse.Sold != 0 {
+				t.Errorf("mouse stock %d sold %d, want 7 and 0", mouse.Stock, mouse.Sold)
+			}
+			if m := f.stock.moves; len(m) != 1 || m[0].Delta != 2 || m[0].Reason != stock.ReasonCancellation || m[0].Reference != "order-1" {
+				t.Errorf("movements = %+v, want +2 mice for the cancellation", m)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusCancel(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		order   orders.Order
+		wantErr error
+	}{
+		{"unpaid", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid}, nil},
+		{"paid", orders.Order{Status: orders.StatusPaid, PaymentStatus: orders.PaymentPaid}, orders.ErrAlreadyPaid},
+		{"paid then refunded", orders.Order{Status: orders.StatusPaid, PaymentStatus: orders.PaymentRefunded}, nil},
+		// the customer's window doesn't bind support
+		{"old", orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: now.Add(-72 * time.Hour)}, nil},
+		{"shipped", orders.Order{Status: orders.StatusShipped, PaymentStatus: orders.PaymentUnpaid}, orders.ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCancelFixture(tt.order)
+
+			got, err := f.svc.UpdateStatus(context.Background(), "order-1", "admin-1", orders.UpdateStatusInput{Status: orders.StatusCancelled})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateStatus = %v, want %v", err, tt.wantErr)
+			}
+			mouse := f.products.byID["mouse"]
+			if tt.wantErr != nil {
+				if f.orders.byID["order-1"].Status != tt.order.Status || mouse.Stock != 5 || len(f.stock.moves) != 0 {
+					t.Errorf("refused cancel changed state: order %s, stock %d, %d movements", f.orders.byID["order-1"].Status, mouse.Stock, len(f.stock.moves))
+				}
+				return
+			}
+			if got.Status != orders.StatusCancelled || mouse.Stock != 7 || mouse.Sold != 0 {
+				t.Errorf("order %s, mouse stock %d sold %d, want cancelled with 7 and 0", got.Status, mouse.Stock, mouse.Sold)
+			}
+		})
+	}
+}
+
+func TestCancelReleasesPromotion(t *testing.T) {
+	discounted := orders.Order{
+		Status:        orders.StatusPending,
+		PaymentStatus: orders.PaymentUnpaid,
+		Discounts:     []orders.Discount{{PromotionID: "promo-1", Code: "SAVE10", Amount: kzt(200)}},
+		CreatedAt:     time.Now().UTC(),
+	}
+	cancels := map[string]func(f *cancelFixture) error{
+		"by the customer": func(f *cancelFixture) error {
+			_, err := f.svc.Cancel(context.Background(), "order-1", "user-1", orders.CancelInput{})
+			return err
+		},
+		"by an admin": func(f *cancelFixture) error {
+			_, err := f.svc.UpdateStatus(context.Background(), "order-1", "admin-1", orders.UpdateStatusInput{Status: orders.StatusCancelled})
+			return err
+		},
+	}
+	for name, cancel := range cancels {
+		t.Run(name, func(t *testing.T) {
+			f := newCancelFixture(discounted)
+			if err := cancel(f); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.promotions.released; len(got) != 1 || got[0] != [2]string{"promo-1", "order-1"} {
+				t.Errorf("released = %v, want promo-1 for order-1", got)
+			}
+			// a second cancel is refused and releases nothing more
+			if err := cancel(f); !errors.Is(err, orders.ErrInvalidTransition) {
+				t.Errorf("second cancel = %v, want %v", err, orders.ErrInvalidTransition)
+			}
+			if len(f.promotions.released) != 1 {
+				t.Errorf("released %d times, want once", len(f.promotions.released))
+			}
+		})
+	}
+
+	f := newCancelFixture(orders.Order{Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, CreatedAt: time.Now().UTC()})
+	if err := cancels["by the customer"](f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.promotions.released) != 0 {
+		t.Errorf("order without a promo code released %v", f.promotions.released)
+	}
+}
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func TestCreateTaxesDiscountedLines(t *testing.T) {
+	prods := &countingProducts{byID: map[string]products.Product{
+		"mouse":  {ID: "mouse", Name: "Mouse", CategoryID: "electronics", Price: kzt(3000), Stock: 10},
+		"novel":  {ID: "novel", Name: "Novel", CategoryID: "books", Price: kzt(1999), Stock: 10},
+		"poster": {ID: "poster", Name: "Poster", CategoryID: "decor", Price: kzt(1001), Stock: 10},
+	}}
+	promos := &fakePromotions{byCode: map[string]promotions.Promotion{
+		// a third off everything but decor
+		"THIRD": {ID: "promo-1", Code: "THIRD", Type: promotions.DiscountPercentage, Value: 100.0 / 3, CategoryIDs: []string{"electronics", "books"}, Active: true},
+	}}
+	rules := fakeTaxes{rules: []taxes.Rule{
+		{ID: "vat", Name: "VAT", Rate: 12, Country: "KZ", Active: true},
+		{ID: "books", Name: "Books exempt", Rate: 0, CategoryID: "books", Active: true},
+	}}
+	svc := orderssvc.New(orderssvc.Deps{
+		Orders:     fakeOrders{},
+		Products:   prods,
+		Stock:      &fakeStock{},
+		Promotions: promos,
+		Delivery:   fakeDelivery{},
+		Taxes:      rules,
+		Rates:      fakeRates{},
+		Holds:      &fakeHolds{products: prods},
+		Notifier:   &fakeNotifier{},
+		Tx:         memrepo.NewUnitOfWork(),
+	}, 0)
+
+	o, err := svc.Create(context.Background(), "user-1", orders.CreateInput{
+		Items: []orders.Item{
+			{ProductID: "mouse", Quantity: 2},
+			{ProductID: "novel", Quantity: 1},
+			{ProductID: "poster", Quantity: 1},
+		},
+		ShippingAddress: &orders.ShippingAddress{FullName: "Test Customer", Line1: "1 Test St", Country: "kz"},
+		PromoCode:       "third",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// eligible 6000+1999 = 7999, a third of it 2666; the mouse line takes
+	// 2666*6000/7999 = 1999.8 -> 2000 and the novel the remaining 666
+	if got := o.Discounts[0].Amount; got != kzt(2666) {
+		t.Fatalf("discount = %v, want 26.66", got)
+	}
+	want := []orders.TaxLine{
+		// mouse 6000-2000 and poster 1001, untouched by the promotion
+		{RuleID: "vat", Name: "VAT", Rate: 12, Base: kzt(5001), Amount: kzt(600)},
+		{RuleID: "books", Name: "Books exempt", Rate: 0, Base: kzt(1333), Amount: kzt(0)},
+	}
+	if len(o.Taxes) != len(want) {
+		t.Fatalf("taxes = %+v, want %+v", o.Taxes, want)
+	}
+	for i := range want {
+		if o.Taxes[i] != want[i] {
+			t.Errorf("tax %d = %+v, want %+v", i, o.Taxes[i], want[i])
+		}
+	}
+	if want := kzt(9000 - 2666 + 600); o.TotalPrice != want {
+		t.Errorf("total = %v, want %v", o.TotalPrice, want)
+	}
+	if len(promos.redeemed) != 1 || promos.redeemed[0].OrderID != "order-1" {
+		t.Errorf("redemptions = %+v", promos.redeemed)
+	}
+}
+
+// BenchmarkCreate prices orders of growing size. Lookups per order stay at
+// one GetByIDs however many lines the order has, where looking products up
+// one at a time took a GetByID per line.
+func BenchmarkCreate(b *testing.B) {
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("lines=%d", n), func(b *testing.B) {
+			f := newCreateFixture(b, n)
+			prods := f.products
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.svc.Create(ctx, "user-1", f.in); err != nil {
+					b.Fatal(err)
+				}
+				f.stock.moves = f.stock.moves[:0]
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(prods.getByIDs)/float64(b.N), "GetByIDs/op")
+			b.ReportMetric(float64(prods.getByID)/float64(b.N), "GetByID/op")
+			if prods.getByIDs != b.N || prods.getByID != 0 {
+				b.Fatalf("lookups: GetByIDs %d, GetByID %d over %d orders", prods.getByIDs, prods.getByID, b.N)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package paymentssvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+type Service struct {
+	repo      payments.Repo
+	ordersSvc orders.Service
+	provider  payments.Provider
+	tx        uow.UnitOfWork
+	now       func() time.Time
+}
+
+func New(repo payments.Repo, ordersSvc orders.Service, provider payments.Provider, tx uow.UnitOfWork) *Service {
+	return &Service{
+		repo:      repo,
+		ordersSvc: ordersSvc,
+		provider:  provider,
+		tx:        tx,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ payments.Service = (*Service)(nil)
+
+func (s *Service) SignatureHeader() string {
+	return s.provider.SignatureHeader()
+}
+
+// Pay creates a payment intent for the amount due on the order. Calling it again while
+// the last intent is still pending returns that intent instead of a new one.
+func (s *Service) Pay(ctx context.Context, orderID string, userID string) (payments.Payment, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return payments.Payment{}, orders.ErrForbidden
+	}
+
+	o, err := s.ordersSvc.Get(ctx, orderID, uid, false)
+	if err != nil {
+		return payments.Payment{}, err
+	}
+	if o.Status != orders.StatusPending || !o.AmountDue().IsPositive() {
+		return payments.Payment{}, payments.ErrNotPayable
+	}
+	if o.PaymentStatus == orders.PaymentPaid || o.PaymentStatus == orders.PaymentRefunded {
+		return payments.Payment{}, payments.ErrNotPayable
+	}
+
+	last, err := s.repo.GetLatestByOrderID(ctx, o.ID)
+	switch {
+	case err == nil:
+		if last.Status == payments.StatusPending && last.Amount == o.AmountDue() {
+			return last, nil
+		}
+	case !errors.Is(err, payments.ErrNotFound):
+		return payments.Payment{}, err
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, o.ID, o.AmountDue())
+	if err != nil {
+		return payments.Payment{}, err
+	}
+
+	now := s.now()
+	return s.repo.Create(ctx, payments.Payment{
+		OrderID:      o.ID,
+		UserID:       uid,
+		Provider:     s.provider.Name(),
+		ProviderRef:  intent.Ref,
+		ClientSecret: intent.ClientSecret,
+		Amount:       o.AmountDue(),
+		Status:       payments.StatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+}
+
+// HandleWebhook applies a provider notification. Providers retry deliveries,
+// so events for payments already in their final state are ignored.
+func (s *Service) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	ev, err := s.provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	p, err := s.repo.GetByProviderRef(ctx, s.provider.Name(), ev.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	switch ev.Type {
+	case payments.EventAuthorized:
+		if p.Status != payments.StatusPending {
+			return nil
+		}
+		if err := s.provider.Capture(ctx, p.ProviderRef, p.Amount); err != nil {
+			return err
+		}
+		return s.settle(ctx, p, payments.StatusPaid, orders.PaymentPaid)
+
+	case payments.EventSucceeded:
+		if p.Status != payments.StatusPending {
+			return nil
+		}
+		return s.settle(ctx, p, payments.StatusPaid, orders.PaymentPaid)
+
+	case payments.EventFailed:
+		if p.Status != payments.StatusPending {
+			return nil
+		}
+		// a stale attempt failing must not undo a later successful one
+		o, err := s.ordersSvc.Get(ctx, p.OrderID, "", true)
+		if err != nil {
+			return err
+		}
+		if o.PaymentStatus == orders.PaymentPaid || o.PaymentStatus == orders.PaymentRefunded {
+			_, err := s.repo.UpdateStatus(ctx, p.ID, payments.StatusFailed, s.now())
+			return err
+		}
+		return s.settle(ctx, p, payments.StatusFailed, orders.PaymentFailed)
+	}
+
+	return nil
+}
+
+// Refund returns what is left of the order's payment after earlier refunds
+// and records it on the order.
+func (s *Service) Refund(ctx context.Context, orderID string) (payments.Payment, error) {
+	p, o, err := s.refundable(ctx, orderID)
+	if err != nil {
+		return payments.Payment{}, err
+	}
+	left := o.TotalPrice.Sub(o.Refunded())
+	if !left.IsPositive() {
+		return payments.Payment{}, payments.ErrNotRefundable
+	}
+
+	return s.refund(ctx, p, o, orders.Refund{Amount: left})
+}
+
+func (s *Service) RefundReturn(ctx context.Context, orderID string, returnID string, amount money.Money) (payments.Payment, error) {
+	if !amount.IsPositive() {
+		return payments.Payment{}, payments.ErrNotRefundable
+	}
+	p, o, err := s.refundable(ctx, orderID)
+	if err != nil {
+		return payments.Payment{}, err
+	}
+
+	return s.refund(ctx, p, o, orders.Refund{ReturnID: returnID, Amount: amount})
+}
+
+// refundable loads the order's settled payment along with the order.
+func (s *Service) refundable(ctx context.Context, orderID string) (payments.Payment, orders.Order, error) {
+	p, err := s.repo.GetLatestByOrderID(ctx, strings.TrimSpace(orderID))
+	if err != nil {
+		if errors.Is(err, payments.ErrNotFound) {
+			return payments.Payment{}, orders.Order{}, payments.ErrNotRefundable
+		}
+		return payments.Payment{}, orders.Order{}, err
+	}
+	if p.Status != payments.StatusPaid {
+		return payments.Payment{}, orders.Order{}, payments.ErrNotRefundable
+	}
+	o, err := s.ordersSvc.Get(ctx, p.OrderID, "", true)
+	if err != nil {
+		return payments.Payment{}, orders.Order{}, err
+	}
+	if !o.TotalPrice.IsPositive() {
+		return payments.Payment{}, orders.Order{}, payments.ErrNotRefundable
+	}
+	return p, o, nil
+}
+
+// refund gives r.Amount back through the provider and records it on the
+// order. The provider is sent the same share of the charge, so the refunds
+// of an order paid in another currency add up to exactly what was charged.
+// The payment becomes refunded once nothing is left of it.
+func (s *Service) refund(ctx context.Context, p payments.Payment, o orders.Order, r orders.Refund) (payments.Payment, error) {
+	before := o.Refunded()
+	after := before.Add(r.Amount)
+	if after.Cmp(o.TotalPrice) > 0 {
+		return payments.Payment{}, payments.ErrRefundExceeded
+	}
+
+	total := o.TotalPrice.Amount
+	charge := p.Amount.MulRatio(after.Amount, total).Sub(p.Amount.MulRatio(before.Amount, total))
+	if err := s.provider.Refund(ctx, p.ProviderRef, charge); err != nil {
+		return payments.Payment{}, err
+	}
+
+	r.CreatedAt = s.now()
+	updated := p
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if after.Cmp(o.TotalPrice) == 0 {
+			var err error
+			updated, err = s.repo.UpdateStatus(ctx, p.ID, payments.StatusRefunded, r.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if _, err := s.ordersSvc.RecordPayment(ctx, p.OrderID, orders.PaymentRefunded); err != nil {
+				return err
+			}
+		}
+		_, err := s.ordersSvc.RecordRefund(ctx, p.OrderID, r)
+		return err
+	})
+	if err != nil {
+		return payments.Payment{}, err
+	}
+
+	return updated, nil
+}
+
+// settle records the outcome on both the payment and its order. Money that
+// arrives for an order cancelled in the meantime is refunded straight away.
+func (s *Service) settle(ctx context.Context, p payments.Payment, st payments.Status, ps orders.PaymentStatus) error {
+	err := s.record(ctx, p, st, ps)
+	if !errors.Is(err, orders.ErrPaidAfterCancel) {
+		return err
+	}
+
+	// the payment is still pending here, so if the refund fails the
+	// provider's retry of the webhook comes back to this point
+	if err := s.provider.Refund(ctx, p.ProviderRef, p.Amount); err != nil {
+		return fmt.Errorf("refund payment %s for cancelled order %s: %w", p.ID, p.OrderID, err)
+	}
+	log.Printf("payments: refunded payment %s received for cancelled order %s", p.ID, p.OrderID)
+	return s.record(ctx, p, payments.StatusRefunded, orders.PaymentRefunded)
+}
+
+func (s *Service) record(ctx context.Context, p payments.Payment, st payments.Status, ps orders.PaymentStatus) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.UpdateStatus(ctx, p.ID, st, s.now()); err != nil {
+			return err
+		}
+		_, err := s.ordersSvc.RecordPayment(ctx, p.OrderID, ps)
+		return err
+	})
+}
@@ -0,0 +1,445 @@
+package paymentssvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/payments/fakeprovider"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	paymentssvc "github.com/bnursik/aitu-ad-final-back/internal/services/payments"
+)
+
+// The fakes embed their interface so only the methods payments call need
+// writing; anything else panics on the nil embedded value.
+
+type fakePayments struct {
+	byID  map[string]payments.Payment
+	order []string // IDs in creation order
+}
+
+func (r *fakePayments) Create(ctx context.Context, p payments.Payment) (payments.Payment, error) {
+	p.ID = fmt.Sprintf("pay-%d", len(r.order)+1)
+	r.byID[p.ID] = p
+	r.order = append(r.order, p.ID)
+	return p, nil
+}
+
+func (r *fakePayments) GetByProviderRef(ctx context.Context, provider, ref string) (payments.Payment, error) {
+	for _, p := range r.byID {
+		if p.Provider == provider && p.ProviderRef == ref {
+			return p, nil
+		}
+	}
+	return payments.Payment{}, payments.ErrNotFound
+}
+
+func (r *fakePayments) GetLatestByOrderID(ctx context.Context, orderID string) (payments.Payment, error) {
+	for i := len(r.order) - 1; i >= 0; i-- {
+		if p := r.byID[r.order[i]]; p.OrderID == orderID {
+			return p, nil
+		}
+	}
+	return payments.Payment{}, payments.ErrNotFound
+}
+
+func (r *fakePayments) UpdateStatus(ctx context.Context, id string, st payments.Status, at time.Time) (payments.Payment, error) {
+	p, ok := r.byID[id]
+	if !ok {
+		return payments.Payment{}, payments.ErrNotFound
+	}
+	p.Status, p.UpdatedAt = st, at
+	r.byID[id] = p
+	return p, nil
+}
+
+type fakeOrders struct {
+	orders.Service
+	byID map[string]orders.Order
+}
+
+func (s *fakeOrders) Get(ctx context.Context, id string, userID string, isAdmin bool) (orders.Order, error) {
+	o, ok := s.byID[id]
+	if !ok || (!isAdmin && o.UserID != userID) {
+		return orders.Order{}, orders.ErrNotFound
+	}
+	return o, nil
+}
+
+func (s *fakeOrders) RecordPayment(ctx context.Context, id string, ps orders.PaymentStatus) (orders.Order, error) {
+	o := s.byID[id]
+	if ps == orders.PaymentPaid && o.Status == orders.StatusCancelled {
+		return orders.Order{}, orders.ErrPaidAfterCancel
+	}
+	o.PaymentStatus = ps
+	if ps == orders.PaymentPaid && o.Status == orders.StatusPending {
+		o.Status = orders.StatusPaid
+	}
+	s.byID[id] = o
+	return o, nil
+}
+
+func (s *fakeOrders) RecordRefund(ctx context.Context, id string, r orders.Refund) (orders.Order, error) {
+	o := s.byID[id]
+	o.Refunds = append(o.Refunds, r)
+	s.byID[id] = o
+	return o, nil
+}
+
+// recordingProvider is the fake gateway, remembering what it captured and refunded.
+type recordingProvider struct {
+	*fakeprovider.Provider
+	captured, refunded []string
+	refundedAmounts    []money.Money
+}
+
+func (p *recordingProvider) Capture(ctx context.Context, ref string, amount money.Money) error {
+	p.captured = append(p.captured, ref)
+	return p.Provider.Capture(ctx, ref, amount)
+}
+
+func (p *recordingProvider) Refund(ctx context.Context, ref string, amount money.Money) error {
+	p.refunded = append(p.refunded, ref)
+	p.refundedAmounts = append(p.refundedAmounts, amount)
+	return p.Provider.Refund(ctx, ref, amount)
+}
+
+const (
+	uid     = "user-1"
+	orderID = "order-1"
+)
+
+type fixture struct {
+	svc      *paymentssvc.Service
+	payments *fakePayments
+	orders   *fakeOrders
+	provider *recordingProvider
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		payments: &fakePayments{byID: map[string]payments.Payment{}},
+		orders: &fakeOrders{byID: map[string]orders.Order{
+			orderID: {ID: orderID, UserID: uid, Status: orders.StatusPending, PaymentStatus: orders.PaymentUnpaid, TotalPrice: money.New(4999, money.Default)},
+		}},
+		provider: &recordingProvider{Provider: fakeprovider.New("whsec")},
+	}
+	f.svc = paymentssvc.New(f.payments, f.orders, f.provider, memrepo.NewUnitOfWork())
+	return f
+}
+
+// send delivers a webhook for ref, signed unless signature is given.
+func (f *fixture) send(t *testing.T, typ payments.EventType, ref string, signature ...string) error {
+	t.Helper()
+	body := []byte(fmt.Sprintf(`{"type":%q,"providerRef":%q}`, typ, ref))
+	sig := f.provider.Sign(body)
+	if len(signature) > 0 {
+		sig = signature[0]
+	}
+	return f.svc.HandleWebhook(context.Background(), body, sig)
+}
+
+func (f *fixture) pay(t *testing.T) payments.Payment {
+	t.Helper()
+	p, err := f.svc.Pay(context.Background(), orderID, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPay(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	if _, err := f.svc.Pay(ctx, orderID, "someone-else"); !errors.Is(err, orders.ErrNotFound) {
+		t.Fatalf("Pay of another user's order = %v, want %v", err, orders.ErrNotFound)
+	}
+
+	p := f.pay(t)
+	if p.Status != payments.StatusPending || p.Amount != money.New(4999, money.Default) || p.ClientSecret == "" {
+		t.Fatalf("payment = %+v, want a pending intent for 49.99", p)
+	}
+	// paying again while the intent is pending resumes it
+	if again := f.pay(t); again.ID != p.ID {
+		t.Errorf("second Pay made %s, want %s resumed", again.ID, p.ID)
+	}
+
+	if err := f.send(t, payments.EventSucceeded, p.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.Pay(ctx, orderID, uid); !errors.Is(err, payments.ErrNotPayable) {
+		t.Errorf("Pay of a paid order = %v, want %v", err, payments.ErrNotPayable)
+	}
+}
+
+func TestWebhookRejectsBadSignature(t *testing.T) {
+	f := newFixture(t)
+	p := f.pay(t)
+
+	for _, sig := range []string{"", "00", fakeprovider.New("other").Sign([]byte("x"))} {
+		if err := f.send(t, payments.EventSucceeded, p.ProviderRef, sig); !errors.Is(err, payments.ErrInvalidSignature) {
+			t.Errorf("signature %q: HandleWebhook = %v, want %v", sig, err, payments.ErrInvalidSignature)
+		}
+	}
+	if got := f.payments.byID[p.ID].Status; got != payments.StatusPending {
+		t.Errorf("payment %s after forged webhooks, want pending", got)
+	}
+	if got := f.orders.byID[orderID].PaymentStatus; got != orders.PaymentUnpaid {
+		t.Errorf("order payment %s after forged webhooks, want unpaid", got)
+	}
+}
+
+func TestWebhookTransitions(t *testing.T) {
+	tests := []struct {
+		name         string
+		events       []payments.EventType
+		wantPayment  payments.Status
+		wantOrder    orders.PaymentStatus
+		wantStatus   orders.Status
+		wantCaptured int
+	}{
+		{"succeeded", []payments.EventType{payments.EventSucceeded},
+			payments.StatusPaid, orders.PaymentPaid, orders.StatusPaid, 0},
+		{"authorized is captured", []payments.EventType{payments.EventAuthorized},
+			payments.StatusPaid, orders.PaymentPaid, orders.StatusPaid, 1},
+		{"failed", []payments.EventType{payments.EventFailed},
+			payments.StatusFailed, orders.PaymentFailed, orders.StatusPending, 0},
+		{"retried delivery is ignored", []payments.EventType{payments.EventAuthorized, payments.EventAuthorized},
+			payments.StatusPaid, orders.PaymentPaid, orders.StatusPaid, 1},
+		{"late failure is ignored", []payments.EventType{payments.EventSucceeded, payments.EventFailed},
+			payments.StatusPaid, orders.PaymentPaid, orders.StatusPaid, 0},
+		{"unknown event is ignored", []payments.EventType{"payment.disputed"},
+			payments.StatusPending, orders.PaymentUnpaid, orders.StatusPending, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			p := f.pay(t)
+			for _, ev := range tt.events {
+				if err := f.send(t, ev, p.ProviderRef); err != nil {
+					t.Fatalf("%s: %v", ev, err)
+				}
+			}
+
+			if got := f.payments.byID[p.ID].Status; got != tt.wantPayment {
+				t.Errorf("payment status %s, want %s", got, tt.wantPayment)
+			}
+			o := f.orders.byID[orderID]
+			if o.PaymentStatus != tt.wantOrder || o.Status != tt.wantStatus {
+				t.Errorf("order %s/%s, want %s/%s", o.Status, o.PaymentStatus, tt.wantStatus, tt.wantOrder)
+			}
+			if len(f.provider.captured) != tt.wantCaptured {
+				t.Errorf("captured %d times, want %d", len(f.provider.captured), tt.wantCaptured)
+			}
+		})
+	}
+}
+
+func TestStaleFailureKeepsOrderPaid(t *testing.T) {
+	f := newFixture(t)
+	first := f.pay(t)
+	if err := f.send(t, payments.EventFailed, first.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	second := f.pay(t)
+	if second.ID == first.ID {
+		t.Fatal("Pay resumed a failed intent")
+	}
+	if err := f.send(t, payments.EventSucceeded, second.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+
+	// a third attempt the client abandoned fails after the order is paid
+	stale, err := f.payments.Create(context.Background(), payments.Payment{
+		OrderID: orderID, Provider: "fake", ProviderRef: "fake_pi_stale", Status: payments.StatusPending,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.send(t, payments.EventFailed, stale.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.payments.byID[stale.ID].Status; got != payments.StatusFailed {
+		t.Errorf("stale payment %s, want failed", got)
+	}
+	if got := f.orders.byID[orderID].PaymentStatus; got != orders.PaymentPaid {
+		t.Errorf("order payment %s, want it to stay paid", got)
+	}
+}
+
+func TestPaymentForCancelledOrderIsRefunded(t *testing.T) {
+	f := newFixture(t)
+	p := f.pay(t)
+
+	// the customer cancels while the payment is still in flight
+	o := f.orders.byID[orderID]
+	o.Status = orders.StatusCancelled
+	f.orders.byID[orderID] = o
+
+	if err := f.send(t, payments.EventSucceeded, p.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.provider.refunded) != 1 || f.provider.refunded[0] != p.ProviderRef {
+		t.Errorf("provider refunds = %v, want %s", f.provider.refunded, p.ProviderRef)
+	}
+	if got := f.payments.byID[p.ID].Status; got != payments.StatusRefunded {
+		t.Errorf("payment %s, want refunded", got)
+	}
+	if got := f.orders.byID[orderID].PaymentStatus; got != orders.PaymentRefunded {
+		t.Errorf("order payment %s, want refunded", got)
+	}
+}
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+func TestRefund(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	if _, err := f.svc.Refund(ctx, orderID); !errors.Is(err, payments.ErrNotRefundable) {
+		t.Fatalf("Refund without a payment = %v, want %v", err, payments.ErrNotRefundable)
+	}
+	p := f.pay(t)
+	if _, err := f.svc.Refund(ctx, orderID); !errors.Is(err, payments.ErrNotRefundable) {
+		t.Fatalf("Refund of a pending payment = %v, want %v", err, payments.ErrNotRefundable)
+	}
+	if err := f.send(t, payments.EventSucceeded, p.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+
+	refunded, err := f.svc.Refund(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refunded.Status != payments.StatusRefunded || len(f.provider.refunded) != 1 {
+		t.Errorf("payment %s after %d provider refunds, want refunded once", refunded.Status, len(f.provider.refunded))
+	}
+	if got := f.orders.byID[orderID].PaymentStatus; got != orders.PaymentRefunded {
+		t.Errorf("order payment %s, want refunded", got)
+	}
+	if rs := f.orders.byID[orderID].Refunds; len(rs) != 1 || rs[0].Amount != money.New(4999, money.Default) || rs[0].ReturnID != "" {
+		t.Errorf("order refunds = %+v, want the whole 49.99", rs)
+	}
+	if _, err := f.svc.Refund(ctx, orderID); !errors.Is(err, payments.ErrNotRefundable) {
+		t.Errorf("second Refund = %v, want %v", err, payments.ErrNotRefundable)
+	}
+	if len(f.orders.byID[orderID].Refunds) != 1 {
+		t.Error("refused Refund still recorded a refund")
+	}
+}
+
+// TestRefundRecordsBaseCurrency refunds an order paid in another currency:
+// the provider returns what was charged, the order records its base total.
+func TestRefundRecordsBaseCurrency(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	o := f.orders.byID[orderID]
+	o.Display = &orders.Display{Currency: "USD", Rate: 500, TotalPrice: money.New(1000, "USD")}
+	f.orders.byID[orderID] = o
+
+	p := f.pay(t)
+	if p.Amount != money.New(1000, "USD") {
+		t.Fatalf("charged %v, want 10 USD", p.Amount)
+	}
+	if err := f.send(t, payments.EventSucceeded, p.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.Refund(ctx, orderID); err != nil {
+		t.Fatal(err)
+	}
+	if rs := f.orders.byID[orderID].Refunds; len(rs) != 1 || rs[0].Amount != money.New(4999, money.Default) {
+		t.Errorf("order refunds = %+v, want 49.99 in the base currency", rs)
+	}
+}
+
+// paid takes the fixture's order through a successful payment.
+func (f *fixture) paid(t *testing.T) payments.Payment {
+	t.Helper()
+	p := f.pay(t)
+	if err := f.send(t, payments.EventSucceeded, p.ProviderRef); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRefundReturn(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	if _, err := f.svc.RefundReturn(ctx, orderID, "return-1", kzt(1000)); !errors.Is(err, payments.ErrNotRefundable) {
+		t.Fatalf("RefundReturn before payment = %v, want %v", err, payments.ErrNotRefundable)
+	}
+	p := f.paid(t)
+
+	steps := []struct {
+		returnID string
+		amount   money.Money
+		wantErr  error
+		// what the order's payment looks like afterwards
+		wantStatus payments.Status
+		wantLeft   money.Money
+	}{
+		{"return-1", kzt(2000), nil, payments.StatusPaid, kzt(2999)},
+		{"return-2", kzt(0), payments.ErrNotRefundable, payments.StatusPaid, kzt(2999)},
+		{"return-2", kzt(3000), payments.ErrRefundExceeded, payments.StatusPaid, kzt(2999)},
+		{"return-2", kzt(2999), nil, payments.StatusRefunded, kzt(0)},
+		{"return-3", kzt(1), payments.ErrNotRefundable, payments.StatusRefunded, kzt(0)},
+	}
+	for i, st := range steps {
+		_, err := f.svc.RefundReturn(ctx, orderID, st.returnID, st.amount)
+		if !errors.Is(err, st.wantErr) {
+			t.Fatalf("step %d: RefundReturn %v = %v, want %v", i, st.amount, err, st.wantErr)
+		}
+		o := f.orders.byID[orderID]
+		if got := f.payments.byID[p.ID].Status; got != st.wantStatus {
+			t.Errorf("step %d: payment %s, want %s", i, got, st.wantStatus)
+		}
+		if left := o.TotalPrice.Sub(o.Refunded()); left != st.wantLeft {
+			t.Errorf("step %d: %v left to refund, want %v", i, left, st.wantLeft)
+		}
+	}
+
+	if got := f.provider.refundedAmounts; len(got) != 2 || got[0] != kzt(2000) || got[1] != kzt(2999) {
+		t.Errorf("provider refunded %v, want 20 then 29.99", got)
+	}
+	if rs := f.orders.byID[orderID].Refunds; len(rs) != 2 || rs[0].ReturnID != "return-1" || rs[1].ReturnID != "return-2" {
+		t.Errorf("order refunds = %+v, want one per return", rs)
+	}
+	if got := f.orders.byID[orderID].PaymentStatus; got != orders.PaymentRefunded {
+		t.Errorf("order payment %s, want refunded once nothing is left", got)
+	}
+}
+
+// TestRefundSharesCharge refunds an order charged 10 USD for 49.99 in the
+// base currency in two parts; the provider gets the same shares of the 10
+// USD and the parts add up to it exactly.
+func TestRefundSharesCharge(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	o := f.orders.byID[orderID]
+	o.Display = &orders.Display{Currency: "USD", Rate: 500, TotalPrice: money.New(1000, "USD")}
+	f.orders.byID[orderID] = o
+	f.paid(t)
+
+	if _, err := f.svc.RefundReturn(ctx, orderID, "return-1", kzt(1666)); err != nil {
+		t.Fatal(err)
+	}
+	// the rest, whatever rounding left over
+	if _, err := f.svc.Refund(ctx, orderID); err != nil {
+		t.Fatal(err)
+	}
+
+	got := f.provider.refundedAmounts
+	if len(got) != 2 || got[0] != money.New(333, "USD") || got[1] != money.New(667, "USD") {
+		t.Errorf("provider refunded %v, want 3.33 and 6.67 USD", got)
+	}
+	if rs := f.orders.byID[orderID].Refunds; len(rs) != 2 || rs[1].Amount != kzt(3333) {
+		t.Errorf("order refunds = %+v, want the 33.33 left in the base currency last", rs)
+	}
+}
@@ -4,25 +4,43 @@ import (
 	"context"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
 	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
 )
 
 type Service struct {
-	repo products.Repo
-	now  func() time.Time
+	repo        products.Repo
+	stockRepo   stock.Repo
+	tx          uow.UnitOfWork
+	priceBreaks []money.Money
+	now         func() time.Time
 }
 
-func New(repo products.Repo) *Service {
+// New builds the products service. Stock set by admins is recorded in the
+// stock ledger in the same unit of work as the product change. priceBreaks
+// are the ascending base-currency prices Facets splits price buckets at.
+func New(repo products.Repo, stockRepo stock.Repo, tx uow.UnitOfWork, priceBreaks []money.Money) *Service {
 	return &Service{
-		repo: repo,
-		now:  func() time.Time { return time.Now().UTC() },
+		repo:        repo,
+		stockRepo:   stockRepo,
+		tx:          tx,
+		priceBreaks: priceBreaks,
+		now:         func() time.Time { return time.Now().UTC() },
 	}
 }
 
 var _ products.Service = (*Service)(nil)
 
 func (s *Service) List(ctx context.Context, f products.ListFilter) ([]products.Product, int64, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	items, err := s.repo.List(ctx, f)
 	if err != nil {
 		return nil, 0, err
@@ -36,6 +54,34 @@ func (s *Service) List(ctx context.Context, f products.ListFilter) ([]products.P
 	return items, total, nil
 }
 
+func (s *Service) Facets(ctx context.Context, f products.ListFilter) (products.Facets, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return products.Facets{}, err
+	}
+	return s.repo.Facets(ctx, f, s.priceBreaks)
+}
+
+// normalizeFilter trims the search query and checks f's bounds.
+func normalizeFilter(f products.ListFilter) (products.ListFilter, error) {
+	f.Query = strings.TrimSpace(f.Query)
+	if utf8.RuneCountInString(f.Query) > products.MaxQueryLen {
+		return f, products.ErrInvalidQuery
+	}
+	if f.MinPrice != nil && f.MinPrice.IsNegative() ||
+		f.MaxPrice != nil && f.MaxPrice.IsNegative() ||
+		f.MinPrice != nil && f.MaxPrice != nil && f.MinPrice.Cmp(*f.MaxPrice) > 0 {
+		return f, products.ErrInvalidFilter
+	}
+	if f.MinRating != nil && (*f.MinRating < 0 || *f.MinRating > 5) {
+		return f, products.ErrInvalidFilter
+	}
+	if f.SortBy != "" && !f.SortBy.Valid() {
+		return f, products.ErrInvalidFilter
+	}
+	return f, nil
+}
+
 func (s *Service) Get(ctx context.Context, id string) (products.Product, error) {
 	return s.repo.GetByID(ctx, id)
 }
@@ -48,12 +94,15 @@ func (s *Service) Create(ctx context.Context, in products.CreateInput) (products
 	if strings.TrimSpace(in.CategoryID) == "" {
 		return products.Product{}, products.ErrInvalidCategory
 	}
-	if in.Price <= 0 {
+	if !in.Price.IsPositive() {
 		return products.Product{}, products.ErrInvalidPrice
 	}
 	if in.Stock < 0 {
 		return products.Product{}, products.ErrInvalidStock
 	}
+	if in.ReorderThreshold < 0 {
+		return products.Product{}, products.ErrInvalidThreshold
+	}
 
 	now := s.now()
 	p := products.Product{
@@ -64,8 +113,33 @@ func (s *Service) Create(ctx context.Context, in products.CreateInput) (products
 		Stock:       in.Stock,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		ReorderThreshold: in.ReorderThreshold,
+	}
+
+	var created products.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.repo.Create(ctx, p)
+		if err != nil {
+			return err
+		}
+		if created.Stock == 0 {
+			return nil
+		}
+		return s.stockRepo.Append(ctx, stock.Movement{
+			ProductID: created.ID,
+			Delta:     created.Stock,
+			Reason:    stock.ReasonAdjustment,
+			ActorID:   strings.TrimSpace(in.CreatedBy),
+			Note:      "initial stock",
+			CreatedAt: now,
+		})
+	})
+	if err != nil {
+		return products.Product{}, err
 	}
-	return s.repo.Create(ctx, p)
+	return created, nil
 }
 
 func (s *Service) Update(ctx context.Context, id string, in products.UpdateInput) (products.Product, error) {
@@ -87,14 +161,48 @@ func (s *Service) Update(ctx context.Context, id string, in products.UpdateInput
 		d := strings.TrimSpace(*in.Description)
 		in.Description = &d
 	}
-	if in.Price != nil && *in.Price <= 0 {
+	if in.Price != nil && !in.Price.IsPositive() {
 		return products.Product{}, products.ErrInvalidPrice
 	}
 	if in.Stock != nil && *in.Stock < 0 {
 		return products.Product{}, products.ErrInvalidStock
 	}
+	if in.ReorderThreshold != nil && *in.ReorderThreshold < 0 {
+		return products.Product{}, products.ErrInvalidThreshold
+	}
+	if in.Stock == nil {
+		return s.repo.Update(ctx, id, in)
+	}
 
-	return s.repo.Update(ctx, id, in)
+	// stock is set, not adjusted, so the movement is the difference to what
+	// the product had; the unit of work keeps a concurrent change from
+	// slipping in between the read and the write
+	var updated products.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		updated, err = s.repo.Update(ctx, id, in)
+		if err != nil {
+			return err
+		}
+		if updated.Stock == cur.Stock {
+			return nil
+		}
+		return s.stockRepo.Append(ctx, stock.Movement{
+			ProductID: updated.ID,
+			Delta:     updated.Stock - cur.Stock,
+			Reason:    stock.ReasonAdjustment,
+			ActorID:   strings.TrimSpace(in.UpdatedBy),
+			Note:      strings.TrimSpace(in.StockNote),
+			CreatedAt: s.now(),
+		})
+	})
+	if err != nil {
+		return products.Product{}, err
+	}
+	return updated, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id string) error {
@@ -0,0 +1,220 @@
+package productssvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	productssvc "github.com/bnursik/aitu-ad-final-back/internal/services/products"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The fakes embed their interface so only the methods the service calls
+// need writing; anything else panics on the nil embedded value.
+
+type fakeProducts struct {
+	products.Repo
+	byID map[string]products.Product
+}
+
+func (r *fakeProducts) GetByID(ctx context.Context, id string) (products.Product, error) {
+	p, ok := r.byID[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *fakeProducts) Create(ctx context.Context, p products.Product) (products.Product, error) {
+	p.ID = "product-1"
+	r.byID[p.ID] = p
+	return p, nil
+}
+
+func (r *fakeProducts) Update(ctx context.Context, id string, in products.UpdateInput) (products.Product, error) {
+	p, ok := r.byID[id]
+	if !ok {
+		return products.Product{}, products.ErrNotFound
+	}
+	if in.Name != nil {
+		p.Name = *in.Name
+	}
+	if in.Stock != nil {
+		p.Stock = *in.Stock
+	}
+	r.byID[id] = p
+	return p, nil
+}
+
+func (r *fakeProducts) List(ctx context.Context, f products.ListFilter) ([]products.Product, error) {
+	return nil, nil
+}
+
+func (r *fakeProducts) Count(ctx context.Context, f products.ListFilter) (int64, error) {
+	return 0, nil
+}
+
+type fakeStock struct {
+	stock.Repo
+	moves []stock.Movement
+}
+
+func (r *fakeStock) Append(ctx context.Context, ms ...stock.Movement) error {
+	r.moves = append(r.moves, ms...)
+	return nil
+}
+
+func newService() (*productssvc.Service, *fakeProducts, *fakeStock) {
+	prods := &fakeProducts{byID: map[string]products.Product{}}
+	ledger := &fakeStock{}
+	return productssvc.New(prods, ledger, memrepo.NewUnitOfWork(), nil), prods, ledger
+}
+
+func TestCreateRecordsInitialStock(t *testing.T) {
+	ctx := context.Background()
+	svc, _, ledger := newService()
+	in := products.CreateInput{CategoryID: "mice", Name: "Mouse", Price: money.New(2500, money.Default), Stock: 12, CreatedBy: "admin-1"}
+
+	p, err := svc.Create(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := ledger.moves; len(m) != 1 || m[0].ProductID != p.ID || m[0].Delta != 12 || m[0].Reason != stock.ReasonAdjustment || m[0].ActorID != "admin-1" {
+		t.Errorf("movements = %+v, want +12 by admin-1", m)
+	}
+
+	// nothing on hand, nothing to record
+	in.Stock = 0
+	if _, err := svc.Create(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger.moves) != 1 {
+		t.Errorf("movements = %+v, want none for zero stock", ledger.moves)
+	}
+
+	in.Stock = -1
+	if _, err := svc.Create(ctx, in); !errors.Is(err, products.ErrInvalidStock) {
+		t.Errorf("Create with negative stock = %v, want %v", err, products.ErrInvalidStock)
+	}
+}
+
+func TestUpdateRecordsStockDifference(t *testing.T) {
+	ctx := context.Background()
+	svc, prods, ledger := newService()
+	prods.byID["mouse"] = products.Product{ID: "mouse", Name: "Mouse", Stock: 10}
+
+	name := "Wireless mouse"
+	if _, err := svc.Update(ctx, "mouse", products.UpdateInput{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	same := int64(10)
+	if _, err := svc.Update(ctx, "mouse", products.UpdateInput{Stock: &same}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger.moves) != 0 {
+		t.Fatalf("movements = %+v, want none while stock is unchanged", ledger.moves)
+	}
+
+	// stock is set, so the movement is the difference
+	lower := int64(7)
+	if _, err := svc.Update(ctx, "mouse", products.UpdateInput{Stock: &lower, UpdatedBy: "admin-1", StockNote: " stocktake "}); err != nil {
+		t.Fatal(err)
+	}
+	if m := ledger.moves; len(m) != 1 || m[0].Delta != -3 || m[0].Note != "stocktake" || m[0].ActorID != "admin-1" {
+		t.Errorf("movements = %+v, want -3 noted as the stocktake", m)
+	}
+
+	if _, err := svc.Update(ctx, "monitor", products.UpdateInput{Stock: &lower}); !errors.Is(err, products.ErrNotFound) {
+		t.Errorf("Update of an unknown product = %v, want %v", err, products.ErrNotFound)
+	}
+}
+
+func TestRejectsNegativeThreshold(t *testing.T) {
+	ctx := context.Background()
+	svc, prods, _ := newService()
+	prods.byID["mouse"] = products.Product{ID: "mouse", Name: "Mouse", Stock: 10}
+
+	in := products.CreateInput{CategoryID: "mice", Name: "Mouse", Price: money.New(2500, money.Default), ReorderThreshold: -1}
+	if _, err := svc.Create(ctx, in); !errors.Is(err, products.ErrInvalidThreshold) {
+		t.Errorf("Create = %v, want %v", err, products.ErrInvalidThreshold)
+	}
+	neg := int64(-1)
+	if _, err := svc.Update(ctx, "mouse", products.UpdateInput{ReorderThreshold: &neg}); !errors.Is(err, products.ErrInvalidThreshold) {
+		t.Errorf("Update = %v, want %v", err, products.ErrInvalidThreshold)
+	}
+}
+
+func TestListValidatesFilter(t *testing.T) {
+	kzt := func(minor int64) *money.Money {
+		m := money.New(minor, money.Default)
+		return &m
+	}
+	rating := func(r float64) *float64 { return &r }
+
+	tests := []struct {
+		name string
+		f    products.ListFilter
+		want error
+	}{
+		{"no filter", products.ListFilter{}, nil},
+		{"price range", products.ListFilter{MinPrice: kzt(1000), MaxPrice: kzt(1000)}, nil},
+		{"negative min price", products.ListFilter{MinPrice: kzt(-1)}, products.ErrInvalidFilter},
+		{"negative max price", products.ListFilter{MaxPrice: kzt(-1)}, products.ErrInvalidFilter},
+		{"min over max", products.ListFilter{MinPrice: kzt(2000), MaxPrice: kzt(1000)}, products.ErrInvalidFilter},
+		{"rating over 5", products.ListFilter{MinRating: rating(5.5)}, products.ErrInvalidFilter},
+		{"negative rating", products.ListFilter{MinRating: rating(-1)}, products.ErrInvalidFilter},
+		{"unknown sort", products.ListFilter{SortBy: "name"}, products.ErrInvalidFilter},
+		{"best selling", products.ListFilter{SortBy: products.SortBySold}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newService()
+			if _, _, err := svc.List(context.Background(), tt.f); !errors.Is(err, tt.want) {
+				t.Errorf("List = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateRefusesStockBelowReserved(t *testing.T) {
+	ctx := context.Background()
+	prods := memrepo.NewProductsRepo()
+	ledger := &fakeStock{}
+	svc := productssvc.New(prods, ledger, memrepo.NewUnitOfWork(prods), nil)
+	p, err := prods.Create(ctx, products.Product{CategoryID: primitive.NewObjectID().Hex(), Name: "Mouse", Price: money.New(2500, money.Default), Stock: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prods.Reserve(ctx, p.ID, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stock     int64
+		wantErr   error
+		wantStock int64
+		wantMoves int
+	}{
+		{3, products.ErrStockBelowReserved, 10, 0},
+		// carts keep exactly what they hold, nothing more is available
+		{4, nil, 4, 1},
+		{0, products.ErrStockBelowReserved, 4, 1},
+	}
+	for _, tt := range tests {
+		_, err := svc.Update(ctx, p.ID, products.UpdateInput{Stock: &tt.stock})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("stock %d: Update = %v, want %v", tt.stock, err, tt.wantErr)
+		}
+		got, err := prods.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Stock != tt.wantStock || got.Reserved != 4 || len(ledger.moves) != tt.wantMoves {
+			t.Errorf("stock %d: product has %d on hand, %d reserved after %d movements; want %d, 4, %d", tt.stock, got.Stock, got.Reserved, len(ledger.moves), tt.wantStock, tt.wantMoves)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package promotionssvc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+)
+
+type Service struct {
+	repo promotions.Repo
+	now  func() time.Time
+}
+
+func New(repo promotions.Repo) *Service {
+	return &Service{
+		repo: repo,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ promotions.Service = (*Service)(nil)
+
+func (s *Service) List(ctx context.Context, f promotions.ListFilter) ([]promotions.Promotion, int64, error) {
+	items, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (promotions.Promotion, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) Create(ctx context.Context, in promotions.CreateInput) (promotions.Promotion, error) {
+	code := promotions.NormalizeCode(in.Code)
+	if code == "" || strings.ContainsAny(code, " \t\n") {
+		return promotions.Promotion{}, promotions.ErrInvalidCode
+	}
+
+	now := s.now()
+	p := promotions.Promotion{
+		Code:           code,
+		Description:    strings.TrimSpace(in.Description),
+		Type:           in.Type,
+		Value:          in.Value,
+		MinOrderTotal:  in.MinOrderTotal,
+		CategoryIDs:    trimIDs(in.CategoryIDs),
+		ProductIDs:     trimIDs(in.ProductIDs),
+		MaxUses:        in.MaxUses,
+		MaxUsesPerUser: in.MaxUsesPerUser,
+		StartsAt:       in.StartsAt,
+		EndsAt:         in.EndsAt,
+		Active:         in.Active,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := validate(p); err != nil {
+		return promotions.Promotion{}, err
+	}
+
+	return s.repo.Create(ctx, p)
+}
+
+func (s *Service) Update(ctx context.Context, id string, in promotions.UpdateInput) (promotions.Promotion, error) {
+	// validate the merged result so e.g. a new type is checked against the old value
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return promotions.Promotion{}, err
+	}
+
+	if in.Description != nil {
+		d := strings.TrimSpace(*in.Description)
+		in.Description = &d
+	}
+	if in.Type != nil {
+		p.Type = *in.Type
+	}
+	if in.Value != nil {
+		p.Value = *in.Value
+	}
+	if in.MinOrderTotal != nil {
+		p.MinOrderTotal = *in.MinOrderTotal
+	}
+	if in.CategoryIDs != nil {
+		ids := trimIDs(*in.CategoryIDs)
+		in.CategoryIDs = &ids
+	}
+	if in.ProductIDs != nil {
+		ids := trimIDs(*in.ProductIDs)
+		in.ProductIDs = &ids
+	}
+	if in.MaxUses != nil {
+		p.MaxUses = *in.MaxUses
+	}
+	if in.MaxUsesPerUser != nil {
+		p.MaxUsesPerUser = *in.MaxUsesPerUser
+	}
+	if in.StartsAt != nil {
+		p.StartsAt = in.StartsAt
+	}
+	if in.EndsAt != nil {
+		p.EndsAt = in.EndsAt
+	}
+	if err := validate(p); err != nil {
+		return promotions.Promotion{}, err
+	}
+
+	return s.repo.Update(ctx, id, in)
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// ---- helpers ----
+
+func validate(p promotions.Promotion) error {
+	if !p.Type.Valid() {
+		return promotions.ErrInvalidType
+	}
+	if p.Value <= 0 || (p.Type == promotions.DiscountPercentage && p.Value > 100) {
+		return promotions.ErrInvalidValue
+	}
+	if p.MinOrderTotal.IsNegative() {
+		return promotions.ErrInvalidValue
+	}
+	if p.MaxUses < 0 || p.MaxUsesPerUser < 0 {
+		return promotions.ErrInvalidLimit
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return promotions.ErrInvalidWindow
+	}
+	return nil
+}
+
+func trimIDs(ids []string) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
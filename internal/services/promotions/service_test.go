@@ -0,0 +1,66 @@
+package promotionssvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/promotions"
+	promotionssvc "github.com/bnursik/aitu-ad-final-back/internal/services/promotions"
+)
+
+// fakeRepo embeds the interface so only Create needs writing.
+type fakeRepo struct {
+	promotions.Repo
+	created []promotions.Promotion
+}
+
+func (r *fakeRepo) Create(ctx context.Context, p promotions.Promotion) (promotions.Promotion, error) {
+	p.ID = "promo-1"
+	r.created = append(r.created, p)
+	return p, nil
+}
+
+func TestCreateValidates(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := promotions.CreateInput{Code: "summer10", Type: promotions.DiscountPercentage, Value: 10, Active: true}
+
+	tests := []struct {
+		name string
+		edit func(in *promotions.CreateInput)
+		want error
+	}{
+		{"valid", func(in *promotions.CreateInput) {}, nil},
+		{"blank code", func(in *promotions.CreateInput) { in.Code = "  " }, promotions.ErrInvalidCode},
+		{"code with spaces", func(in *promotions.CreateInput) { in.Code = "summer 10" }, promotions.ErrInvalidCode},
+		{"unknown type", func(in *promotions.CreateInput) { in.Type = "bogo" }, promotions.ErrInvalidType},
+		{"zero value", func(in *promotions.CreateInput) { in.Value = 0 }, promotions.ErrInvalidValue},
+		{"over 100 percent", func(in *promotions.CreateInput) { in.Value = 101 }, promotions.ErrInvalidValue},
+		{"fixed over 100", func(in *promotions.CreateInput) {
+			in.Type, in.Value = promotions.DiscountFixed, 150
+		}, nil},
+		{"negative minimum", func(in *promotions.CreateInput) { in.MinOrderTotal = money.New(-1, money.Default) }, promotions.ErrInvalidValue},
+		{"negative limit", func(in *promotions.CreateInput) { in.MaxUsesPerUser = -1 }, promotions.ErrInvalidLimit},
+		{"window ends before it starts", func(in *promotions.CreateInput) {
+			end := start.Add(-time.Hour)
+			in.StartsAt, in.EndsAt = &start, &end
+		}, promotions.ErrInvalidWindow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			in := valid
+			tt.edit(&in)
+
+			_, err := promotionssvc.New(repo).Create(context.Background(), in)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Create = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && repo.created[0].Code != "SUMMER10" {
+				t.Errorf("stored code %q, want it normalized", repo.created[0].Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package reservationssvc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+// sweepBatch is how many expired holds SweepExpired loads at a time.
+const sweepBatch = 100
+
+type Service struct {
+	repo         reservations.Repo
+	productsRepo products.Repo
+	tx           uow.UnitOfWork
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+// New builds the reservations service. Holds last ttl from their last change.
+func New(repo reservations.Repo, productsRepo products.Repo, tx uow.UnitOfWork, ttl time.Duration) *Service {
+	return &Service{
+		repo:         repo,
+		productsRepo: productsRepo,
+		tx:           tx,
+		ttl:          ttl,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ reservations.Service = (*Service)(nil)
+
+func (s *Service) Hold(ctx context.Context, userID, productID string, qty int64) (reservations.Reservation, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return reservations.Reservation{}, reservations.ErrInvalidID
+	}
+	pid := strings.TrimSpace(productID)
+	if pid == "" {
+		return reservations.Reservation{}, reservations.ErrInvalidProduct
+	}
+	if qty <= 0 {
+		return reservations.Reservation{}, reservations.ErrInvalidQty
+	}
+
+	now := s.now()
+	res := reservations.Reservation{
+		UserID:    uid,
+		ProductID: pid,
+		Quantity:  qty,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		held := int64(0)
+		cur, err := s.repo.Get(ctx, uid, pid)
+		switch {
+		case err == nil:
+			held = cur.Quantity
+			res.CreatedAt = cur.CreatedAt
+		case !errors.Is(err, reservations.ErrNotFound):
+			return err
+		}
+
+		if qty > held {
+			err = s.productsRepo.Reserve(ctx, pid, qty-held)
+		} else {
+			err = s.productsRepo.Unreserve(ctx, pid, held-qty)
+		}
+		if err != nil {
+			return mapProductErr(err)
+		}
+		return s.repo.Upsert(ctx, res)
+	})
+	if err != nil {
+		return reservations.Reservation{}, err
+	}
+	return res, nil
+}
+
+func (s *Service) Release(ctx context.Context, userID, productID string) error {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return reservations.ErrInvalidID
+	}
+	pid := strings.TrimSpace(productID)
+	if pid == "" {
+		return reservations.ErrInvalidProduct
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		res, err := s.repo.Delete(ctx, uid, pid)
+		if err != nil {
+			if errors.Is(err, reservations.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		return s.unreserve(ctx, res)
+	})
+}
+
+func (s *Service) ReleaseAll(ctx context.Context, userID string) error {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return reservations.ErrInvalidID
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		held, err := s.repo.ListByUser(ctx, uid)
+		if err != nil {
+			return err
+		}
+		for _, r := range held {
+			if err := s.Release(ctx, uid, r.ProductID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) ListByUser(ctx context.Context, userID string) ([]reservations.Reservation, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return nil, reservations.ErrInvalidID
+	}
+	return s.repo.ListByUser(ctx, uid)
+}
+
+// SweepExpired releases expired holds one at a time, each in its own unit of
+// work, so a hold renewed while the sweep runs is skipped rather than lost.
+func (s *Service) SweepExpired(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		now := s.now()
+		expired, err := s.repo.ListExpired(ctx, now, sweepBatch)
+		if err != nil {
+			return released, err
+		}
+
+		for _, r := range expired {
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				res, err := s.repo.DeleteExpired(ctx, r.UserID, r.ProductID, now)
+				if err != nil {
+					return err
+				}
+				return s.unreserve(ctx, res)
+			})
+			switch {
+			case err == nil:
+				released++
+			case !errors.Is(err, reservations.ErrNotFound):
+				return released, err
+			}
+		}
+
+		if len(expired) < sweepBatch {
+			return released, nil
+		}
+	}
+}
+
+// Run sweeps expired holds every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			n, err := s.SweepExpired(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("reservations sweep: %v", err)
+			}
+			if n > 0 {
+				log.Printf("reservations sweep: released %d expired holds", n)
+			}
+		}
+	}
+}
+
+// ---- helpers ----
+
+// unreserve gives a deleted hold back to its product. A product deleted
+// since has nothing to give back to.
+func (s *Service) unreserve(ctx context.Context, r reservations.Reservation) error {
+	err := s.productsRepo.Unreserve(ctx, r.ProductID, r.Quantity)
+	if err != nil && !errors.Is(err, products.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func mapProductErr(err error) error {
+	switch {
+	case errors.Is(err, products.ErrInsufficientStock):
+		return reservations.ErrInsufficientStock
+	case errors.Is(err, products.ErrNotFound), errors.Is(err, products.ErrInvalidID):
+		return reservations.ErrInvalidProduct
+	}
+	return err
+}
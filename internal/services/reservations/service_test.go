@@ -0,0 +1,256 @@
+package reservationssvc_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/reservations"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	reservationssvc "github.com/bnursik/aitu-ad-final-back/internal/services/reservations"
+)
+
+// The fakes embed their interface so only the methods the service calls
+// need writing; anything else panics on the nil embedded value.
+
+type fakeRepo struct {
+	reservations.Repo
+	held map[[2]string]reservations.Reservation
+	// beforeDeleteExpired runs just before DeleteExpired checks the hold,
+	// standing in for a request that renews it mid-sweep.
+	beforeDeleteExpired func()
+}
+
+func (r *fakeRepo) Get(ctx context.Context, userID, productID string) (reservations.Reservation, error) {
+	res, ok := r.held[[2]string{userID, productID}]
+	if !ok {
+		return reservations.Reservation{}, reservations.ErrNotFound
+	}
+	return res, nil
+}
+
+func (r *fakeRepo) ListByUser(ctx context.Context, userID string) ([]reservations.Reservation, error) {
+	var out []reservations.Reservation
+	for _, res := range r.held {
+		if res.UserID == userID {
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) ListExpired(ctx context.Context, now time.Time, limit int64) ([]reservations.Reservation, error) {
+	var out []reservations.Reservation
+	for _, res := range r.held {
+		if res.Expired(now) {
+			out = append(out, res)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExpiresAt.Before(out[j].ExpiresAt) })
+	if int64(len(out)) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) Upsert(ctx context.Context, res reservations.Reservation) error {
+	r.held[[2]string{res.UserID, res.ProductID}] = res
+	return nil
+}
+
+func (r *fakeRepo) Delete(ctx context.Context, userID, productID string) (reservations.Reservation, error) {
+	res, err := r.Get(ctx, userID, productID)
+	if err != nil {
+		return reservations.Reservation{}, err
+	}
+	delete(r.held, [2]string{userID, productID})
+	return res, nil
+}
+
+func (r *fakeRepo) DeleteExpired(ctx context.Context, userID, productID string, now time.Time) (reservations.Reservation, error) {
+	if r.beforeDeleteExpired != nil {
+		r.beforeDeleteExpired()
+	}
+	res, err := r.Get(ctx, userID, productID)
+	if err != nil || !res.Expired(now) {
+		return reservations.Reservation{}, reservations.ErrNotFound
+	}
+	delete(r.held, [2]string{userID, productID})
+	return res, nil
+}
+
+type fakeProducts struct {
+	products.Repo
+	byID map[string]products.Product
+}
+
+func (r *fakeProducts) Reserve(ctx context.Context, productID string, qty int64) error {
+	p, ok := r.byID[productID]
+	if !ok {
+		return products.ErrNotFound
+	}
+	if p.Available() < qty {
+		return products.ErrInsufficientStock
+	}
+	p.Reserved += qty
+	r.byID[productID] = p
+	return nil
+}
+
+func (r *fakeProducts) Unreserve(ctx context.Context, productID string, qty int64) error {
+	p, ok := r.byID[productID]
+	if !ok {
+		return products.ErrNotFound
+	}
+	p.Reserved -= qty
+	r.byID[productID] = p
+	return nil
+}
+
+func newService(t *testing.T, ttl time.Duration) (*reservationssvc.Service, *fakeRepo, *fakeProducts) {
+	t.Helper()
+	repo := &fakeRepo{held: map[[2]string]reservations.Reservation{}}
+	prods := &fakeProducts{byID: map[string]products.Product{
+		"mouse":    {ID: "mouse", Stock: 5},
+		"keyboard": {ID: "keyboard", Stock: 2},
+	}}
+	return reservationssvc.New(repo, prods, memrepo.NewUnitOfWork(), ttl), repo, prods
+}
+
+func TestHold(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, prods := newService(t, 15*time.Minute)
+
+	before := time.Now()
+	r, err := svc.Hold(ctx, "user-1", "mouse", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Quantity != 3 || r.ExpiresAt.Before(before.Add(15*time.Minute)) {
+		t.Errorf("hold = %+v, want 3 until 15 minutes from now", r)
+	}
+	if got := prods.byID["mouse"].Reserved; got != 3 {
+		t.Fatalf("reserved = %d, want 3", got)
+	}
+
+	// changing a hold only moves the difference
+	if _, err := svc.Hold(ctx, "user-1", "mouse", 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Hold(ctx, "user-2", "mouse", 1); !errors.Is(err, reservations.ErrInsufficientStock) {
+		t.Fatalf("Hold past the stock = %v, want %v", err, reservations.ErrInsufficientStock)
+	}
+	if _, err := svc.Hold(ctx, "user-1", "mouse", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := prods.byID["mouse"].Reserved; got != 2 {
+		t.Errorf("reserved = %d after lowering the hold, want 2", got)
+	}
+	if got := repo.held[[2]string{"user-1", "mouse"}]; got.Quantity != 2 || !got.CreatedAt.Equal(r.CreatedAt) {
+		t.Errorf("stored hold = %+v, want 2 keeping its creation time", got)
+	}
+}
+
+func TestHoldRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		uid, pid string
+		qty      int64
+		want     error
+	}{
+		{"no user", " ", "mouse", 1, reservations.ErrInvalidID},
+		{"no product", "user-1", "", 1, reservations.ErrInvalidProduct},
+		{"zero quantity", "user-1", "mouse", 0, reservations.ErrInvalidQty},
+		{"unknown product", "user-1", "monitor", 1, reservations.ErrInvalidProduct},
+		{"more than available", "user-1", "keyboard", 3, reservations.ErrInsufficientStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := newService(t, time.Minute)
+			if _, err := svc.Hold(context.Background(), tt.uid, tt.pid, tt.qty); !errors.Is(err, tt.want) {
+				t.Errorf("Hold = %v, want %v", err, tt.want)
+			}
+			if len(repo.held) != 0 {
+				t.Errorf("holds = %v after a rejected Hold", repo.held)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, prods := newService(t, time.Minute)
+
+	for _, pid := range []string{"mouse", "keyboard"} {
+		if _, err := svc.Hold(ctx, "user-1", pid, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.Release(ctx, "user-1", "mouse"); err != nil {
+		t.Fatal(err)
+	}
+	// releasing again, or something never held, is a no-op
+	if err := svc.Release(ctx, "user-1", "mouse"); err != nil {
+		t.Fatal(err)
+	}
+	if got := prods.byID["mouse"].Reserved; got != 0 {
+		t.Errorf("mouse reserved = %d, want 0", got)
+	}
+
+	// a deleted product has nothing to give back to
+	delete(prods.byID, "keyboard")
+	if err := svc.ReleaseAll(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.held) != 0 {
+		t.Errorf("holds = %v after ReleaseAll", repo.held)
+	}
+}
+
+func TestSweepExpired(t *testing.T) {
+	ctx := context.Background()
+	// holds that lapse as soon as they are made
+	svc, repo, prods := newService(t, -time.Second)
+	live := reservationssvc.New(repo, prods, memrepo.NewUnitOfWork(), time.Hour)
+
+	if _, err := svc.Hold(ctx, "user-1", "mouse", 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Hold(ctx, "user-2", "mouse", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := live.Hold(ctx, "user-3", "keyboard", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// user-2 comes back to their cart while the sweep is running
+	repo.beforeDeleteExpired = func() {
+		if res, ok := repo.held[[2]string{"user-2", "mouse"}]; ok && res.Expired(time.Now()) {
+			if _, err := live.Hold(ctx, "user-2", "mouse", 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	n, err := svc.SweepExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("released %d holds, want only user-1's", n)
+	}
+	if _, ok := repo.held[[2]string{"user-1", "mouse"}]; ok {
+		t.Error("expired hold kept")
+	}
+	for _, key := range [][2]string{{"user-2", "mouse"}, {"user-3", "keyboard"}} {
+		if _, ok := repo.held[key]; !ok {
+			t.Errorf("live hold %v released", key)
+		}
+	}
+	if got := prods.byID["mouse"].Reserved; got != 1 {
+		t.Errorf("mouse reserved = %d, want user-2's 1", got)
+	}
+}
@@ -0,0 +1,271 @@
+package returnssvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/returns"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/uow"
+)
+
+type Service struct {
+	repo         returns.Repo
+	ordersSvc    orders.Service
+	paymentsSvc  payments.Service
+	productsRepo products.Repo
+	stockRepo    stock.Repo
+	tx           uow.UnitOfWork
+	now          func() time.Time
+}
+
+func New(repo returns.Repo, ordersSvc orders.Service, paymentsSvc payments.Service, productsRepo products.Repo, stockRepo stock.Repo, tx uow.UnitOfWork) *Service {
+	return &Service{
+		repo:         repo,
+		ordersSvc:    ordersSvc,
+		paymentsSvc:  paymentsSvc,
+		productsRepo: productsRepo,
+		stockRepo:    stockRepo,
+		tx:           tx,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ returns.Service = (*Service)(nil)
+
+// Create opens a return on lines of the user's own delivered order. Each
+// product can be returned up to the quantity bought, across all returns that
+// weren't rejected.
+func (s *Service) Create(ctx context.Context, orderID string, userID string, in returns.CreateInput) (returns.Return, error) {
+	uid := strings.TrimSpace(userID)
+	if uid == "" {
+		return returns.Return{}, returns.ErrForbidden
+	}
+	reason := strings.TrimSpace(in.Reason)
+	if reason == "" {
+		return returns.Return{}, returns.ErrInvalidReason
+	}
+	if len(in.Items) == 0 {
+		return returns.Return{}, returns.ErrInvalidItems
+	}
+
+	requested := make(map[string]int64, len(in.Items))
+	order := make([]string, 0, len(in.Items))
+	for _, it := range in.Items {
+		pid := strings.TrimSpace(it.ProductID)
+		if pid == "" {
+			return returns.Return{}, returns.ErrInvalidProduct
+		}
+		if it.Quantity <= 0 {
+			return returns.Return{}, returns.ErrInvalidQty
+		}
+		if _, seen := requested[pid]; !seen {
+			order = append(order, pid)
+		}
+		requested[pid] += it.Quantity
+	}
+
+	o, err := s.ordersSvc.Get(ctx, orderID, uid, false)
+	if err != nil {
+		return returns.Return{}, err
+	}
+	if o.Status != orders.StatusDelivered {
+		return returns.Return{}, returns.ErrNotReturnable
+	}
+
+	bought := make(map[string]orders.Item, len(o.Items))
+	for _, it := range o.Items {
+		if b, ok := bought[it.ProductID]; ok {
+			b.Quantity += it.Quantity
+			bought[it.ProductID] = b
+			continue
+		}
+		bought[it.ProductID] = it
+	}
+
+	// what the customer actually paid for the goods, after discounts and
+	// with tax added on top of prices; each line is refunded in proportion
+	// paid/subtotal
+	paid := o.Subtotal.Add(o.AddedTax())
+	for _, d := range o.Discounts {
+		paid = paid.Sub(d.Amount)
+	}
+
+	now := s.now()
+	r := returns.Return{
+		OrderID: o.ID,
+		UserID:  uid,
+		Reason:  reason,
+		Status:  returns.StatusRequested,
+		StatusHistory: []returns.StatusChange{
+			{To: returns.StatusRequested, ChangedBy: uid, ChangedAt: now},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	var created returns.Return
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		already, err := s.repo.ReturnedQuantities(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, pid := range order {
+			line, ok := bought[pid]
+			if !ok {
+				return returns.ErrInvalidProduct
+			}
+			qty := requested[pid]
+			if already[pid]+qty > line.Quantity {
+				return returns.ErrQtyExceeded
+			}
+
+			refund := line.UnitPrice.Mul(qty)
+			if o.Subtotal.IsPositive() {
+				refund = refund.MulRatio(paid.Amount, o.Subtotal.Amount)
+			}
+			r.Items = append(r.Items, returns.Item{
+				ProductID:    pid,
+				ProductName:  line.ProductName,
+				Quantity:     qty,
+				UnitPrice:    line.UnitPrice,
+				RefundAmount: refund,
+			})
+			r.RefundAmount = r.RefundAmount.Add(refund)
+		}
+
+		created, err = s.repo.Create(ctx, r)
+		return err
+	})
+	if err != nil {
+		return returns.Return{}, err
+	}
+
+	return created, nil
+}
+
+func (s *Service) List(ctx context.Context, userID string, isAdmin bool, f returns.ListFilter) ([]returns.Return, int64, error) {
+	var scope *string
+	if !isAdmin {
+		uid := strings.TrimSpace(userID)
+		scope = &uid
+	}
+
+	list, err := s.repo.List(ctx, scope, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(ctx, scope, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string, userID string, isAdmin bool) (returns.Return, error) {
+	if isAdmin {
+		return s.repo.GetByID(ctx, id, nil)
+	}
+	uid := strings.TrimSpace(userID)
+	return s.repo.GetByID(ctx, id, &uid)
+}
+
+func (s *Service) Approve(ctx context.Context, id string, actorID string, in returns.ReviewInput) (returns.Return, error) {
+	return s.transition(ctx, id, actorID, returns.StatusApproved, in.Note, nil)
+}
+
+func (s *Service) Reject(ctx context.Context, id string, actorID string, in returns.ReviewInput) (returns.Return, error) {
+	return s.transition(ctx, id, actorID, returns.StatusRejected, in.Note, nil)
+}
+
+func (s *Service) Receive(ctx context.Context, id string, actorID string, in returns.ReceiveInput) (returns.Return, error) {
+	return s.transition(ctx, id, actorID, returns.StatusReceived, in.Note, func(ctx context.Context, r returns.Return) (bool, error) {
+		if in.Restock {
+			moves := make([]stock.Movement, 0, len(r.Items))
+			for _, it := range r.Items {
+				if err := s.productsRepo.IncrementStock(ctx, it.ProductID, it.Quantity); err != nil {
+					// a deleted product has nowhere to go back to
+					if errors.Is(err, products.ErrNotFound) {
+						continue
+					}
+					return false, err
+				}
+				// a unit back on the shelf is no longer sold
+				if err := s.productsRepo.AddSold(ctx, it.ProductID, -it.Quantity); err != nil {
+					return false, err
+				}
+				moves = append(moves, stock.Movement{
+					ProductID: it.ProductID,
+					Delta:     it.Quantity,
+					Reason:    stock.ReasonReturn,
+					ActorID:   strings.TrimSpace(actorID),
+					Reference: r.ID,
+					CreatedAt: s.now(),
+				})
+			}
+			if err := s.stockRepo.Append(ctx, moves...); err != nil {
+				return false, err
+			}
+		}
+
+		// refunds stop at what was paid: an order refunded in full already,
+		// or never paid through the provider, gets nothing more back
+		o, err := s.ordersSvc.Get(ctx, r.OrderID, "", true)
+		if err != nil {
+			return false, err
+		}
+		if o.PaymentStatus != orders.PaymentPaid {
+			return in.Restock, nil
+		}
+		amount := money.Min(r.RefundAmount, o.TotalPrice.Sub(o.Refunded()))
+		if amount.IsPositive() {
+			if _, err := s.paymentsSvc.RefundReturn(ctx, o.ID, r.ID, amount); err != nil {
+				return false, err
+			}
+		}
+		return in.Restock, nil
+	})
+}
+
+// transition moves a return to `to` inside a unit of work. effect, if set,
+// runs in the same unit of work and reports whether items were restocked.
+func (s *Service) transition(ctx context.Context, id string, actorID string, to returns.Status, note string, effect func(ctx context.Context, r returns.Return) (bool, error)) (returns.Return, error) {
+	var updated returns.Return
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := s.repo.GetByID(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+		if !cur.Status.CanTransitionTo(to) {
+			return returns.ErrInvalidTransition
+		}
+
+		restocked := false
+		if effect != nil {
+			if restocked, err = effect(ctx, cur); err != nil {
+				return err
+			}
+		}
+
+		updated, err = s.repo.UpdateStatus(ctx, id, returns.StatusChange{
+			From:      cur.Status,
+			To:        to,
+			ChangedBy: strings.TrimSpace(actorID),
+			Note:      strings.TrimSpace(note),
+			ChangedAt: s.now(),
+		}, restocked)
+		return err
+	})
+	if err != nil {
+		return returns.Return{}, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,380 @@
+package returnssvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/money"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/orders"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/payments"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/products"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/returns"
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	memrepo "github.com/bnursik/aitu-ad-final-back/internal/repository/memory"
+	returnssvc "github.com/bnursik/aitu-ad-final-back/internal/services/returns"
+)
+
+// The fakes embed their interface so only the methods returns call need
+// writing; anything else panics on the nil embedded value.
+
+type fakeReturns struct {
+	returns.Repo
+	byID map[string]returns.Return
+}
+
+func (r *fakeReturns) Create(ctx context.Context, rt returns.Return) (returns.Return, error) {
+	rt.ID = fmt.Sprintf("return-%d", len(r.byID)+1)
+	r.byID[rt.ID] = rt
+	return rt, nil
+}
+
+func (r *fakeReturns) GetByID(ctx context.Context, id string, userID *string) (returns.Return, error) {
+	rt, ok := r.byID[id]
+	if !ok || (userID != nil && rt.UserID != *userID) {
+		return returns.Return{}, returns.ErrNotFound
+	}
+	return rt, nil
+}
+
+func (r *fakeReturns) UpdateStatus(ctx context.Context, id string, ch returns.StatusChange, restocked bool) (returns.Return, error) {
+	rt := r.byID[id]
+	if rt.Status != ch.From {
+		return returns.Return{}, returns.ErrInvalidTransition
+	}
+	rt.Status = ch.To
+	rt.Restocked = rt.Restocked || restocked
+	rt.StatusHistory = append(rt.StatusHistory, ch)
+	r.byID[id] = rt
+	return rt, nil
+}
+
+func (r *fakeReturns) ReturnedQuantities(ctx context.Context, orderID string) (map[string]int64, error) {
+	out := map[string]int64{}
+	for _, rt := range r.byID {
+		if rt.OrderID != orderID || rt.Status == returns.StatusRejected {
+			continue
+		}
+		for _, it := range rt.Items {
+			out[it.ProductID] += it.Quantity
+		}
+	}
+	return out, nil
+}
+
+type fakeOrders struct {
+	orders.Service
+	byID map[string]orders.Order
+}
+
+func (s *fakeOrders) Get(ctx context.Context, id string, userID string, isAdmin bool) (orders.Order, error) {
+	o, ok := s.byID[id]
+	if !ok || (!isAdmin && o.UserID != userID) {
+		return orders.Order{}, orders.ErrNotFound
+	}
+	return o, nil
+}
+
+// fakePayments refunds by adding to the order's refunds, as the real
+// service does once the provider has returned the money.
+type fakePayments struct {
+	payments.Service
+	orders  *fakeOrders
+	refunds []orders.Refund
+}
+
+func (s *fakePayments) RefundReturn(ctx context.Context, orderID string, returnID string, amount money.Money) (payments.Payment, error) {
+	rf := orders.Refund{ReturnID: returnID, Amount: amount}
+	o := s.orders.byID[orderID]
+	o.Refunds = append(o.Refunds, rf)
+	s.orders.byID[orderID] = o
+	s.refunds = append(s.refunds, rf)
+	return payments.Payment{OrderID: orderID, Status: payments.StatusPaid}, nil
+}
+
+type fakeProducts struct {
+	products.Repo
+	restocked map[string]int64
+	sold      map[string]int64
+}
+
+func (r *fakeProducts) IncrementStock(ctx context.Context, productID string, qty int64) error {
+	if productID == "deleted" {
+		return products.ErrNotFound
+	}
+	r.restocked[productID] += qty
+	return nil
+}
+
+func (r *fakeProducts) AddSold(ctx context.Context, productID string, qty int64) error {
+	r.sold[productID] += qty
+	return nil
+}
+
+type fakeStock struct {
+	stock.Repo
+	moves []stock.Movement
+}
+
+func (r *fakeStock) Append(ctx context.Context, ms ...stock.Movement) error {
+	r.moves = append(r.moves, ms...)
+	return nil
+}
+
+const (
+	uid     = "user-1"
+	admin   = "admin-1"
+	orderID = "order-1"
+)
+
+func kzt(minor int64) money.Money { return money.New(minor, money.Default) }
+
+type fixture struct {
+	svc      *returnssvc.Service
+	returns  *fakeReturns
+	orders   *fakeOrders
+	payments *fakePayments
+	products *fakeProducts
+	stock    *fakeStock
+}
+
+// newFixture has a delivered order of 2 mice at 30 and a keyboard at 40,
+// bought with a 10 discount and paid 95 with delivery: the customer paid
+// 90% of every line.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		returns: &fakeReturns{byID: map[string]returns.Return{}},
+		orders: &fakeOrders{byID: map[string]orders.Order{
+			orderID: {
+				ID:            orderID,
+				UserID:        uid,
+				Status:        orders.StatusDelivered,
+				PaymentStatus: orders.PaymentPaid,
+				Items: []orders.Item{
+					{ProductID: "mouse", ProductName: "Mouse", Quantity: 2, UnitPrice: kzt(3000), LineTotal: kzt(6000)},
+					{ProductID: "keyboard", ProductName: "Keyboard", Quantity: 1, UnitPrice: kzt(4000), LineTotal: kzt(4000)},
+				},
+				Subtotal:   kzt(10000),
+				Discounts:  []orders.Discount{{Code: "SAVE10", Amount: kzt(1000)}},
+				TotalPrice: kzt(9500),
+			},
+		}},
+		products: &fakeProducts{restocked: map[string]int64{}, sold: map[string]int64{}},
+		stock:    &fakeStock{},
+	}
+	f.payments = &fakePayments{orders: f.orders}
+	f.svc = returnssvc.New(f.returns, f.orders, f.payments, f.products, f.stock, memrepo.NewUnitOfWork())
+	return f
+}
+
+func (f *fixture) open(t *testing.T, items ...returns.Item) returns.Return {
+	t.Helper()
+	r, err := f.svc.Create(context.Background(), orderID, uid, returns.CreateInput{Items: items, Reason: "changed my mind"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestCreateProratesRefund(t *testing.T) {
+	f := newFixture(t)
+
+	r := f.open(t, returns.Item{ProductID: "mouse", Quantity: 1}, returns.Item{ProductID: "keyboard", Quantity: 1})
+	if r.Status != returns.StatusRequested || len(r.Items) != 2 {
+		t.Fatalf("return = %+v, want a requested return of two lines", r)
+	}
+	// 30 * 0.9 and 40 * 0.9; the delivery fee is not refunded
+	if r.Items[0].RefundAmount != kzt(2700) || r.Items[1].RefundAmount != kzt(3600) || r.RefundAmount != kzt(6300) {
+		t.Errorf("refunds %v + %v = %v, want 27 + 36 = 63", r.Items[0].RefundAmount, r.Items[1].RefundAmount, r.RefundAmount)
+	}
+}
+
+func TestCreateRefundsAddedTax(t *testing.T) {
+	f := newFixture(t)
+	o := f.orders.byID[orderID]
+	// 12% added on the discounted 90, so the goods cost 100.8 and each line
+	// is refunded at 100.8%
+	o.Taxes = []orders.TaxLine{{RuleID: "vat", Name: "VAT", Rate: 12, Base: kzt(9000), Amount: kzt(1080)}}
+	o.TotalPrice = kzt(10580)
+	f.orders.byID[orderID] = o
+
+	r := f.open(t, returns.Item{ProductID: "mouse", Quantity: 2}, returns.Item{ProductID: "keyboard", Quantity: 1})
+	if r.RefundAmount != kzt(10080) {
+		t.Errorf("refund = %v, want the 100.80 paid for the goods", r.RefundAmount)
+	}
+}
+
+func TestCreateRejects(t *testing.T) {
+	mouse := func(qty int64) returns.Item { return returns.Item{ProductID: "mouse", Quantity: qty} }
+	tests := []struct {
+		name   string
+		edit   func(f *fixture)
+		items  []returns.Item
+		reason string
+		want   error
+	}{
+		{"no reason", nil, []returns.Item{mouse(1)}, " ", returns.ErrInvalidReason},
+		{"no items", nil, nil, "broken", returns.ErrInvalidItems},
+		{"zero quantity", nil, []returns.Item{mouse(0)}, "broken", returns.ErrInvalidQty},
+		{"not on the order", nil, []returns.Item{{ProductID: "monitor", Quantity: 1}}, "broken", returns.ErrInvalidProduct},
+		{"more than bought", nil, []returns.Item{mouse(3)}, "broken", returns.ErrQtyExceeded},
+		{"more than bought across lines", nil, []returns.Item{mouse(2), mouse(1)}, "broken", returns.ErrQtyExceeded},
+		{"not delivered yet", func(f *fixture) {
+			o := f.orders.byID[orderID]
+			o.Status = orders.StatusShipped
+			f.orders.byID[orderID] = o
+		}, []returns.Item{mouse(1)}, "broken", returns.ErrNotReturnable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.edit != nil {
+				tt.edit(f)
+			}
+			_, err := f.svc.Create(context.Background(), orderID, uid, returns.CreateInput{Items: tt.items, Reason: tt.reason})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Create = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	f := newFixture(t)
+	if _, err := f.svc.Create(context.Background(), orderID, "someone-else", returns.CreateInput{Items: []returns.Item{mouse(1)}, Reason: "broken"}); !errors.Is(err, orders.ErrNotFound) {
+		t.Errorf("Create on another user's order = %v, want %v", err, orders.ErrNotFound)
+	}
+}
+
+func TestCreateCountsEarlierReturns(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	mouse := returns.Item{ProductID: "mouse", Quantity: 1}
+
+	first := f.open(t, mouse)
+	f.open(t, mouse)
+	if _, err := f.svc.Create(ctx, orderID, uid, returns.CreateInput{Items: []returns.Item{mouse}, Reason: "again"}); !errors.Is(err, returns.ErrQtyExceeded) {
+		t.Fatalf("third mouse = %v, want %v", err, returns.ErrQtyExceeded)
+	}
+
+	// a rejected return frees its quantity again
+	if _, err := f.svc.Reject(ctx, first.ID, admin, returns.ReviewInput{Note: "worn"}); err != nil {
+		t.Fatal(err)
+	}
+	f.open(t, mouse)
+}
+
+func TestTransitions(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	r := f.open(t, returns.Item{ProductID: "mouse", Quantity: 2})
+
+	if _, err := f.svc.Receive(ctx, r.ID, admin, returns.ReceiveInput{}); !errors.Is(err, returns.ErrInvalidTransition) {
+		t.Fatalf("Receive before approval = %v, want %v", err, returns.ErrInvalidTransition)
+	}
+	if _, err := f.svc.Approve(ctx, r.ID, admin, returns.ReviewInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.Reject(ctx, r.ID, admin, returns.ReviewInput{}); !errors.Is(err, returns.ErrInvalidTransition) {
+		t.Fatalf("Reject after approval = %v, want %v", err, returns.ErrInvalidTransition)
+	}
+	if len(f.payments.refunds) != 0 {
+		t.Fatal("refunded before the goods came back")
+	}
+
+	got, err := f.svc.Receive(ctx, r.ID, admin, returns.ReceiveInput{Restock: true, Note: "unopened"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != returns.StatusReceived || !got.Restocked || len(got.StatusHistory) != 3 {
+		t.Errorf("return = %s restocked=%v with %d history entries", got.Status, got.Restocked, len(got.StatusHistory))
+	}
+	if f.products.restocked["mouse"] != 2 {
+		t.Errorf("restocked %d mice, want 2", f.products.restocked["mouse"])
+	}
+	if f.products.sold["mouse"] != -2 {
+		t.Errorf("sold changed by %d mice, want -2", f.products.sold["mouse"])
+	}
+	if m := f.stock.moves; len(m) != 1 || m[0].ProductID != "mouse" || m[0].Delta != 2 || m[0].Reason != stock.ReasonReturn || m[0].Reference != r.ID || m[0].ActorID != admin {
+		t.Errorf("movements = %+v, want +2 mice for the return", m)
+	}
+	if len(f.payments.refunds) != 1 || f.payments.refunds[0].Amount != kzt(5400) || f.payments.refunds[0].ReturnID != r.ID {
+		t.Errorf("refunds = %+v, want 54 for %s", f.payments.refunds, r.ID)
+	}
+
+	if _, err := f.svc.Receive(ctx, r.ID, admin, returns.ReceiveInput{}); !errors.Is(err, returns.ErrInvalidTransition) {
+		t.Errorf("second Receive = %v, want %v", err, returns.ErrInvalidTransition)
+	}
+}
+
+func TestReceiveWithoutRestock(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	r := f.open(t, returns.Item{ProductID: "keyboard", Quantity: 1})
+	if _, err := f.svc.Approve(ctx, r.ID, admin, returns.ReviewInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := f.svc.Receive(ctx, r.ID, admin, returns.ReceiveInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Restocked || len(f.products.restocked) != 0 || len(f.products.sold) != 0 || len(f.stock.moves) != 0 {
+		t.Errorf("restocked %v without being asked to", f.products.restocked)
+	}
+	if len(f.payments.refunds) != 1 || f.payments.refunds[0].Amount != kzt(3600) {
+		t.Errorf("refunds = %+v, want 36", f.payments.refunds)
+	}
+}
+
+func TestReceiveCapsRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment orders.PaymentStatus
+		earlier []money.Money
+		want    money.Money // zero for no refund
+	}{
+		{"nothing refunded yet", orders.PaymentPaid, nil, kzt(5400)},
+		{"after another return", orders.PaymentPaid, []money.Money{kzt(3600)}, kzt(5400)},
+		{"only part left", orders.PaymentPaid, []money.Money{kzt(9000)}, kzt(500)},
+		{"refunded in full", orders.PaymentRefunded, []money.Money{kzt(9500)}, money.Money{}},
+		{"never paid", orders.PaymentUnpaid, nil, money.Money{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t)
+			o := f.orders.byID[orderID]
+			o.PaymentStatus = tt.payment
+			for _, amt := range tt.earlier {
+				o.Refunds = append(o.Refunds, orders.Refund{Amount: amt})
+			}
+			f.orders.byID[orderID] = o
+
+			r := f.open(t, returns.Item{ProductID: "mouse", Quantity: 2})
+			if _, err := f.svc.Approve(ctx, r.ID, admin, returns.ReviewInput{}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.svc.Receive(ctx, r.ID, admin, returns.ReceiveInput{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != returns.StatusReceived {
+				t.Errorf("return %s, want received", got.Status)
+			}
+
+			if tt.want.IsZero() {
+				if len(f.payments.refunds) != 0 {
+					t.Errorf("refunds = %+v, want none", f.payments.refunds)
+				}
+				return
+			}
+			if rs := f.payments.refunds; len(rs) != 1 || rs[0].Amount != tt.want || rs[0].ReturnID != r.ID {
+				t.Errorf("refunds = %+v, want %v for %s", rs, tt.want, r.ID)
+			}
+			if left := f.orders.byID[orderID].TotalPrice.Sub(f.orders.byID[orderID].Refunded()); left.IsNegative() {
+				t.Errorf("refunded %v more than was paid", left.Neg())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package stocksvc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+)
+
+type Service struct {
+	repo stock.Repo
+}
+
+func New(repo stock.Repo) *Service {
+	return &Service{repo: repo}
+}
+
+var _ stock.Service = (*Service)(nil)
+
+func (s *Service) History(ctx context.Context, f stock.ListFilter) ([]stock.Movement, int64, error) {
+	f.ProductID = strings.TrimSpace(f.ProductID)
+	if f.ProductID == "" {
+		return nil, 0, stock.ErrInvalidID
+	}
+
+	list, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (s *Service) Check(ctx context.Context, productID string) (stock.Reconciliation, error) {
+	pid := strings.TrimSpace(productID)
+	if pid == "" {
+		return stock.Reconciliation{}, stock.ErrInvalidID
+	}
+
+	recs, err := s.repo.Reconcile(ctx, pid)
+	if err != nil {
+		return stock.Reconciliation{}, err
+	}
+	return recs[0], nil
+}
+
+func (s *Service) Reconcile(ctx context.Context, all bool) ([]stock.Reconciliation, error) {
+	recs, err := s.repo.Reconcile(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return recs, nil
+	}
+
+	out := make([]stock.Reconciliation, 0)
+	for _, r := range recs {
+		if !r.Balanced() {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
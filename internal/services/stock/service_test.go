@@ -0,0 +1,127 @@
+package stocksvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/stock"
+	stocksvc "github.com/bnursik/aitu-ad-final-back/internal/services/stock"
+)
+
+// fakeRepo embeds the interface so only the read methods need writing.
+type fakeRepo struct {
+	stock.Repo
+	moves []stock.Movement
+	recs  []stock.Reconciliation
+}
+
+func (r *fakeRepo) List(ctx context.Context, f stock.ListFilter) ([]stock.Movement, error) {
+	var out []stock.Movement
+	for _, m := range r.moves {
+		if m.ProductID == f.ProductID {
+			out = append(out, m)
+		}
+	}
+	if f.Offset >= int64(len(out)) {
+		return nil, nil
+	}
+	out = out[f.Offset:]
+	if f.Limit < int64(len(out)) {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) Count(ctx context.Context, f stock.ListFilter) (int64, error) {
+	n := int64(0)
+	for _, m := range r.moves {
+		if m.ProductID == f.ProductID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeRepo) Reconcile(ctx context.Context, productID string) ([]stock.Reconciliation, error) {
+	if productID == "" {
+		return r.recs, nil
+	}
+	for _, rec := range r.recs {
+		if rec.ProductID == productID {
+			return []stock.Reconciliation{rec}, nil
+		}
+	}
+	return nil, stock.ErrNotFound
+}
+
+func newService() (*stocksvc.Service, *fakeRepo) {
+	repo := &fakeRepo{
+		moves: []stock.Movement{
+			{ProductID: "mouse", Delta: -1, Reason: stock.ReasonOrder},
+			{ProductID: "keyboard", Delta: 4, Reason: stock.ReasonAdjustment},
+			{ProductID: "mouse", Delta: 10, Reason: stock.ReasonAdjustment},
+		},
+		recs: []stock.Reconciliation{
+			{ProductID: "mouse", Stock: 9, Ledger: 9, Movements: 2},
+			{ProductID: "keyboard", Stock: 6, Ledger: 4, Movements: 1},
+		},
+	}
+	return stocksvc.New(repo), repo
+}
+
+func TestHistory(t *testing.T) {
+	svc, _ := newService()
+
+	list, total, err := svc.History(context.Background(), stock.ListFilter{ProductID: " mouse ", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || total != 2 {
+		t.Errorf("got %d of %d movements, want 1 of 2", len(list), total)
+	}
+
+	if _, _, err := svc.History(context.Background(), stock.ListFilter{ProductID: " ", Limit: 1}); !errors.Is(err, stock.ErrInvalidID) {
+		t.Errorf("History without a product = %v, want %v", err, stock.ErrInvalidID)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	svc, _ := newService()
+	ctx := context.Background()
+
+	r, err := svc.Check(ctx, "keyboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Drift() != 2 {
+		t.Errorf("keyboard drift = %d, want 2", r.Drift())
+	}
+	if _, err := svc.Check(ctx, "monitor"); !errors.Is(err, stock.ErrNotFound) {
+		t.Errorf("Check of an unknown product = %v, want %v", err, stock.ErrNotFound)
+	}
+	if _, err := svc.Check(ctx, ""); !errors.Is(err, stock.ErrInvalidID) {
+		t.Errorf("Check without a product = %v, want %v", err, stock.ErrInvalidID)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	svc, _ := newService()
+	ctx := context.Background()
+
+	drifted, err := svc.Reconcile(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifted) != 1 || drifted[0].ProductID != "keyboard" {
+		t.Errorf("drifted = %+v, want only the keyboard", drifted)
+	}
+
+	all, err := svc.Reconcile(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("all = %+v, want both products", all)
+	}
+}
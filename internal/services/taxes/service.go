@@ -0,0 +1,85 @@
+package taxessvc
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+)
+
+type Service struct {
+	repo taxes.Repo
+	now  func() time.Time
+}
+
+func New(repo taxes.Repo) *Service {
+	return &Service{
+		repo: repo,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+var _ taxes.Service = (*Service)(nil)
+
+func (s *Service) List(ctx context.Context, f taxes.ListFilter) ([]taxes.Rule, error) {
+	return s.repo.List(ctx, f)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (taxes.Rule, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) Create(ctx context.Context, in taxes.CreateInput) (taxes.Rule, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return taxes.Rule{}, taxes.ErrInvalidName
+	}
+	if !validRate(in.Rate) {
+		return taxes.Rule{}, taxes.ErrInvalidRate
+	}
+
+	now := s.now()
+	r := taxes.Rule{
+		Name:       name,
+		Rate:       in.Rate,
+		Inclusive:  in.Inclusive,
+		CategoryID: strings.TrimSpace(in.CategoryID),
+		Country:    strings.TrimSpace(in.Country),
+		Region:     strings.TrimSpace(in.Region),
+		Active:     in.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	return s.repo.Create(ctx, r)
+}
+
+func (s *Service) Update(ctx context.Context, id string, in taxes.UpdateInput) (taxes.Rule, error) {
+	if in.Name != nil {
+		n := strings.TrimSpace(*in.Name)
+		if n == "" {
+			return taxes.Rule{}, taxes.ErrInvalidName
+		}
+		in.Name = &n
+	}
+	if in.Rate != nil && !validRate(*in.Rate) {
+		return taxes.Rule{}, taxes.ErrInvalidRate
+	}
+	for _, f := range []*string{in.CategoryID, in.Country, in.Region} {
+		if f != nil {
+			*f = strings.TrimSpace(*f)
+		}
+	}
+
+	return s.repo.Update(ctx, id, in)
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// validRate accepts percentages from 0 (an exemption) to 100.
+func validRate(r float64) bool {
+	return !math.IsNaN(r) && r >= 0 && r <= 100
+}
@@ -0,0 +1,90 @@
+package taxessvc_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bnursik/aitu-ad-final-back/internal/domain/taxes"
+	taxessvc "github.com/bnursik/aitu-ad-final-back/internal/services/taxes"
+)
+
+// fakeRepo embeds the interface so only Create and Update need writing.
+type fakeRepo struct {
+	taxes.Repo
+	created []taxes.Rule
+	updated []taxes.UpdateInput
+}
+
+func (r *fakeRepo) Create(ctx context.Context, rule taxes.Rule) (taxes.Rule, error) {
+	rule.ID = "rule-1"
+	r.created = append(r.created, rule)
+	return rule, nil
+}
+
+func (r *fakeRepo) Update(ctx context.Context, id string, in taxes.UpdateInput) (taxes.Rule, error) {
+	r.updated = append(r.updated, in)
+	return taxes.Rule{ID: id}, nil
+}
+
+func TestCreateValidates(t *testing.T) {
+	valid := taxes.CreateInput{Name: " VAT ", Rate: 12, Country: " KZ ", Active: true}
+
+	tests := []struct {
+		name string
+		edit func(in *taxes.CreateInput)
+		want error
+	}{
+		{"valid", func(in *taxes.CreateInput) {}, nil},
+		{"exemption", func(in *taxes.CreateInput) { in.Rate = 0 }, nil},
+		{"blank name", func(in *taxes.CreateInput) { in.Name = "  " }, taxes.ErrInvalidName},
+		{"negative rate", func(in *taxes.CreateInput) { in.Rate = -1 }, taxes.ErrInvalidRate},
+		{"over 100", func(in *taxes.CreateInput) { in.Rate = 100.5 }, taxes.ErrInvalidRate},
+		{"NaN", func(in *taxes.CreateInput) { in.Rate = math.NaN() }, taxes.ErrInvalidRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			in := valid
+			tt.edit(&in)
+
+			_, err := taxessvc.New(repo).Create(context.Background(), in)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Create = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(repo.created) != 0 {
+					t.Error("invalid rule was stored")
+				}
+				return
+			}
+			if r := repo.created[0]; r.Name != "VAT" || r.Country != "KZ" {
+				t.Errorf("stored name %q country %q, want them trimmed", r.Name, r.Country)
+			}
+		})
+	}
+}
+
+func TestUpdateTrims(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := taxessvc.New(repo)
+	ctx := context.Background()
+
+	blank := " "
+	if _, err := svc.Update(ctx, "rule-1", taxes.UpdateInput{Name: &blank}); !errors.Is(err, taxes.ErrInvalidName) {
+		t.Fatalf("blank name: %v, want ErrInvalidName", err)
+	}
+	rate := 120.0
+	if _, err := svc.Update(ctx, "rule-1", taxes.UpdateInput{Rate: &rate}); !errors.Is(err, taxes.ErrInvalidRate) {
+		t.Fatalf("rate 120: %v, want ErrInvalidRate", err)
+	}
+
+	region := " Almaty "
+	if _, err := svc.Update(ctx, "rule-1", taxes.UpdateInput{Region: &region}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.updated) != 1 || *repo.updated[0].Region != "Almaty" {
+		t.Errorf("updates = %+v, want one with the region trimmed", repo.updated)
+	}
+}
@@ -44,7 +44,7 @@ func (s *Service) Add(ctx context.Context, userID string, in wishlist.AddItemInp
 		}
 		return wishlist.WishlistItem{}, err
 	}
-	if prod.Stock < 1 {
+	if prod.Available() < 1 {
 		return wishlist.WishlistItem{}, wishlist.ErrProductOutOfStock
 	}
 